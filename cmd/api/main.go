@@ -1,12 +1,32 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"vpp-go/internal/audit"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/collectors"
 	"vpp-go/internal/config"
 	"vpp-go/internal/database"
+	"vpp-go/internal/dispatch"
+	"vpp-go/internal/events"
+	"vpp-go/internal/forecast"
 	"vpp-go/internal/handlers"
+	"vpp-go/internal/httpclient"
+	"vpp-go/internal/market"
+	"vpp-go/internal/models"
+	"vpp-go/internal/scheduler"
+	"vpp-go/internal/stream"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -14,13 +34,26 @@ import (
 )
 
 func main() {
+	if err := run(); err != nil {
+		log.Fatalf("%v", err)
+	}
+}
+
+// run 啟動服務器，收到終止信號時停止接收新請求、等待進行中的請求與任務結束後返回
+func run() error {
 	// 初始化配置
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("載入配置失敗: %w", err)
+	}
+
+	// 配置對外HTTP請求（重試、退避、熔斷）
+	httpclient.SetDefault(httpclient.New(httpclient.Config(cfg.Outbound)))
 
 	// 初始化資料庫連接
 	db, err := database.InitDB(cfg)
 	if err != nil {
-		log.Fatalf("無法連接資料庫: %v", err)
+		return fmt.Errorf("無法連接資料庫: %w", err)
 	}
 	defer db.Close()
 
@@ -29,20 +62,82 @@ func main() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	// 創建路由
-	r := gin.Default()
+	// 創建路由，存取日誌不記錄網址中的權杖
+	r := gin.New()
+	r.Use(gin.LoggerWithConfig(gin.LoggerConfig{Formatter: accessLogFormatter}), gin.Recovery())
 
 	// 配置CORS
-	r.Use(cors.New(cors.Config{
-		AllowAllOrigins:  true,
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-	}))
+	corsCfg, err := corsConfig(cfg.Auth.AllowedOrigins)
+	if err != nil {
+		return fmt.Errorf("CORS_ALLOWED_ORIGINS 配置錯誤: %w", err)
+	}
+	r.Use(cors.New(corsCfg))
 
 	// 創建處理器
 	h := handlers.NewHandler(db)
+	h.Sites = cfg.Sites
+
+	// 閘道器以 API 金鑰、使用者以 JWT 認證
+	h.AuthEnabled = cfg.Auth.Enabled
+	if cfg.Auth.Enabled {
+		tokens, err := auth.NewJWT(cfg.Auth.JWTSecret, cfg.Auth.JWTIssuer, cfg.Auth.JWTAudience)
+		if err != nil {
+			return fmt.Errorf("認證初始化失敗（可設定 AUTH_ENABLED=false 關閉認證）: %w", err)
+		}
+		h.Tokens = tokens
+		h.StreamTickets = auth.NewTickets(cfg.Stream.TicketTTL)
+	} else {
+		log.Println("警告: 認證已關閉，所有路由皆可匿名存取")
+	}
+
+	taipower, err := collectors.NewTaipowerCollectorFromConfig(db, cfg.External)
+	if err != nil {
+		return fmt.Errorf("台電收集器初始化失敗: %w", err)
+	}
+	taipower.Location = cfg.App.Timezone
+	h.TaipowerCollector = taipower
+
+	transport, err := dispatch.NewTransport(cfg.Dispatch.Transport, cfg.Sites, httpclient.Default())
+	if err != nil {
+		return fmt.Errorf("派遣傳送方式初始化失敗: %w", err)
+	}
+	dispatcher := dispatch.NewDispatcher(db, transport, cfg.Dispatch)
+	h.Dispatcher = dispatcher
+	h.BidPlanner = market.NewPlanner(db, cfg.Market, cfg.Sites, cfg.App.Timezone)
+
+	// 收集器與上傳發佈的事件經匯流排分送給訂閱者
+	bus := events.NewBus()
+	h.Events = bus
+	h.Ingester.Events = bus
+	taipower.Events = bus
+
+	// 新寫入的數據推送給即時訂閱者
+	hub := stream.NewHub(cfg.Stream)
+	if err := hub.Attach(bus, cfg.Events); err != nil {
+		return fmt.Errorf("即時推送初始化失敗: %w", err)
+	}
+	h.StreamHub = hub
+	h.StreamHeartbeat = cfg.Stream.Heartbeat
+	h.AllowedOrigins = cfg.Auth.AllowedOrigins
+
+	// 服務器關閉時結束閘道器的長輪詢
+	done := make(chan struct{})
+	h.Done = done
+
+	// 啟動數據收集排程
+	var sched *scheduler.Scheduler
+	if cfg.Scheduler.Enabled {
+		sched, err = newScheduler(cfg, db, taipower, dispatcher, bus)
+		if err != nil {
+			return fmt.Errorf("排程器初始化失敗: %w", err)
+		}
+		sched.Start()
+		h.Scheduler = sched
+	}
+
+	// 存活與就緒檢查
+	r.GET("/healthz", h.Healthz)
+	r.GET("/readyz", h.Readyz)
 
 	// 根路由
 	r.GET("/", func(c *gin.Context) {
@@ -51,21 +146,34 @@ func main() {
 			"version": "1.0.0",
 			"endpoints": gin.H{
 				"upload":   "/api/upload",
+				"batch":    "/api/upload/batch",
+				"devices":  "/api/devices/:device_id/commands",
 				"vpp":      "/api/vpp/*",
 				"taipower": "/api/taipower/*",
+				"market":   "/api/market/*",
+				"admin":    "/api/admin/*",
+				"healthz":  "/healthz",
+				"readyz":   "/readyz",
 			},
 		})
 	})
 
-	// API路由組
-	api := r.Group("/api")
+	// API路由組，寫入與控制請求皆記入稽核記錄
+	api := r.Group("/api", h.Audit())
 	{
-		// 樹莓派數據上傳
-		api.POST("/upload", h.UploadData)
+		// 樹莓派數據上傳（API 金鑰）
+		upload := api.Group("/upload", h.RequireDevice())
+		{
+			upload.POST("", h.UploadData)
+			upload.POST("/batch", h.UploadBatch)
+		}
 
 		// VPP路由
-		vpp := api.Group("/vpp")
+		vpp := api.Group("/vpp", h.RequireUser())
 		{
+			// 場站列表
+			vpp.GET("/sites", h.GetSites)
+
 			// 即時數據
 			vpp.GET("/realdata", h.GetAllRealtimeData)
 			vpp.GET("/realdata/:site_id", h.GetSiteRealtimeData)
@@ -73,17 +181,32 @@ func main() {
 			// 太陽能數據
 			vpp.GET("/solar/latest", h.GetLatestSolarData)
 			vpp.GET("/solar/history", h.GetSolarHistory)
+			vpp.GET("/solar/forecast", h.GetSolarForecast)
 
 			// 負載數據
 			vpp.GET("/load/latest", h.GetLatestLoadData)
 			vpp.GET("/load/history", h.GetLoadHistory)
+			vpp.GET("/load/forecast", h.GetLoadForecast)
+
+			// 儲能數據
+			vpp.GET("/storage/latest", h.GetLatestStorageData)
+			vpp.GET("/storage/history", h.GetStorageHistory)
+
+			// 派遣指令
+			vpp.POST("/dispatch", h.CreateDispatch)
+			vpp.GET("/dispatch", h.ListDispatches)
+			vpp.GET("/dispatch/:id", h.GetDispatch)
 
 			// 統計彙總
 			vpp.GET("/summary", h.GetSummary)
+
+			// 即時數據推送（WebSocket / SSE）
+			vpp.GET("/stream", h.StreamTelemetry)
+			vpp.POST("/stream/ticket", h.IssueStreamTicket)
 		}
 
 		// 台電備轉資料路由
-		taipower := api.Group("/taipower")
+		taipower := api.Group("/taipower", h.RequireUser())
 		{
 			reserve := taipower.Group("/reserve")
 			{
@@ -94,17 +217,230 @@ func main() {
 				reserve.GET("/hour", h.GetReserveByHour)
 			}
 		}
-	}
 
-	// 獲取端口
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+		// 電力市場路由
+		marketGroup := api.Group("/market", h.RequireUser())
+		{
+			marketGroup.POST("/revenue", h.CalculateRevenue)
+			marketGroup.POST("/bid-plan", h.CreateBidPlan)
+			marketGroup.GET("/bid-plan", h.ListBidPlans)
+			marketGroup.GET("/bid-plan/:id", h.GetBidPlan)
+		}
+
+		// 閘道器指令（長輪詢，API 金鑰）
+		devices := api.Group("/devices/:device_id", h.RequireDevice())
+		{
+			devices.GET("/commands", h.PollDeviceCommands)
+			devices.POST("/commands/ack", h.AckDeviceCommands)
+		}
+
+		// 管理路由
+		admin := api.Group("/admin", h.RequireUser(), h.RequirePermission(auth.PermAdmin))
+		{
+			admin.GET("/jobs", h.GetJobs)
+			admin.GET("/jobs/:name", h.GetJob)
+			admin.POST("/jobs/:name/run", h.RunJob)
+			admin.GET("/events", h.GetEventSubscribers)
+			admin.GET("/audit", h.GetAuditLog)
+			admin.POST("/taipower/backfill", h.BackfillReserve)
+			admin.POST("/devices/:device_id/commands", h.EnqueueDeviceCommand)
+			admin.POST("/api-keys", h.CreateAPIKey)
+			admin.GET("/api-keys", h.ListAPIKeys)
+			admin.DELETE("/api-keys/:id", h.RevokeAPIKey)
+		}
 	}
 
 	// 啟動服務器
-	log.Printf("VPP API 服務器啟動於端口 %s\n", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("服務器啟動失敗: %v", err)
+	srv := &http.Server{
+		Addr:              ":" + cfg.App.Port,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	srv.RegisterOnShutdown(func() { close(done) })
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("VPP API 服務器啟動於端口 %s\n", cfg.App.Port)
+	errCh := make(chan error, 1)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+	}()
+
+	// 等待終止信號
+	var serveErr error
+	select {
+	case serveErr = <-errCh:
+		log.Printf("服務器異常結束: %v", serveErr)
+	case <-ctx.Done():
+		log.Println("收到終止信號，正在關閉...")
+	}
+	// 再次收到信號時直接結束程序
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.App.ShutdownTimeout)
+	defer cancel()
+
+	// 停止接收新請求並等待進行中的請求完成
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Printf("服務器關閉逾時: %v", err)
+	}
+
+	if sched != nil {
+		if err := sched.Stop(shutdownCtx); err != nil {
+			log.Printf("排程器停止失敗: %v", err)
+		}
 	}
+
+	// 請求已全部結束，不會再有新指令，等待背景送出結束
+	if err := dispatcher.Stop(shutdownCtx); err != nil {
+		log.Printf("派遣器停止失敗: %v", err)
+	}
+
+	// 排程任務結束後不再有新事件，等待訂閱者處理完剩餘事件
+	if err := bus.Close(shutdownCtx); err != nil {
+		log.Printf("事件匯流排關閉失敗: %v", err)
+	}
+
+	log.Println("服務器已關閉")
+	return serveErr
+}
+
+// sensitiveParams 存取日誌中須遮蔽的網址參數
+var sensitiveParams = []string{"access_token", "ticket"}
+
+// accessLogFormatter 與 gin 預設格式相同，但遮蔽網址中的權杖及票證
+func accessLogFormatter(param gin.LogFormatterParams) string {
+	if param.Latency > time.Minute {
+		param.Latency = param.Latency.Truncate(time.Second)
+	}
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v\n%s",
+		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		param.Method,
+		redactQuery(param.Path),
+		param.ErrorMessage,
+	)
+}
+
+// redactQuery 將路徑中敏感參數的值換成 REDACTED
+func redactQuery(path string) string {
+	i := strings.IndexByte(path, '?')
+	if i < 0 {
+		return path
+	}
+	query, err := url.ParseQuery(path[i+1:])
+	if err != nil {
+		return path[:i] + "?REDACTED"
+	}
+	redacted := false
+	for _, name := range sensitiveParams {
+		if _, ok := query[name]; ok {
+			query[name] = []string{"REDACTED"}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return path
+	}
+	return path[:i+1] + query.Encode()
+}
+
+// corsConfig 依允許的來源建立CORS配置，未設定時拒絕所有跨來源請求
+func corsConfig(origins []string) (cors.Config, error) {
+	cfg := cors.Config{
+		AllowMethods:  []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:  []string{"Origin", "Content-Type", "Authorization", "X-API-Key", "Idempotency-Key", "Last-Event-ID"},
+		ExposeHeaders: []string{"Content-Length", "Content-Disposition"},
+	}
+
+	for _, origin := range origins {
+		if origin == "*" {
+			// 允許所有來源時不可攜帶憑證
+			cfg.AllowAllOrigins = true
+			return cfg, nil
+		}
+	}
+	if len(origins) == 0 {
+		cfg.AllowOriginFunc = func(string) bool { return false }
+		return cfg, nil
+	}
+
+	cfg.AllowOrigins = origins
+	cfg.AllowCredentials = true
+	return cfg, cfg.Validate()
+}
+
+// newScheduler 創建排程器並註冊所有數據收集器
+func newScheduler(cfg *config.Config, db *sql.DB, taipower *collectors.TaipowerCollector, dispatcher *dispatch.Dispatcher, bus *events.Bus) (*scheduler.Scheduler, error) {
+	sched := scheduler.New(cfg.App.Timezone)
+	sched.Events = bus
+	// 任務的執行結果同步寫入稽核記錄
+	sched.Recorder = audit.NewJobRecorder(models.NewAuditModel(db))
+
+	// 每個場站一個太陽能收集器，依場站時區解析排程
+	for _, site := range cfg.Sites {
+		if site.APIURL == "" {
+			log.Printf("場站 %s 未配置API URL，略過太陽能收集器\n", site.ID)
+			continue
+		}
+
+		solar := collectors.NewSiteSolarCollector(db, site)
+		solar.Events = bus
+		spec := "CRON_TZ=" + site.Timezone.String() + " " + site.PollSchedule
+		if err := sched.Register(spec, solar); err != nil {
+			return nil, err
+		}
+	}
+
+	// 每個場站一個太陽能預測任務
+	for _, site := range cfg.Sites {
+		spec := "CRON_TZ=" + site.Timezone.String() + " " + cfg.Forecast.SolarCron
+		if err := sched.Register(spec, forecast.NewSolarForecaster(db, site, cfg.Forecast)); err != nil {
+			return nil, err
+		}
+	}
+
+	// 每個場站一個負載預測任務，共用國定假日行事曆
+	calendar, err := forecast.NewCalendar(cfg.Forecast.Holidays, cfg.Forecast.MakeupWorkdays)
+	if err != nil {
+		return nil, err
+	}
+	// 缺少假日資料時負載預測任務會失敗，啟動時先提醒
+	if err := calendar.CheckCoverage(time.Now().AddDate(0, 0, -cfg.Forecast.TrainingDays), time.Now().Add(forecast.LoadHorizon)); err != nil {
+		log.Printf("警告: %v，負載預測任務將會失敗\n", err)
+	}
+	for _, site := range cfg.Sites {
+		spec := "CRON_TZ=" + site.Timezone.String() + " " + cfg.Forecast.LoadCron
+		if err := sched.Register(spec, forecast.NewLoadForecaster(db, site, calendar, cfg.Forecast)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := sched.Register(cfg.Scheduler.TaipowerCron, taipower); err != nil {
+		return nil, err
+	}
+
+	if err := sched.Register(cfg.Dispatch.Cron, dispatcher); err != nil {
+		return nil, err
+	}
+
+	idempotency := models.NewIdempotencyModel(db)
+	cleanup := scheduler.NewFuncJob("cleanup:idempotency", func(ctx context.Context) error {
+		deleted, err := idempotency.DeleteBefore(time.Now().Add(-cfg.Scheduler.IdempotencyTTL))
+		if err != nil {
+			return err
+		}
+		log.Printf("已清理過期冪等請求記錄: %d 筆\n", deleted)
+		return nil
+	})
+	if err := sched.Register(cfg.Scheduler.CleanupCron, cleanup); err != nil {
+		return nil, err
+	}
+
+	return sched, nil
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRedactQuery(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/vpp/stream", "/api/vpp/stream"},
+		{"/api/vpp/stream?site_id=north", "/api/vpp/stream?site_id=north"},
+		{"/api/vpp/stream?access_token=eyJ.abc.def&site_id=north", "/api/vpp/stream?access_token=REDACTED&site_id=north"},
+		{"/api/vpp/stream?ticket=xyz", "/api/vpp/stream?ticket=REDACTED"},
+		{"/api/vpp/stream?access_token=%zz", "/api/vpp/stream?REDACTED"},
+	}
+	for _, tt := range tests {
+		if got := redactQuery(tt.path); got != tt.want {
+			t.Errorf("redactQuery(%q) = %q, 預期 %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestAccessLogFormatter(t *testing.T) {
+	line := accessLogFormatter(gin.LogFormatterParams{
+		TimeStamp:  time.Now(),
+		StatusCode: 200,
+		Method:     "GET",
+		Path:       "/api/vpp/stream?access_token=secret-token",
+	})
+	if strings.Contains(line, "secret-token") {
+		t.Errorf("日誌含權杖: %s", line)
+	}
+}
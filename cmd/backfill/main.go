@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+	"vpp-go/internal/collectors"
+	"vpp-go/internal/config"
+	"vpp-go/internal/database"
+	"vpp-go/internal/httpclient"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	os.Exit(run())
+}
+
+// run 執行回補並返回結束代碼，有日期回補失敗、被中斷或發生錯誤時返回 1
+// 以返回代替 os.Exit，確保延遲的資料庫關閉與訊號處理得以執行
+func run() int {
+	yesterday := time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+
+	startStr := flag.String("start", yesterday, "開始日期 (YYYY-MM-DD)")
+	endStr := flag.String("end", yesterday, "結束日期 (YYYY-MM-DD)")
+	concurrency := flag.Int("concurrency", 2, "同時抓取的天數上限")
+	interval := flag.Duration("interval", 2*time.Second, "兩次請求之間的最小間隔")
+	dryRun := flag.Bool("dry-run", false, "只列出缺漏的日期，不實際抓取")
+	flag.Parse()
+
+	startDate, err := time.Parse("2006-01-02", *startStr)
+	if err != nil {
+		log.Printf("無效的開始日期: %v", err)
+		return 1
+	}
+	endDate, err := time.Parse("2006-01-02", *endStr)
+	if err != nil {
+		log.Printf("無效的結束日期: %v", err)
+		return 1
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("載入配置失敗: %v", err)
+		return 1
+	}
+	httpclient.SetDefault(httpclient.New(httpclient.Config(cfg.Outbound)))
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		log.Printf("無法連接資料庫: %v", err)
+		return 1
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	collector, err := collectors.NewTaipowerCollectorFromConfig(db, cfg.External)
+	if err != nil {
+		log.Printf("台電收集器初始化失敗: %v", err)
+		return 1
+	}
+
+	results, err := collector.Backfill(ctx, startDate, endDate, collectors.BackfillOptions{
+		Concurrency: *concurrency,
+		Interval:    *interval,
+		DryRun:      *dryRun,
+	})
+	if err != nil {
+		log.Printf("回補失敗: %v", err)
+		return 1
+	}
+
+	if len(results) == 0 {
+		fmt.Println("區間內沒有缺漏的備轉資料")
+		return 0
+	}
+
+	// 輸出每日摘要
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "日期\t缺漏時段\t抓取\t新增\t更新\t失敗\t錯誤")
+
+	var totalInserted, totalUpdated, totalFailed, errored int
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%s\n",
+			r.Date, len(r.MissingHours), r.Fetched, r.Inserted, r.Updated, r.Failed, r.Error)
+		totalInserted += r.Inserted
+		totalUpdated += r.Updated
+		totalFailed += r.Failed
+		if !r.Succeeded() {
+			errored++
+		}
+	}
+	fmt.Fprintf(w, "合計\t\t\t%d\t%d\t%d\t\n", totalInserted, totalUpdated, totalFailed)
+	w.Flush()
+
+	if ctx.Err() != nil {
+		log.Printf("回補被中斷，%d 天未完成", errored)
+		return 1
+	}
+	if errored > 0 {
+		log.Printf("%d 天回補失敗", errored)
+		return 1
+	}
+	return 0
+}
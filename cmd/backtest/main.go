@@ -0,0 +1,155 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/database"
+	"vpp-go/internal/forecast"
+	"vpp-go/internal/models"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	today := time.Now().Format("2006-01-02")
+	monthAgo := time.Now().AddDate(0, 0, -30).Format("2006-01-02")
+
+	kind := flag.String("kind", "load", "預測類型: load 或 solar")
+	siteID := flag.String("site", "", "場站ID")
+	startStr := flag.String("start", monthAgo, "回測開始日期 (YYYY-MM-DD)")
+	endStr := flag.String("end", today, "回測結束日期 (YYYY-MM-DD，不含)")
+	trainingDays := flag.Int("training-days", 0, "訓練所用的歷史天數（預設同 FORECAST_TRAINING_DAYS）")
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("載入配置失敗: %v", err)
+	}
+	site, ok := cfg.GetSite(*siteID)
+	if !ok {
+		log.Fatalf("無效的場站ID: %q", *siteID)
+	}
+	location := site.Timezone
+	if location == nil {
+		location = cfg.App.Timezone
+	}
+
+	start, err := time.ParseInLocation("2006-01-02", *startStr, location)
+	if err != nil {
+		log.Fatalf("無效的開始日期: %v", err)
+	}
+	end, err := time.ParseInLocation("2006-01-02", *endStr, location)
+	if err != nil {
+		log.Fatalf("無效的結束日期: %v", err)
+	}
+	if !end.After(start) {
+		log.Fatalf("結束日期須晚於開始日期")
+	}
+	if *trainingDays <= 0 {
+		*trainingDays = cfg.Forecast.TrainingDays
+	}
+
+	cfg.Database.AutoMigrate = false
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		log.Fatalf("無法連接資料庫: %v", err)
+	}
+	defer db.Close()
+
+	from := start.AddDate(0, 0, -*trainingDays)
+	var actual map[time.Time]float64
+	var predict forecast.PredictFunc
+
+	switch *kind {
+	case "load":
+		calendar, err := forecast.NewCalendar(cfg.Forecast.Holidays, cfg.Forecast.MakeupWorkdays)
+		if err != nil {
+			log.Fatalf("行事曆配置錯誤: %v", err)
+		}
+		if err := calendar.CheckCoverage(from, end); err != nil {
+			log.Fatalf("行事曆配置錯誤: %v", err)
+		}
+		history, err := models.NewLoadDataModel(db).GetIntervals(site.ID, from, end, forecast.Interval)
+		if err != nil {
+			log.Fatalf("查詢負載數據失敗: %v", err)
+		}
+		actual = make(map[time.Time]float64, len(history))
+		for _, h := range history {
+			actual[h.Time.UTC()] = h.LoadValue
+		}
+		predict = func(issuedAt time.Time) ([]forecast.Point, error) {
+			i := sort.Search(len(history), func(i int) bool { return !history[i].Time.Before(issuedAt.AddDate(0, 0, -*trainingDays)) })
+			j := sort.Search(len(history), func(j int) bool { return !history[j].Time.Before(issuedAt) })
+			model, _, _, err := forecast.TrainLoad(history[i:j], issuedAt, calendar, location)
+			if err != nil {
+				return nil, err
+			}
+			var points []forecast.Point
+			for _, f := range model.Predict(issuedAt, int(forecast.LoadHorizon/forecast.Interval)) {
+				points = append(points, forecast.Point{Time: f.TargetTime, Value: f.LoadKW})
+			}
+			return points, nil
+		}
+
+	case "solar":
+		history, err := models.NewSolarDataModel(db).GetIntervals(site.ID, from, end, forecast.Interval)
+		if err != nil {
+			log.Fatalf("查詢太陽能數據失敗: %v", err)
+		}
+		actual = make(map[time.Time]float64, len(history))
+		for _, h := range history {
+			actual[h.Time.UTC()] = h.ACTotalPower
+		}
+		predict = func(issuedAt time.Time) ([]forecast.Point, error) {
+			i := sort.Search(len(history), func(i int) bool { return !history[i].Time.Before(issuedAt.AddDate(0, 0, -*trainingDays)) })
+			j := sort.Search(len(history), func(j int) bool { return !history[j].Time.Before(issuedAt) })
+			k := sort.Search(len(history), func(k int) bool { return !history[k].Time.Before(issuedAt.Add(-24 * time.Hour)) })
+			model, _, _, err := forecast.TrainSolar(history[i:j], site.Latitude, site.Longitude, location)
+			if err != nil {
+				return nil, err
+			}
+			clearness := model.Clearness(history[k:j], 1)
+			var points []forecast.Point
+			for _, f := range model.Predict(issuedAt, int(forecast.SolarHorizon/forecast.Interval), clearness) {
+				points = append(points, forecast.Point{Time: f.TargetTime, Value: f.PowerKW})
+			}
+			return points, nil
+		}
+
+	default:
+		log.Fatalf("不支援的預測類型: %s", *kind)
+	}
+
+	result, err := forecast.Backtest(start, end, location, actual, predict)
+	if err != nil {
+		log.Fatalf("回測失敗: %v", err)
+	}
+
+	fmt.Printf("場站: %s, 類型: %s, 區間: %s ~ %s, 發布 %d 次, 數據不足略過 %d 次\n",
+		site.ID, *kind, *startStr, *endStr, result.Runs, result.Skipped)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "預測時距\t樣本\tMAPE (%)\tRMSE (kW)\tMAE (kW)")
+	printAccuracy(w, "全部", result.Overall)
+	for _, lead := range result.ByLead {
+		printAccuracy(w, lead.Lead, lead.Accuracy)
+	}
+	w.Flush()
+}
+
+// printAccuracy 輸出一列準確度，無樣本的指標顯示為 -
+func printAccuracy(w *tabwriter.Writer, label string, a forecast.Accuracy) {
+	format := func(v *float64) string {
+		if v == nil {
+			return "-"
+		}
+		return fmt.Sprintf("%.3f", *v)
+	}
+	fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", label, a.Samples, format(a.MAPE), format(a.RMSE), format(a.MAE))
+}
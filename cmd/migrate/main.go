@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"vpp-go/internal/config"
+	"vpp-go/internal/database"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+const usage = `用法: migrate <up|down|status> [選項]
+
+  up                 套用所有尚未套用的遷移
+  down [-steps N]    回滾最近套用的 N 個遷移（預設 1）
+  status             列出所有遷移的套用狀態
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	command := os.Args[1]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	steps := flags.Int("steps", 1, "回滾的遷移數量")
+	flags.Parse(os.Args[2:])
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("載入配置失敗: %v", err)
+	}
+	// 由本命令明確執行遷移，連接時不自動遷移
+	cfg.Database.AutoMigrate = false
+
+	db, err := database.InitDB(cfg)
+	if err != nil {
+		log.Fatalf("無法連接資料庫: %v", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch command {
+	case "up":
+		applied, err := database.MigrateUp(ctx, db)
+		if err != nil {
+			log.Fatalf("遷移失敗: %v", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("資料庫已是最新版本")
+			return
+		}
+		fmt.Printf("已套用 %d 個遷移\n", len(applied))
+
+	case "down":
+		if *steps <= 0 {
+			log.Fatalf("無效的回滾數量: %d", *steps)
+		}
+		reverted, err := database.MigrateDown(ctx, db, *steps)
+		if err != nil {
+			log.Fatalf("回滾失敗: %v", err)
+		}
+		fmt.Printf("已回滾 %d 個遷移\n", len(reverted))
+
+	case "status":
+		statuses, err := database.GetMigrationStatus(ctx, db)
+		if err != nil {
+			log.Fatalf("無法獲取遷移狀態: %v", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "版本\t名稱\t狀態\t套用時間")
+		for _, s := range statuses {
+			state, appliedAt := "未套用", ""
+			if s.Applied {
+				state = "已套用"
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", s.Version, s.Name, state, appliedAt)
+		}
+		w.Flush()
+
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/config"
+
+	_ "github.com/joho/godotenv/autoload"
+)
+
+func main() {
+	subject := flag.String("sub", "", "使用者識別（必須）")
+	roles := flag.String("roles", auth.RoleViewer, "角色，逗號分隔（viewer、operator、trader、admin）")
+	sites := flag.String("sites", auth.AllSites, "角色適用的場站，逗號分隔，* 為所有場站")
+	ttl := flag.Duration("ttl", 24*time.Hour, "有效期間")
+	flag.Parse()
+
+	if *subject == "" {
+		log.Fatalf("缺少 -sub 參數")
+	}
+
+	var roleList []string
+	for _, role := range splitList(*roles) {
+		if !auth.IsValidRole(role) {
+			log.Fatalf("無效的角色: %s", role)
+		}
+		roleList = append(roleList, role)
+	}
+	if len(roleList) == 0 {
+		log.Fatalf("缺少 -roles 參數")
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("載入配置失敗: %v", err)
+	}
+	tokens, err := auth.NewJWT(cfg.Auth.JWTSecret, cfg.Auth.JWTIssuer, cfg.Auth.JWTAudience)
+	if err != nil {
+		log.Fatalf("認證初始化失敗: %v", err)
+	}
+
+	claims := auth.Claims{
+		Subject:   *subject,
+		ExpiresAt: time.Now().Add(*ttl).Unix(),
+	}
+	siteList := splitList(*sites)
+	if len(siteList) == 0 || (len(siteList) == 1 && siteList[0] == auth.AllSites) {
+		claims.Roles = roleList
+	} else {
+		for _, siteID := range siteList {
+			if !config.IsValidSite(siteID) {
+				log.Fatalf("無效的場站ID: %s", siteID)
+			}
+		}
+		for _, role := range roleList {
+			claims.Grants = append(claims.Grants, auth.Grant{Role: role, Sites: siteList})
+		}
+	}
+
+	token, err := tokens.Sign(claims)
+	if err != nil {
+		log.Fatalf("簽發權杖失敗: %v", err)
+	}
+	fmt.Println(token)
+}
+
+// splitList 拆分逗號分隔的參數並去除空白項
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -8,4 +8,5 @@ require (
 	github.com/lib/pq v1.10.9
 	github.com/robfig/cron/v3 v3.0.1
 	github.com/joho/godotenv v1.5.1
+	golang.org/x/net v0.22.0
 )
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"log"
+	"strings"
+	"vpp-go/internal/config"
+	"vpp-go/internal/events"
+	"vpp-go/internal/models"
+)
+
+// ActionJobRun 排程任務執行的稽核動作
+const ActionJobRun = "job.run"
+
+// schedulerActor 排程任務的操作者
+const schedulerActor = "scheduler"
+
+// JobRecorder 將排程任務的執行結果寫入稽核記錄
+// 由排程器在任務結束後同步呼叫，不經事件匯流排，緩衝已滿時也不會丟棄記錄
+type JobRecorder struct {
+	Model *models.AuditModel
+}
+
+// NewJobRecorder 創建排程任務的稽核記錄器
+func NewJobRecorder(model *models.AuditModel) *JobRecorder {
+	return &JobRecorder{Model: model}
+}
+
+// RecordJob 寫入一次任務執行的稽核記錄，寫入失敗只記錄日誌，不影響任務結果
+func (r *JobRecorder) RecordJob(job events.JobFinished) {
+	if err := r.Model.Insert(jobEntry(job)); err != nil {
+		log.Printf("寫入稽核記錄失敗: %s, 錯誤: %v\n", job.Job, err)
+	}
+}
+
+// jobEntry 將排程任務執行結果轉為稽核記錄
+func jobEntry(job events.JobFinished) *models.AuditEntry {
+	entry := &models.AuditEntry{
+		OccurredAt: job.Start,
+		ActorType:  models.ActorSystem,
+		Actor:      schedulerActor,
+		Action:     ActionJobRun,
+		Target:     job.Job,
+		Sites:      jobSites(job.Job),
+		Result:     models.AuditSuccess,
+		DurationMs: job.Duration.Milliseconds(),
+	}
+	if job.Err != nil {
+		entry.Result = models.AuditFailure
+		entry.Detail = job.Err.Error()
+	}
+	return entry
+}
+
+// jobSites 場站任務的名稱以場站ID結尾（如 solar:north、forecast:load:north）
+func jobSites(name string) []string {
+	if i := strings.LastIndex(name, ":"); i >= 0 && config.IsValidSite(name[i+1:]) {
+		return []string{name[i+1:]}
+	}
+	return nil
+}
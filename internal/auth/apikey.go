@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const (
+	// apiKeyPrefix 金鑰開頭，方便在日誌或設定檔中辨識
+	apiKeyPrefix = "vpp_"
+	// apiKeyBytes 金鑰的隨機位元組數
+	apiKeyBytes = 32
+	// displayPrefixLength 保存供辨識的金鑰前綴長度（含 vpp_）
+	displayPrefixLength = 12
+)
+
+// GenerateAPIKey 產生新的設備 API 金鑰，返回明文金鑰、供辨識的前綴及雜湊
+// 明文金鑰只在建立時返回一次，資料庫只保存雜湊
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	b := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return key, key[:displayPrefixLength], HashAPIKey(key), nil
+}
+
+// HashAPIKey 計算金鑰的 SHA-256 雜湊（十六進位）
+// 金鑰為高熵隨機值，不需加鹽或慢雜湊
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// minSecretLength HS256 簽章金鑰的最短長度（位元組）
+const minSecretLength = 32
+
+// clockSkew 驗證 exp、nbf 時容許的時鐘誤差
+const clockSkew = 30 * time.Second
+
+// ErrInvalidToken 權杖格式、簽章或有效期間不符
+var ErrInvalidToken = errors.New("無效的權杖")
+
+// jwtHeader HS256 權杖標頭
+const jwtHeader = `{"alg":"HS256","typ":"JWT"}`
+
+// Audience 權杖的 aud 聲明，可為字串或字串陣列
+type Audience []string
+
+// UnmarshalJSON 同時接受字串與字串陣列
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// MarshalJSON 單一對象時輸出字串
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+// Claims 使用者權杖聲明
+// roles 的角色適用所有場站，grants 的角色只適用列出的場站
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Roles     []string `json:"roles,omitempty"`
+	Grants    []Grant  `json:"grants,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+// JWT HS256 權杖的簽發與驗證
+type JWT struct {
+	secret   []byte
+	issuer   string
+	audience string
+}
+
+// NewJWT 創建權杖簽發與驗證器，issuer、audience 不為空時驗證對應聲明
+func NewJWT(secret, issuer, audience string) (*JWT, error) {
+	if len(secret) < minSecretLength {
+		return nil, fmt.Errorf("JWT 簽章金鑰至少須 %d 位元組", minSecretLength)
+	}
+	return &JWT{secret: []byte(secret), issuer: issuer, audience: audience}, nil
+}
+
+// Sign 簽發權杖，未填的 iss、aud、iat 以驗證器設定及目前時間補上
+func (j *JWT) Sign(claims Claims) (string, error) {
+	if claims.Issuer == "" {
+		claims.Issuer = j.issuer
+	}
+	if len(claims.Audience) == 0 && j.audience != "" {
+		claims.Audience = Audience{j.audience}
+	}
+	if claims.IssuedAt == 0 {
+		claims.IssuedAt = time.Now().Unix()
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := encodeSegment([]byte(jwtHeader)) + "." + encodeSegment(payload)
+	return signingInput + "." + encodeSegment(j.sign(signingInput)), nil
+}
+
+// Verify 驗證權杖簽章與有效期間，返回聲明
+func (j *JWT) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	headerJSON, err := decodeSegment(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidToken
+	}
+
+	signature, err := decodeSegment(parts[2])
+	if err != nil || !hmac.Equal(signature, j.sign(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt == 0 || now.Add(-clockSkew).Unix() >= claims.ExpiresAt {
+		return nil, fmt.Errorf("%w: 已過期", ErrInvalidToken)
+	}
+	if claims.NotBefore != 0 && now.Add(clockSkew).Unix() < claims.NotBefore {
+		return nil, fmt.Errorf("%w: 尚未生效", ErrInvalidToken)
+	}
+	if j.issuer != "" && claims.Issuer != j.issuer {
+		return nil, fmt.Errorf("%w: 簽發者不符", ErrInvalidToken)
+	}
+	if j.audience != "" && !containsString(claims.Audience, j.audience) {
+		return nil, fmt.Errorf("%w: 對象不符", ErrInvalidToken)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: 缺少 sub", ErrInvalidToken)
+	}
+	return &claims, nil
+}
+
+// sign 計算 HMAC-SHA256 簽章
+func (j *JWT) sign(signingInput string) []byte {
+	mac := hmac.New(sha256.New, j.secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+// encodeSegment base64url 編碼（無填充）
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeSegment base64url 解碼（無填充）
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
+
+// containsString 檢查切片是否包含指定字串
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
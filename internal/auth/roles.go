@@ -0,0 +1,88 @@
+package auth
+
+// 使用者角色
+const (
+	RoleViewer   = "viewer"   // 查詢數據
+	RoleOperator = "operator" // 查詢並下達派遣指令
+	RoleTrader   = "trader"   // 查詢、收益試算及投標計畫
+	RoleAdmin    = "admin"    // 所有操作及管理
+)
+
+// Permission 操作權限
+type Permission string
+
+// 操作權限
+const (
+	PermRead     Permission = "read"     // 查詢場站數據、派遣指令及台電備轉資料
+	PermDispatch Permission = "dispatch" // 下達派遣指令
+	PermTrade    Permission = "trade"    // 收益試算、產生及查詢投標計畫
+	PermAdmin    Permission = "admin"    // 排程任務、回補、閘道器指令與 API 金鑰管理
+)
+
+// rolePermissions 各角色具有的權限
+var rolePermissions = map[string][]Permission{
+	RoleViewer:   {PermRead},
+	RoleOperator: {PermRead, PermDispatch},
+	RoleTrader:   {PermRead, PermTrade},
+	RoleAdmin:    {PermRead, PermDispatch, PermTrade, PermAdmin},
+}
+
+// AllSites 授權適用所有場站（含日後新增的場站）
+const AllSites = "*"
+
+// IsValidRole 檢查角色名稱
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// roleHas 檢查角色是否具有權限
+func roleHas(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Grant 角色及其適用的場站
+type Grant struct {
+	Role  string   `json:"role"`
+	Sites []string `json:"sites"`
+}
+
+// grants 權杖的所有授權，roles 聲明中的角色適用所有場站
+func (c *Claims) grants() []Grant {
+	grants := make([]Grant, 0, len(c.Roles)+len(c.Grants))
+	for _, role := range c.Roles {
+		grants = append(grants, Grant{Role: role, Sites: []string{AllSites}})
+	}
+	return append(grants, c.Grants...)
+}
+
+// SiteScope 具有該權限的場站；all 為 true 時適用所有場站，否則為 sites 列出的場站（可能為空）
+func (c *Claims) SiteScope(perm Permission) (all bool, sites []string) {
+	seen := make(map[string]bool)
+	for _, g := range c.grants() {
+		if !roleHas(g.Role, perm) {
+			continue
+		}
+		for _, site := range g.Sites {
+			if site == AllSites {
+				return true, nil
+			}
+			if !seen[site] {
+				seen[site] = true
+				sites = append(sites, site)
+			}
+		}
+	}
+	return false, sites
+}
+
+// Can 檢查是否具有該場站的權限
+func (c *Claims) Can(perm Permission, siteID string) bool {
+	all, sites := c.SiteScope(perm)
+	return all || containsString(sites, siteID)
+}
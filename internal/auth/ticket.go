@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"sync"
+	"time"
+)
+
+// ticketBytes 票證的隨機位元組數
+const ticketBytes = 32
+
+// Tickets 短效且只能使用一次的票證，供無法設定標頭的瀏覽器即時推送連線代替權杖放在網址中
+// 票證只保存在記憶體，服務器重啟後失效
+type Tickets struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	tickets map[string]ticket
+}
+
+// ticket 票證對應的使用者聲明及到期時間
+type ticket struct {
+	claims  *Claims
+	expires time.Time
+}
+
+// NewTickets 創建票證存放區，票證在 ttl 後失效
+func NewTickets(ttl time.Duration) *Tickets {
+	return &Tickets{ttl: ttl, tickets: make(map[string]ticket)}
+}
+
+// Issue 為已驗證的使用者發出票證，返回票證及到期時間
+// 票證不會晚於權杖本身到期
+func (t *Tickets) Issue(claims *Claims) (string, time.Time, error) {
+	b := make([]byte, ticketBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", time.Time{}, err
+	}
+	value := base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+	expires := now.Add(t.ttl)
+	if claims.ExpiresAt > 0 && time.Unix(claims.ExpiresAt, 0).Before(expires) {
+		expires = time.Unix(claims.ExpiresAt, 0)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	// 順便清除已過期未使用的票證
+	for k, v := range t.tickets {
+		if !now.Before(v.expires) {
+			delete(t.tickets, k)
+		}
+	}
+	t.tickets[value] = ticket{claims: claims, expires: expires}
+	return value, expires, nil
+}
+
+// Redeem 使用票證，返回發出時的使用者聲明；票證不存在、已使用或已過期時返回 false
+func (t *Tickets) Redeem(value string) (*Claims, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	v, ok := t.tickets[value]
+	if !ok {
+		return nil, false
+	}
+	delete(t.tickets, value)
+	if !time.Now().Before(v.expires) {
+		return nil, false
+	}
+	return v.claims, true
+}
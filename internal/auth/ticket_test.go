@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTickets(t *testing.T) {
+	tickets := NewTickets(time.Minute)
+	claims := &Claims{Subject: "alice", Roles: []string{"viewer"}}
+
+	value, expires, err := tickets.Issue(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Until(expires) > time.Minute {
+		t.Errorf("到期時間 %v 超過有效時間", expires)
+	}
+
+	got, ok := tickets.Redeem(value)
+	if !ok || got.Subject != "alice" {
+		t.Fatalf("Redeem = %+v, %v, 預期 alice", got, ok)
+	}
+	if _, ok := tickets.Redeem(value); ok {
+		t.Error("票證只能使用一次")
+	}
+	if _, ok := tickets.Redeem("unknown"); ok {
+		t.Error("不存在的票證應被拒絕")
+	}
+}
+
+func TestTicketsExpire(t *testing.T) {
+	tickets := NewTickets(time.Minute)
+
+	// 權杖已過期時票證隨之失效
+	value, _, err := tickets.Issue(&Claims{Subject: "alice", ExpiresAt: time.Now().Add(-time.Second).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := tickets.Redeem(value); ok {
+		t.Error("票證不應晚於權杖到期")
+	}
+
+	tickets = NewTickets(-time.Second)
+	value, _, _ = tickets.Issue(&Claims{Subject: "alice"})
+	if _, ok := tickets.Redeem(value); ok {
+		t.Error("過期的票證應被拒絕")
+	}
+	// 發出新票證時清除過期未使用的票證
+	tickets.Issue(&Claims{Subject: "bob"})
+	if len(tickets.tickets) != 1 {
+		t.Errorf("存放 %d 張票證, 預期只剩新發出的 1 張", len(tickets.tickets))
+	}
+}
@@ -1,12 +1,16 @@
 package collectors
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/events"
+	"vpp-go/internal/httpclient"
 	"vpp-go/internal/models"
 
 	"database/sql"
@@ -20,6 +24,10 @@ type SolarCollector struct {
 	SiteID   string
 	Username string
 	Password string
+	Location *time.Location
+	HTTP     *httpclient.Client
+	// Events 不為 nil 時發佈數據寫入及收集失敗事件
+	Events *events.Bus
 }
 
 // NewSolarCollector 創建太陽能數據收集器
@@ -31,9 +39,20 @@ func NewSolarCollector(db *sql.DB, apiURL, siteID, username, password string) *S
 		SiteID:   siteID,
 		Username: username,
 		Password: password,
+		Location: time.Local,
+		HTTP:     httpclient.Default(),
 	}
 }
 
+// NewSiteSolarCollector 依場站配置創建太陽能數據收集器
+func NewSiteSolarCollector(db *sql.DB, site config.SiteConfig) *SolarCollector {
+	c := NewSolarCollector(db, site.APIURL, site.ID, site.Username, site.Password)
+	if site.Timezone != nil {
+		c.Location = site.Timezone
+	}
+	return c
+}
+
 // YihongAPIResponse 義鴻API響應結構
 type YihongAPIResponse struct {
 	Success bool                   `json:"success"`
@@ -41,11 +60,9 @@ type YihongAPIResponse struct {
 }
 
 // FetchData 從義鴻API獲取數據
-func (c *SolarCollector) FetchData() (*models.SolarData, error) {
-	client := &http.Client{Timeout: 30 * time.Second}
-
+func (c *SolarCollector) FetchData(ctx context.Context) (*models.SolarData, error) {
 	// 構建請求
-	req, err := http.NewRequest("GET", c.APIURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", c.APIURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("創建請求失敗: %w", err)
 	}
@@ -56,7 +73,7 @@ func (c *SolarCollector) FetchData() (*models.SolarData, error) {
 	}
 
 	// 發送請求
-	resp, err := client.Do(req)
+	resp, err := c.HTTP.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("請求失敗: %w", err)
 	}
@@ -91,7 +108,7 @@ func (c *SolarCollector) FetchData() (*models.SolarData, error) {
 func (c *SolarCollector) parseData(data map[string]interface{}) *models.SolarData {
 	solarData := &models.SolarData{
 		SiteID:   c.SiteID,
-		DateTime: time.Now(),
+		DateTime: time.Now().In(c.Location),
 	}
 
 	// 解析各個欄位（根據實際API響應格式調整）
@@ -134,40 +151,53 @@ func (c *SolarCollector) parseData(data map[string]interface{}) *models.SolarDat
 
 // SaveToDatabase 保存數據到資料庫
 func (c *SolarCollector) SaveToDatabase(data *models.SolarData) error {
-	return c.Model.Insert(data)
+	if err := c.Model.Insert(data); err != nil {
+		return err
+	}
+	c.Events.Publish(events.SampleStored{
+		Metric: events.MetricSolar,
+		SiteID: data.SiteID,
+		Time:   data.DateTime,
+		Source: events.SourceCollector,
+		Data:   data,
+	})
+	return nil
 }
 
-// CollectAndSave 收集並保存數據
-func (c *SolarCollector) CollectAndSave() error {
+// CollectAndSave 收集並保存數據，失敗時發佈收集失敗事件
+func (c *SolarCollector) CollectAndSave(ctx context.Context) error {
 	log.Printf("開始收集太陽能數據 - 場站: %s\n", c.SiteID)
 
-	data, err := c.FetchData()
+	data, err := c.FetchData(ctx)
 	if err != nil {
-		return fmt.Errorf("獲取數據失敗: %w", err)
+		return c.failed(fmt.Errorf("獲取數據失敗: %w", err))
 	}
 
 	if err := c.SaveToDatabase(data); err != nil {
-		return fmt.Errorf("保存數據失敗: %w", err)
+		return c.failed(fmt.Errorf("保存數據失敗: %w", err))
 	}
 
 	log.Printf("太陽能數據收集成功 - 場站: %s, 時間: %s\n", c.SiteID, data.DateTime.Format("2006-01-02 15:04:05"))
 	return nil
 }
 
-// StartSchedule 啟動定時收集（每15分鐘）
-func (c *SolarCollector) StartSchedule() {
-	ticker := time.NewTicker(15 * time.Minute)
-	defer ticker.Stop()
+// failed 發佈收集失敗事件並返回原錯誤
+func (c *SolarCollector) failed(err error) error {
+	c.Events.Publish(events.CollectionFailed{
+		Collector: c.Name(),
+		SiteID:    c.SiteID,
+		Time:      time.Now(),
+		Err:       err,
+	})
+	return err
+}
 
-	// 立即執行一次
-	if err := c.CollectAndSave(); err != nil {
-		log.Printf("太陽能數據收集錯誤: %v\n", err)
-	}
+// Name 排程任務名稱
+func (c *SolarCollector) Name() string {
+	return "solar:" + c.SiteID
+}
 
-	// 定時執行
-	for range ticker.C {
-		if err := c.CollectAndSave(); err != nil {
-			log.Printf("太陽能數據收集錯誤: %v\n", err)
-		}
-	}
+// Run 排程執行入口
+func (c *SolarCollector) Run(ctx context.Context) error {
+	return c.CollectAndSave(ctx)
 }
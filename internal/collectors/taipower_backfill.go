@@ -0,0 +1,239 @@
+package collectors
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+	"vpp-go/internal/events"
+	"vpp-go/internal/models"
+)
+
+// BackfillOptions 回補選項
+type BackfillOptions struct {
+	Concurrency int           // 同時抓取的天數上限
+	Interval    time.Duration // 兩次請求之間的最小間隔
+	DryRun      bool          // 只偵測缺漏，不實際抓取
+}
+
+// BackfillDayResult 單日回補結果
+type BackfillDayResult struct {
+	Date         string `json:"date"`
+	MissingHours []int  `json:"missing_hours"`
+	Fetched      int    `json:"fetched"`
+	Inserted     int    `json:"inserted"`
+	Updated      int    `json:"updated"`
+	Failed       int    `json:"failed"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Succeeded 單日回補是否成功，取消、抓取失敗或有時段寫入失敗時為 false
+func (r BackfillDayResult) Succeeded() bool {
+	return r.Error == "" && r.Failed == 0
+}
+
+// Backfill 回補日期區間內缺漏的備轉資料，只抓取有缺漏時段的日期
+func (c *TaipowerCollector) Backfill(ctx context.Context, startDate, endDate time.Time, opts BackfillOptions) ([]BackfillDayResult, error) {
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("結束日期不能早於開始日期")
+	}
+	if opts.Concurrency < 1 {
+		opts.Concurrency = 1
+	}
+
+	missing, err := c.Model.GetMissingHours(startDate, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("偵測缺漏資料失敗: %w", err)
+	}
+
+	dates := make([]string, 0, len(missing))
+	for date := range missing {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+
+	results := make([]BackfillDayResult, len(dates))
+	for i, date := range dates {
+		results[i] = BackfillDayResult{Date: date, MissingHours: missing[date]}
+	}
+
+	if opts.DryRun || len(dates) == 0 {
+		return results, nil
+	}
+
+	log.Printf("開始回補台電備轉資料 - 區間: %s ~ %s, 缺漏天數: %d\n",
+		startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), len(dates))
+
+	// 以 ticker 限制請求頻率
+	var throttle <-chan time.Time
+	if opts.Interval > 0 {
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		throttle = ticker.C
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c.backfillDay(ctx, &results[i])
+			}
+		}()
+	}
+
+	sent := 0
+dispatch:
+	for sent < len(results) {
+		if sent > 0 && throttle != nil {
+			select {
+			case <-throttle:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+
+		select {
+		case jobs <- sent:
+			sent++
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	// 未派發的日期標記為取消
+	for i := sent; i < len(results); i++ {
+		results[i].Error = ctx.Err().Error()
+	}
+
+	return results, nil
+}
+
+// backfillDay 抓取並保存單日資料
+func (c *TaipowerCollector) backfillDay(ctx context.Context, result *BackfillDayResult) {
+	date, err := time.Parse("2006-01-02", result.Date)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	dataList, err := c.FetchData(ctx, date)
+	if err != nil {
+		result.Error = err.Error()
+		result.Failed = len(result.MissingHours)
+		log.Printf("台電備轉資料回補失敗 - 日期: %s, 錯誤: %v\n", result.Date, err)
+		return
+	}
+	result.Fetched = len(dataList)
+
+	stored := make([]models.TaipowerReserveData, 0, len(dataList))
+	for i := range dataList {
+		inserted, err := c.Model.Upsert(&dataList[i])
+		switch {
+		case err != nil:
+			result.Failed++
+			result.Error = err.Error()
+			continue
+		case inserted:
+			result.Inserted++
+		default:
+			result.Updated++
+		}
+		stored = append(stored, dataList[i])
+	}
+	c.publishDay(stored, events.SourceBackfill)
+
+	log.Printf("台電備轉資料回補完成 - 日期: %s, 新增: %d, 更新: %d, 失敗: %d\n",
+		result.Date, result.Inserted, result.Updated, result.Failed)
+}
+
+// BackfillSummary 回補結果彙總
+type BackfillSummary struct {
+	StartDate string              `json:"start_date"`
+	EndDate   string              `json:"end_date"`
+	DryRun    bool                `json:"dry_run"`
+	Days      int                 `json:"days"`
+	Inserted  int                 `json:"inserted"`
+	Updated   int                 `json:"updated"`
+	Failed    int                 `json:"failed"`
+	Results   []BackfillDayResult `json:"results"`
+}
+
+// BackfillJob 在排程器背景執行的一次性回補任務，結果列於任務狀態
+type BackfillJob struct {
+	Collector *TaipowerCollector
+	StartDate time.Time
+	EndDate   time.Time
+	Options   BackfillOptions
+
+	name    string
+	mu      sync.Mutex
+	summary *BackfillSummary
+}
+
+// NewBackfillJob 創建回補任務，任務名稱含日期區間及建立時間，作為任務ID
+func NewBackfillJob(c *TaipowerCollector, startDate, endDate time.Time, opts BackfillOptions) *BackfillJob {
+	return &BackfillJob{
+		Collector: c,
+		StartDate: startDate,
+		EndDate:   endDate,
+		Options:   opts,
+		name: fmt.Sprintf("backfill:taipower:%s-%s:%d",
+			startDate.Format("20060102"), endDate.Format("20060102"), time.Now().UnixMilli()),
+	}
+}
+
+// Name 任務名稱
+func (j *BackfillJob) Name() string {
+	return j.name
+}
+
+// Run 執行回補，有日期回補失敗時返回錯誤
+func (j *BackfillJob) Run(ctx context.Context) error {
+	results, err := j.Collector.Backfill(ctx, j.StartDate, j.EndDate, j.Options)
+	if err != nil {
+		return err
+	}
+
+	summary := &BackfillSummary{
+		StartDate: j.StartDate.Format("2006-01-02"),
+		EndDate:   j.EndDate.Format("2006-01-02"),
+		DryRun:    j.Options.DryRun,
+		Days:      len(results),
+		Results:   results,
+	}
+	var errored int
+	for _, r := range results {
+		summary.Inserted += r.Inserted
+		summary.Updated += r.Updated
+		summary.Failed += r.Failed
+		if !r.Succeeded() {
+			errored++
+		}
+	}
+
+	j.mu.Lock()
+	j.summary = summary
+	j.mu.Unlock()
+
+	if errored > 0 {
+		return fmt.Errorf("%d 天回補失敗", errored)
+	}
+	return nil
+}
+
+// Result 回補結果彙總，尚未完成時為 nil
+func (j *BackfillJob) Result() interface{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.summary == nil {
+		return nil
+	}
+	return j.summary
+}
@@ -0,0 +1,24 @@
+package collectors
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBackfillDayResultSucceeded(t *testing.T) {
+	tests := []struct {
+		name   string
+		result BackfillDayResult
+		want   bool
+	}{
+		{"全部寫入", BackfillDayResult{Date: "2024-01-02", Fetched: 24, Inserted: 24}, true},
+		{"部分時段寫入失敗", BackfillDayResult{Date: "2024-01-02", Fetched: 24, Inserted: 23, Failed: 1, Error: "寫入失敗"}, false},
+		{"中斷未派發", BackfillDayResult{Date: "2024-01-02", Error: context.Canceled.Error()}, false},
+		{"日期無法解析", BackfillDayResult{Date: "2024-13-01", Error: "parsing time"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.result.Succeeded(); got != tt.want {
+			t.Errorf("%s: Succeeded = %v, 預期 %v", tt.name, got, tt.want)
+		}
+	}
+}
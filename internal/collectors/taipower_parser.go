@@ -0,0 +1,524 @@
+package collectors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"vpp-go/internal/models"
+
+	"golang.org/x/net/html"
+)
+
+// ErrReserveTableNotFound 頁面中找不到備轉資料表格
+var ErrReserveTableNotFound = errors.New("找不到備轉資料表格")
+
+// 備轉資料欄位鍵值（與 TaipowerReserveData 的 JSON 標籤一致）
+const (
+	colHour          = "tran_hour"
+	colSRBid         = "sr_bid"
+	colSRBidQSE      = "sr_bid_qse"
+	colSRBidNonTrade = "sr_bid_nontrade"
+	colSRPrice       = "sr_price"
+	colSRPerfPrice1  = "sr_perf_price_1"
+	colSRPerfPrice2  = "sr_perf_price_2"
+	colSRPerfPrice3  = "sr_perf_price_3"
+	colSUPBid        = "sup_bid"
+	colSUPBidQSE     = "sup_bid_qse"
+	colSUPBidNonTrd  = "sup_bid_nontrade"
+	colSUPPrice      = "sup_price"
+	colDate          = "tran_date"
+)
+
+// reserveColumns 欄位鍵值及可接受的表頭文字（正規化後比對）
+// 多層表頭會以上層文字加下層文字組合，例如「即時備轉」+「得標容量」
+var reserveColumns = []struct {
+	key     string
+	headers []string
+}{
+	{colHour, []string{"時段", "交易時段", "小時", "時間"}},
+	{colSRBid, []string{"即時備轉得標容量", "即時備轉容量", "即時備轉得標量"}},
+	{colSRBidQSE, []string{"即時備轉合格交易者得標容量", "即時備轉合格交易者", "即時備轉交易者得標容量"}},
+	{colSRBidNonTrade, []string{"即時備轉非交易容量", "即時備轉非交易", "即時備轉非交易得標容量"}},
+	{colSRPrice, []string{"即時備轉結清價格", "即時備轉容量費", "即時備轉價格"}},
+	{colSRPerfPrice1, []string{"即時備轉效能價格1", "即時備轉效能費1"}},
+	{colSRPerfPrice2, []string{"即時備轉效能價格2", "即時備轉效能費2"}},
+	{colSRPerfPrice3, []string{"即時備轉效能價格3", "即時備轉效能費3"}},
+	{colSUPBid, []string{"補充備轉得標容量", "補充備轉容量", "補充備轉得標量"}},
+	{colSUPBidQSE, []string{"補充備轉合格交易者得標容量", "補充備轉合格交易者", "補充備轉交易者得標容量"}},
+	{colSUPBidNonTrd, []string{"補充備轉非交易容量", "補充備轉非交易", "補充備轉非交易得標容量"}},
+	{colSUPPrice, []string{"補充備轉結清價格", "補充備轉容量費", "補充備轉價格"}},
+}
+
+// dateColumn 交易日期欄位，為選用欄位
+var dateColumn = struct {
+	key     string
+	headers []string
+}{colDate, []string{"交易日期", "日期", "date"}}
+
+// tableDateFormats 交易日期可接受的格式
+var tableDateFormats = []string{"2006-01-02", "2006/01/02", "20060102", "2006-1-2", "2006/1/2"}
+
+// parseTableDate 解析交易日期，支援民國年（如 113/01/02）
+func parseTableDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range tableDateFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == '/' || r == '-' || r == '.' })
+	if len(parts) == 3 {
+		year, errY := strconv.Atoi(parts[0])
+		month, errM := strconv.Atoi(parts[1])
+		day, errD := strconv.Atoi(parts[2])
+		if errY == nil && errM == nil && errD == nil && year > 0 && year < 1911 {
+			return time.Date(year+1911, time.Month(month), day, 0, 0, 0, 0, time.UTC), nil
+		}
+	}
+
+	return time.Time{}, errors.New("無效的日期格式")
+}
+
+// CellError 單一儲存格解析錯誤
+type CellError struct {
+	Row    int    // 資料列序號（從1開始，不含表頭）
+	Column string // 欄位鍵值
+	Value  string // 原始文字
+	Err    error
+}
+
+// Error 實作 error 介面
+func (e *CellError) Error() string {
+	return fmt.Sprintf("第 %d 列欄位 %s 無法解析 %q: %v", e.Row, e.Column, e.Value, e.Err)
+}
+
+// Unwrap 返回底層錯誤
+func (e *CellError) Unwrap() error {
+	return e.Err
+}
+
+// ReserveParseError 備轉資料表格解析錯誤
+type ReserveParseError struct {
+	MissingColumns []string     // 找不到對應表頭的欄位
+	Cells          []*CellError // 無法解析的儲存格
+	Hours          int          // 解析出的時段數
+	DuplicateHours []int        // 重複出現的時段
+}
+
+// Error 實作 error 介面
+func (e *ReserveParseError) Error() string {
+	var parts []string
+	if len(e.MissingColumns) > 0 {
+		parts = append(parts, fmt.Sprintf("缺少欄位 %s", strings.Join(e.MissingColumns, ", ")))
+	}
+	if len(e.Cells) > 0 {
+		parts = append(parts, fmt.Sprintf("%d 個儲存格無法解析（首個: %v）", len(e.Cells), e.Cells[0]))
+	}
+	if len(e.DuplicateHours) > 0 {
+		parts = append(parts, fmt.Sprintf("時段重複 %v", e.DuplicateHours))
+	}
+	if e.Hours != 24 {
+		parts = append(parts, fmt.Sprintf("時段數為 %d，應為 24", e.Hours))
+	}
+	return "備轉資料解析失敗: " + strings.Join(parts, "; ")
+}
+
+// ParseReserveHTML 解析台電備轉資料頁面
+// 依表頭文字定位表格並對應欄位，任何儲存格無法解析或時段數不為24時返回 *ReserveParseError
+func ParseReserveHTML(r io.Reader, date time.Time) ([]models.TaipowerReserveData, error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return nil, fmt.Errorf("解析HTML失敗: %w", err)
+	}
+
+	var best *reserveTable
+	for _, table := range findAll(doc, "table") {
+		t := newReserveTable(tableGrid(table))
+		if t == nil {
+			continue
+		}
+		if best == nil || len(t.columns) > len(best.columns) {
+			best = t
+		}
+	}
+	if best == nil {
+		return nil, ErrReserveTableNotFound
+	}
+	if err := best.filterDate(date); err != nil {
+		return nil, err
+	}
+
+	return best.parse(date)
+}
+
+// reserveTable 已定位的備轉資料表格
+type reserveTable struct {
+	columns map[string]int // 欄位鍵值 -> 欄位索引
+	rows    [][]string     // 資料列
+}
+
+// newReserveTable 由表格網格辨識表頭，沒有時段欄位則返回 nil
+func newReserveTable(grid [][]string, isHeader []bool) *reserveTable {
+	headerRows := countHeaderRows(isHeader)
+	if headerRows == 0 {
+		return nil
+	}
+
+	// 組合多層表頭
+	width := 0
+	for _, row := range grid {
+		if len(row) > width {
+			width = len(row)
+		}
+	}
+	headers := make([]string, width)
+	for i := 0; i < width; i++ {
+		var parts []string
+		for r := 0; r < headerRows; r++ {
+			if i < len(grid[r]) {
+				text := normalizeHeader(grid[r][i])
+				if text != "" && (len(parts) == 0 || parts[len(parts)-1] != text) {
+					parts = append(parts, text)
+				}
+			}
+		}
+		headers[i] = strings.Join(parts, "")
+	}
+
+	columns := mapColumns(headers)
+	if _, ok := columns[colHour]; !ok || len(columns) < 2 {
+		return nil
+	}
+
+	return &reserveTable{columns: columns, rows: grid[headerRows:]}
+}
+
+// mapColumns 依正規化後的表頭文字對應欄位索引
+func mapColumns(headers []string) map[string]int {
+	columns := make(map[string]int)
+	for _, col := range append(reserveColumns, dateColumn) {
+		for i, header := range headers {
+			if matchHeader(header, col.key, col.headers) {
+				columns[col.key] = i
+				break
+			}
+		}
+	}
+	return columns
+}
+
+// filterDate 表格含交易日期欄位時（如開放資料整月檔），只保留指定日期的資料列
+func (t *reserveTable) filterDate(date time.Time) error {
+	idx, ok := t.columns[colDate]
+	if !ok {
+		return nil
+	}
+
+	want := date.Format("2006-01-02")
+	var rows [][]string
+	for i, row := range t.rows {
+		if isBlankRow(row) || idx >= len(row) {
+			continue
+		}
+		rowDate, err := parseTableDate(row[idx])
+		if err != nil {
+			return &CellError{Row: i + 1, Column: colDate, Value: row[idx], Err: err}
+		}
+		if rowDate.Format("2006-01-02") == want {
+			rows = append(rows, row)
+		}
+	}
+	t.rows = rows
+	return nil
+}
+
+// parse 將資料列轉為備轉資料
+func (t *reserveTable) parse(date time.Time) ([]models.TaipowerReserveData, error) {
+	perr := &ReserveParseError{}
+	for _, col := range reserveColumns {
+		if _, ok := t.columns[col.key]; !ok {
+			perr.MissingColumns = append(perr.MissingColumns, col.key)
+		}
+	}
+	if len(perr.MissingColumns) > 0 {
+		return nil, perr
+	}
+
+	var dataList []models.TaipowerReserveData
+	for i, row := range t.rows {
+		if isBlankRow(row) {
+			continue
+		}
+
+		data := models.TaipowerReserveData{TranDate: date}
+		p := cellParser{row: row, rowNum: i + 1, columns: t.columns, perr: perr}
+
+		data.TranHour = p.hour(colHour)
+		data.SRBid = p.float(colSRBid)
+		data.SRBidQSE = p.float(colSRBidQSE)
+		data.SRBidNonTrade = p.float(colSRBidNonTrade)
+		data.SRPrice = p.float(colSRPrice)
+		data.SRPerfPrice1 = p.float(colSRPerfPrice1)
+		data.SRPerfPrice2 = p.float(colSRPerfPrice2)
+		data.SRPerfPrice3 = p.float(colSRPerfPrice3)
+		data.SUPBid = p.float(colSUPBid)
+		data.SUPBidQSE = p.float(colSUPBidQSE)
+		data.SUPBidNonTrade = p.float(colSUPBidNonTrd)
+		data.SUPPrice = p.float(colSUPPrice)
+
+		if !p.failed {
+			dataList = append(dataList, data)
+		}
+	}
+
+	normalizeHours(dataList)
+
+	seen := make(map[int]bool)
+	for _, data := range dataList {
+		if seen[data.TranHour] {
+			perr.DuplicateHours = append(perr.DuplicateHours, data.TranHour)
+		}
+		seen[data.TranHour] = true
+	}
+	perr.Hours = len(seen)
+
+	if len(perr.Cells) > 0 || len(perr.DuplicateHours) > 0 || perr.Hours != 24 {
+		return nil, perr
+	}
+	return dataList, nil
+}
+
+// cellParser 逐欄解析單一資料列並收集錯誤
+type cellParser struct {
+	row     []string
+	rowNum  int
+	columns map[string]int
+	perr    *ReserveParseError
+	failed  bool
+}
+
+// hourPattern 時段格式，例如 "1"、"01"、"00:00"、"00:00~01:00"
+var hourPattern = regexp.MustCompile(`^(\d{1,2})(?::\d{2})?(?:\s*[-~～]\s*\d{1,2}(?::\d{2})?)?$`)
+
+// hour 解析時段欄位
+func (p *cellParser) hour(key string) int {
+	value := p.cell(key)
+	m := hourPattern.FindStringSubmatch(value)
+	if m == nil {
+		p.fail(key, value, errors.New("無效的時段格式"))
+		return 0
+	}
+
+	hour, _ := strconv.Atoi(m[1])
+	if hour < 0 || hour > 24 {
+		p.fail(key, value, errors.New("時段超出範圍"))
+		return 0
+	}
+	return hour
+}
+
+// float 解析數值欄位
+func (p *cellParser) float(key string) float64 {
+	value := p.cell(key)
+	cleaned := strings.ReplaceAll(value, ",", "")
+	if cleaned == "" {
+		p.fail(key, value, errors.New("空白儲存格"))
+		return 0
+	}
+
+	val, err := strconv.ParseFloat(cleaned, 64)
+	if err != nil {
+		p.fail(key, value, err)
+		return 0
+	}
+	return val
+}
+
+// cell 獲取欄位文字
+func (p *cellParser) cell(key string) string {
+	idx := p.columns[key]
+	if idx >= len(p.row) {
+		return ""
+	}
+	return p.row[idx]
+}
+
+// fail 記錄儲存格錯誤
+func (p *cellParser) fail(key, value string, err error) {
+	p.failed = true
+	p.perr.Cells = append(p.perr.Cells, &CellError{Row: p.rowNum, Column: key, Value: value, Err: err})
+}
+
+// normalizeHours 時段以 1~24 表示時轉為 0~23
+func normalizeHours(dataList []models.TaipowerReserveData) {
+	hasZero, has24 := false, false
+	for _, data := range dataList {
+		hasZero = hasZero || data.TranHour == 0
+		has24 = has24 || data.TranHour == 24
+	}
+	if has24 && !hasZero {
+		for i := range dataList {
+			dataList[i].TranHour--
+		}
+	}
+}
+
+// tableGrid 展開表格為網格，處理 rowspan/colspan
+// 同時返回各列是否整列皆為 th，供辨識表頭列
+func tableGrid(table *html.Node) ([][]string, []bool) {
+	var grid [][]string
+	var headerRows []bool
+	pending := make(map[[2]int]string) // rowspan 延伸的儲存格
+
+	for r, tr := range tableRows(table) {
+		var row []string
+		col := 0
+		allHeader := true
+		fill := func() {
+			for {
+				text, ok := pending[[2]int{r, col}]
+				if !ok {
+					return
+				}
+				row = append(row, text)
+				delete(pending, [2]int{r, col})
+				col++
+			}
+		}
+
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type != html.ElementNode || (cell.Data != "td" && cell.Data != "th") {
+				continue
+			}
+			fill()
+
+			allHeader = allHeader && cell.Data == "th"
+			text := nodeText(cell)
+			colspan := spanAttr(cell, "colspan")
+			rowspan := spanAttr(cell, "rowspan")
+			for c := 0; c < colspan; c++ {
+				row = append(row, text)
+				for rr := 1; rr < rowspan; rr++ {
+					pending[[2]int{r + rr, col}] = text
+				}
+				col++
+			}
+		}
+		fill()
+
+		grid = append(grid, row)
+		headerRows = append(headerRows, allHeader && len(row) > 0)
+	}
+
+	return grid, headerRows
+}
+
+// countHeaderRows 計算表格開頭的表頭列數，沒有 th 列時視第一列為表頭
+func countHeaderRows(headerRows []bool) int {
+	n := 0
+	for n < len(headerRows) && headerRows[n] {
+		n++
+	}
+	if n == 0 && len(headerRows) > 0 {
+		n = 1
+	}
+	return n
+}
+
+// tableRows 獲取表格的所有 tr（不含巢狀表格）
+func tableRows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.Data {
+			case "tr":
+				rows = append(rows, child)
+			case "thead", "tbody", "tfoot":
+				walk(child)
+			}
+		}
+	}
+	walk(table)
+	return rows
+}
+
+// findAll 找出所有指定標籤的節點
+func findAll(n *html.Node, tag string) []*html.Node {
+	var nodes []*html.Node
+	if n.Type == html.ElementNode && n.Data == tag {
+		nodes = append(nodes, n)
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		nodes = append(nodes, findAll(child, tag)...)
+	}
+	return nodes
+}
+
+// nodeText 獲取節點內的文字並壓縮空白
+func nodeText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+			sb.WriteByte(' ')
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// spanAttr 獲取 rowspan/colspan 屬性，預設為1
+func spanAttr(n *html.Node, name string) int {
+	for _, attr := range n.Attr {
+		if attr.Key == name {
+			if v, err := strconv.Atoi(strings.TrimSpace(attr.Val)); err == nil && v > 0 {
+				return v
+			}
+		}
+	}
+	return 1
+}
+
+// headerReplacer 移除表頭中的空白及括號
+var headerReplacer = strings.NewReplacer(" ", "", "(", "", ")", "", "（", "", "）", "", "_", "", "-", "")
+
+// normalizeHeader 正規化表頭文字
+func normalizeHeader(s string) string {
+	return strings.ToLower(headerReplacer.Replace(s))
+}
+
+// matchHeader 檢查表頭是否符合欄位，也接受欄位鍵值本身作為表頭
+func matchHeader(header, key string, aliases []string) bool {
+	if header == normalizeHeader(key) {
+		return true
+	}
+	for _, alias := range aliases {
+		if header == normalizeHeader(alias) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlankRow 檢查是否為空白列
+func isBlankRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
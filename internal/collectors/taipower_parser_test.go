@@ -0,0 +1,189 @@
+package collectors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"vpp-go/internal/models"
+)
+
+// update 以目前解析結果覆寫 golden 檔：go test ./internal/collectors -update
+var update = flag.Bool("update", false, "覆寫 testdata 中的 golden 檔")
+
+// testDate 測試頁面的交易日期
+var testDate = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+// readFixture 讀取 testdata 中的測試檔
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("讀取測試檔失敗: %v", err)
+	}
+	return data
+}
+
+// checkGolden 比對解析結果與 golden 檔
+func checkGolden(t *testing.T, name string, got []models.TaipowerReserveData) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		data, err := json.MarshalIndent(got, "", "  ")
+		if err != nil {
+			t.Fatalf("序列化失敗: %v", err)
+		}
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			t.Fatalf("寫入 golden 檔失敗: %v", err)
+		}
+		return
+	}
+
+	var want []models.TaipowerReserveData
+	if err := json.Unmarshal(readFixture(t, name), &want); err != nil {
+		t.Fatalf("解析 golden 檔失敗: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("解析結果與 %s 不符\n got: %+v\nwant: %+v", name, got, want)
+	}
+}
+
+func TestParseReserveHTML(t *testing.T) {
+	// 三種版面的頁面內容相同，應解析出同一份資料
+	tests := []struct {
+		name    string
+		fixture string
+	}{
+		{"單層中文表頭", "reserve_flat.html"},
+		{"兩層表頭與1~24時段", "reserve_two_level.html"},
+		{"欄位鍵值表頭與整月資料", "reserve_monthly_keys.html"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseReserveHTML(bytes.NewReader(readFixture(t, tt.fixture)), testDate)
+			if err != nil {
+				t.Fatalf("解析失敗: %v", err)
+			}
+			checkGolden(t, "reserve_2024-01-02.golden.json", got)
+		})
+	}
+}
+
+func TestParseReserveHTMLErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		fixture    string
+		hours      int
+		cells      []CellError
+		duplicates []int
+		missing    []string
+	}{
+		{
+			name:    "無法解析的儲存格",
+			fixture: "reserve_bad_cell.html",
+			hours:   23,
+			cells:   []CellError{{Row: 5, Column: colSRPrice, Value: "N/A"}},
+		},
+		{
+			name:    "缺少時段",
+			fixture: "reserve_23_hours.html",
+			hours:   23,
+		},
+		{
+			name:       "重複時段",
+			fixture:    "reserve_duplicate_hour.html",
+			hours:      24,
+			duplicates: []int{23},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseReserveHTML(bytes.NewReader(readFixture(t, tt.fixture)), testDate)
+
+			var perr *ReserveParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("錯誤 = %v, 預期 *ReserveParseError", err)
+			}
+			if perr.Hours != tt.hours {
+				t.Errorf("Hours = %d, 預期 %d", perr.Hours, tt.hours)
+			}
+			if !reflect.DeepEqual(perr.DuplicateHours, tt.duplicates) {
+				t.Errorf("DuplicateHours = %v, 預期 %v", perr.DuplicateHours, tt.duplicates)
+			}
+			if !reflect.DeepEqual(perr.MissingColumns, tt.missing) {
+				t.Errorf("MissingColumns = %v, 預期 %v", perr.MissingColumns, tt.missing)
+			}
+			if len(perr.Cells) != len(tt.cells) {
+				t.Fatalf("Cells = %v, 預期 %d 個", perr.Cells, len(tt.cells))
+			}
+			for i, want := range tt.cells {
+				got := perr.Cells[i]
+				if got.Row != want.Row || got.Column != want.Column || got.Value != want.Value {
+					t.Errorf("Cells[%d] = %+v, 預期 %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseReserveHTMLNoTable(t *testing.T) {
+	page := `<html><body><table><tr><td>首頁</td></tr></table></body></html>`
+	_, err := ParseReserveHTML(bytes.NewReader([]byte(page)), testDate)
+	if !errors.Is(err, ErrReserveTableNotFound) {
+		t.Fatalf("錯誤 = %v, 預期 ErrReserveTableNotFound", err)
+	}
+}
+
+func TestMapColumns(t *testing.T) {
+	tests := []struct {
+		header string
+		key    string
+	}{
+		{"交易時段", colHour},
+		{"小時", colHour},
+		{"tran_hour", colHour},
+		{"TRAN_HOUR", colHour},
+		{"即時備轉（得標容量）", colSRBid},
+		{"即時備轉 得標量", colSRBid},
+		{"即時備轉合格交易者", colSRBidQSE},
+		{"即時備轉效能費1", colSRPerfPrice1},
+		{"補充備轉容量費", colSUPPrice},
+		{"sup_bid_nontrade", colSUPBidNonTrd},
+		{"日期", colDate},
+		{"Date", colDate},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			columns := mapColumns([]string{normalizeHeader(tt.header)})
+			if idx, ok := columns[tt.key]; !ok || idx != 0 {
+				t.Errorf("%q 對應 %v, 預期 %s", tt.header, columns, tt.key)
+			}
+		})
+	}
+
+	if columns := mapColumns([]string{normalizeHeader("備註")}); len(columns) != 0 {
+		t.Errorf("未知表頭不應對應欄位: %v", columns)
+	}
+}
+
+func TestParseTableDate(t *testing.T) {
+	want := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	for _, s := range []string{"2024-01-02", "2024/01/02", "20240102", "2024/1/2", "113/01/02", "113-1-2", " 113.01.02 "} {
+		got, err := parseTableDate(s)
+		if err != nil || !got.Equal(want) {
+			t.Errorf("parseTableDate(%q) = %v, %v, 預期 %v", s, got, err, want)
+		}
+	}
+
+	if _, err := parseTableDate("一月二日"); err == nil {
+		t.Error("無效日期應返回錯誤")
+	}
+}
@@ -0,0 +1,246 @@
+package collectors
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"vpp-go/internal/httpclient"
+	"vpp-go/internal/models"
+)
+
+// 備轉資料來源類型
+const (
+	SourceHTML = "html"
+	SourceCSV  = "csv"
+	SourceJSON = "json"
+)
+
+// ReserveSource 台電備轉資料來源
+type ReserveSource interface {
+	// Name 來源類型
+	Name() string
+	// Fetch 獲取指定日期的24個時段資料
+	Fetch(ctx context.Context, date time.Time) ([]models.TaipowerReserveData, error)
+}
+
+// NewReserveSource 依類型創建備轉資料來源
+// urlTemplate 中的 {date} 會替換為 YYYYMMDD，{iso_date} 替換為 YYYY-MM-DD
+func NewReserveSource(kind, urlTemplate string, client *httpclient.Client) (ReserveSource, error) {
+	fetcher := sourceFetcher{URLTemplate: urlTemplate, HTTP: client}
+
+	switch strings.ToLower(kind) {
+	case SourceHTML, "":
+		return &HTMLReserveSource{fetcher}, nil
+	case SourceCSV:
+		return &CSVReserveSource{fetcher}, nil
+	case SourceJSON:
+		return &JSONReserveSource{fetcher}, nil
+	default:
+		return nil, fmt.Errorf("不支援的備轉資料來源: %s", kind)
+	}
+}
+
+// sourceFetcher 來源共用的下載邏輯
+type sourceFetcher struct {
+	URLTemplate string
+	HTTP        *httpclient.Client
+}
+
+// url 依日期展開URL範本
+func (f sourceFetcher) url(date time.Time) string {
+	return strings.NewReplacer(
+		"{date}", date.Format("20060102"),
+		"{iso_date}", date.Format("2006-01-02"),
+	).Replace(f.URLTemplate)
+}
+
+// get 下載指定日期的內容
+func (f sourceFetcher) get(ctx context.Context, date time.Time) ([]byte, error) {
+	resp, err := f.HTTP.Get(ctx, f.url(date))
+	if err != nil {
+		return nil, fmt.Errorf("請求失敗: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("網站返回錯誤狀態碼: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("讀取響應失敗: %w", err)
+	}
+	return body, nil
+}
+
+// HTMLReserveSource 爬取台電網站的備轉資料頁面
+type HTMLReserveSource struct {
+	sourceFetcher
+}
+
+// Name 來源類型
+func (s *HTMLReserveSource) Name() string {
+	return SourceHTML
+}
+
+// Fetch 獲取並解析HTML頁面
+func (s *HTMLReserveSource) Fetch(ctx context.Context, date time.Time) ([]models.TaipowerReserveData, error) {
+	body, err := s.get(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReserveHTML(bytes.NewReader(body), date)
+}
+
+// CSVReserveSource 台電開放資料CSV檔
+type CSVReserveSource struct {
+	sourceFetcher
+}
+
+// Name 來源類型
+func (s *CSVReserveSource) Name() string {
+	return SourceCSV
+}
+
+// Fetch 獲取並解析CSV檔
+func (s *CSVReserveSource) Fetch(ctx context.Context, date time.Time) ([]models.TaipowerReserveData, error) {
+	body, err := s.get(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReserveCSV(bytes.NewReader(body), date)
+}
+
+// JSONReserveSource 台電開放資料JSON檔
+type JSONReserveSource struct {
+	sourceFetcher
+}
+
+// Name 來源類型
+func (s *JSONReserveSource) Name() string {
+	return SourceJSON
+}
+
+// Fetch 獲取並解析JSON檔
+func (s *JSONReserveSource) Fetch(ctx context.Context, date time.Time) ([]models.TaipowerReserveData, error) {
+	body, err := s.get(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	return ParseReserveJSON(body, date)
+}
+
+// ParseReserveCSV 解析備轉資料CSV，第一列為表頭
+// 含交易日期欄位時只取指定日期的資料列
+func ParseReserveCSV(r io.Reader, date time.Time) ([]models.TaipowerReserveData, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("解析CSV失敗: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrReserveTableNotFound
+	}
+
+	// 移除 UTF-8 BOM
+	records[0][0] = strings.TrimPrefix(records[0][0], "\ufeff")
+
+	return parseRecords(records[0], records[1:], date)
+}
+
+// ParseReserveJSON 解析備轉資料JSON
+// 接受物件陣列，或包在 records / data / result.records 中的物件陣列
+func ParseReserveJSON(body []byte, date time.Time) ([]models.TaipowerReserveData, error) {
+	var items []map[string]interface{}
+	if err := json.Unmarshal(body, &items); err != nil {
+		var wrapper struct {
+			Records []map[string]interface{} `json:"records"`
+			Data    []map[string]interface{} `json:"data"`
+			Result  struct {
+				Records []map[string]interface{} `json:"records"`
+			} `json:"result"`
+		}
+		if err := json.Unmarshal(body, &wrapper); err != nil {
+			return nil, fmt.Errorf("解析JSON失敗: %w", err)
+		}
+		switch {
+		case len(wrapper.Records) > 0:
+			items = wrapper.Records
+		case len(wrapper.Data) > 0:
+			items = wrapper.Data
+		default:
+			items = wrapper.Result.Records
+		}
+	}
+	if len(items) == 0 {
+		return nil, ErrReserveTableNotFound
+	}
+
+	// 以所有物件的鍵值聯集作為表頭
+	keySet := make(map[string]bool)
+	for _, item := range items {
+		for key := range item {
+			keySet[key] = true
+		}
+	}
+	headers := make([]string, 0, len(keySet))
+	for key := range keySet {
+		headers = append(headers, key)
+	}
+	sort.Strings(headers)
+
+	rows := make([][]string, len(items))
+	for i, item := range items {
+		row := make([]string, len(headers))
+		for j, key := range headers {
+			row[j] = jsonCellText(item[key])
+		}
+		rows[i] = row
+	}
+
+	return parseRecords(headers, rows, date)
+}
+
+// parseRecords 以表頭對應欄位後解析資料列
+func parseRecords(headers []string, rows [][]string, date time.Time) ([]models.TaipowerReserveData, error) {
+	normalized := make([]string, len(headers))
+	for i, header := range headers {
+		normalized[i] = normalizeHeader(header)
+	}
+
+	columns := mapColumns(normalized)
+	if _, ok := columns[colHour]; !ok {
+		return nil, ErrReserveTableNotFound
+	}
+
+	t := &reserveTable{columns: columns, rows: rows}
+	if err := t.filterDate(date); err != nil {
+		return nil, err
+	}
+	return t.parse(date)
+}
+
+// jsonCellText 將JSON值轉為儲存格文字
+func jsonCellText(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,154 @@
+package collectors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vpp-go/internal/httpclient"
+)
+
+func TestReserveSourceFetch(t *testing.T) {
+	// 測試檔內容與 HTML 頁面相同，應解析出同一份 golden 資料
+	tests := []struct {
+		kind    string
+		fixture string
+	}{
+		{SourceHTML, "reserve_flat.html"},
+		{SourceCSV, "reserve_month.csv"},
+		{SourceJSON, "reserve_day.json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.kind, func(t *testing.T) {
+			body := readFixture(t, tt.fixture)
+			var gotPath string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+				w.Write(body)
+			}))
+			defer srv.Close()
+
+			source, err := NewReserveSource(tt.kind, srv.URL+"/reserve/{date}/{iso_date}", httpclient.New(httpclient.DefaultConfig()))
+			if err != nil {
+				t.Fatalf("創建來源失敗: %v", err)
+			}
+			if source.Name() != tt.kind {
+				t.Errorf("Name() = %s, 預期 %s", source.Name(), tt.kind)
+			}
+
+			got, err := source.Fetch(context.Background(), testDate)
+			if err != nil {
+				t.Fatalf("獲取失敗: %v", err)
+			}
+			if gotPath != "/reserve/20240102/2024-01-02" {
+				t.Errorf("請求路徑 = %s", gotPath)
+			}
+			checkGolden(t, "reserve_2024-01-02.golden.json", got)
+		})
+	}
+}
+
+func TestReserveSourceErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		kind    string
+		fixture string
+		hours   int
+		cell    *CellError
+	}{
+		{
+			name:    "CSV無法解析的儲存格",
+			kind:    SourceCSV,
+			fixture: "reserve_bad_cell.csv",
+			hours:   23,
+			cell:    &CellError{Row: 10, Column: colSRBidNonTrade, Value: "-"},
+		},
+		{
+			name:    "JSON缺少時段",
+			kind:    SourceJSON,
+			fixture: "reserve_missing_hour.json",
+			hours:   23,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := readFixture(t, tt.fixture)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write(body)
+			}))
+			defer srv.Close()
+
+			source, err := NewReserveSource(tt.kind, srv.URL, httpclient.New(httpclient.DefaultConfig()))
+			if err != nil {
+				t.Fatalf("創建來源失敗: %v", err)
+			}
+			_, err = source.Fetch(context.Background(), testDate)
+
+			var perr *ReserveParseError
+			if !errors.As(err, &perr) {
+				t.Fatalf("錯誤 = %v, 預期 *ReserveParseError", err)
+			}
+			if perr.Hours != tt.hours {
+				t.Errorf("Hours = %d, 預期 %d", perr.Hours, tt.hours)
+			}
+			if tt.cell == nil {
+				if len(perr.Cells) != 0 {
+					t.Errorf("Cells = %v, 預期無", perr.Cells)
+				}
+				return
+			}
+			if len(perr.Cells) != 1 {
+				t.Fatalf("Cells = %v, 預期 1 個", perr.Cells)
+			}
+			got := perr.Cells[0]
+			if got.Row != tt.cell.Row || got.Column != tt.cell.Column || got.Value != tt.cell.Value {
+				t.Errorf("Cells[0] = %+v, 預期 %+v", got, tt.cell)
+			}
+		})
+	}
+}
+
+func TestReserveSourceHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	source, _ := NewReserveSource(SourceCSV, srv.URL, httpclient.New(httpclient.DefaultConfig()))
+	if _, err := source.Fetch(context.Background(), testDate); err == nil {
+		t.Fatal("404 應返回錯誤")
+	}
+}
+
+func TestParseReserveJSONShapes(t *testing.T) {
+	// 物件陣列、records、data 及 result.records 四種包裝
+	records := string(readFixture(t, "reserve_missing_hour.json"))
+	for _, body := range []string{
+		records,
+		`{"records": ` + records + `}`,
+		`{"data": ` + records + `}`,
+		`{"result": {"records": ` + records + `}}`,
+	} {
+		_, err := ParseReserveJSON([]byte(body), testDate)
+		var perr *ReserveParseError
+		if !errors.As(err, &perr) || perr.Hours != 23 {
+			t.Errorf("錯誤 = %v, 預期解析出 23 個時段", err)
+		}
+	}
+
+	if _, err := ParseReserveJSON([]byte(`{"records": []}`), testDate); !errors.Is(err, ErrReserveTableNotFound) {
+		t.Errorf("空資料錯誤 = %v, 預期 ErrReserveTableNotFound", err)
+	}
+}
+
+func TestNewReserveSourceUnknown(t *testing.T) {
+	if _, err := NewReserveSource("xml", "http://example.com", nil); err == nil {
+		t.Fatal("不支援的來源應返回錯誤")
+	}
+	if s, err := NewReserveSource("", "http://example.com", nil); err != nil || s.Name() != SourceHTML {
+		t.Fatalf("空白類型應預設為 HTML: %v, %v", s, err)
+	}
+}
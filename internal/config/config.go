@@ -1,16 +1,28 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
 // Config 應用配置結構
 type Config struct {
-	Database DatabaseConfig
-	App      AppConfig
-	External ExternalConfig
+	Database  DatabaseConfig
+	App       AppConfig
+	External  ExternalConfig
+	Scheduler SchedulerConfig
+	Outbound  OutboundConfig
+	Dispatch  DispatchConfig
+	Market    MarketConfig
+	Forecast  ForecastConfig
+	Stream    StreamConfig
+	Events    EventsConfig
+	Auth      AuthConfig
+	Sites     []SiteConfig
 }
 
 // DatabaseConfig 資料庫配置
@@ -21,18 +33,147 @@ type DatabaseConfig struct {
 	Password string
 	DBName   string
 	SSLMode  string
+	// AutoMigrate 連接時自動套用尚未套用的遷移
+	AutoMigrate bool
 }
 
 // AppConfig 應用配置
 type AppConfig struct {
 	Port     string
 	Timezone *time.Location
+	// ShutdownTimeout 收到終止信號後等待進行中請求與任務結束的時間
+	ShutdownTimeout time.Duration
 }
 
 // ExternalConfig 外部API配置
 type ExternalConfig struct {
-	YihongAPIURL string
-	TaipowerURL  string
+	YihongAPIURL   string
+	YihongUsername string
+	YihongPassword string
+	TaipowerURL    string
+
+	// TaipowerSource 備轉資料來源：html、csv、json
+	TaipowerSource string
+	// TaipowerSourceURL 來源URL範本，{date} 為 YYYYMMDD，{iso_date} 為 YYYY-MM-DD
+	TaipowerSourceURL string
+}
+
+// SchedulerConfig 定時任務配置（cron 表達式）
+type SchedulerConfig struct {
+	Enabled      bool
+	SolarCron    string
+	TaipowerCron string
+	// CleanupCron 清理過期冪等請求記錄的排程
+	CleanupCron string
+	// IdempotencyTTL 冪等請求記錄保留時間
+	IdempotencyTTL time.Duration
+}
+
+// OutboundConfig 對外HTTP請求配置（重試、退避、熔斷）
+type OutboundConfig struct {
+	Timeout          time.Duration
+	MaxRetries       int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+}
+
+// DispatchConfig 派遣指令配置
+type DispatchConfig struct {
+	// Transport 指令傳送方式: http（POST 到場站閘道器）或 log（只記錄日誌）
+	Transport string
+	// Cron 送出待送指令與驗證已結束指令的排程
+	Cron string
+	// LeadTime 指令開始前多久送出
+	LeadTime time.Duration
+	// MaxAttempts 送出失敗的重試上限，超過即標記為失敗
+	MaxAttempts int
+	// Tolerance 實測響應與設定點的容許相對偏差
+	Tolerance float64
+	// VerifyDelay 指令結束後等待遙測補齊再驗證的時間
+	VerifyDelay time.Duration
+	// CommandTTL 閘道器設定類指令的預設有效時間，逾期未確認即不再送出
+	CommandTTL time.Duration
+}
+
+// MarketConfig 備轉市場投標配置
+type MarketConfig struct {
+	// LookbackDays 估計預期價格與太陽能出力所用的歷史天數
+	LookbackDays int
+	// MinSoC、MaxSoC 投標時保留的電量狀態上下限 %
+	MinSoC float64
+	MaxSoC float64
+	// ActivationRate 預期被調度的比例，用於估計投標時段消耗的電量
+	ActivationRate float64
+	// ChargeEfficiency 太陽能充電效率
+	ChargeEfficiency float64
+}
+
+// ForecastConfig 預測配置
+type ForecastConfig struct {
+	// SolarCron 重新訓練並產生太陽能發電預測的排程
+	SolarCron string
+	// LoadCron 重新訓練並產生負載預測的排程
+	LoadCron string
+	// TrainingDays 訓練模型所用的歷史天數
+	TrainingDays int
+	// Holidays、MakeupWorkdays 內建行事曆以外的國定假日與補行上班日（YYYY-MM-DD）
+	Holidays       []string
+	MakeupWorkdays []string
+}
+
+// StreamConfig 即時數據推送配置
+type StreamConfig struct {
+	// Heartbeat 無數據時發送心跳的間隔
+	Heartbeat time.Duration
+	// ReplaySize 保留最近幾筆訊息供斷線重連後補送
+	ReplaySize int
+	// ClientBuffer 每個連線的待送訊息上限，超過時中斷連線由客戶端重連補送
+	ClientBuffer int
+	// TicketTTL 即時推送連線票證的有效時間
+	TicketTTL time.Duration
+}
+
+// EventsConfig 事件匯流排配置
+type EventsConfig struct {
+	// Buffer 每個訂閱者的待處理事件上限
+	Buffer int
+	// BlockTimeout 訂閱者緩衝已滿時發佈端的最長等待時間（僅限等待型訂閱者）
+	BlockTimeout time.Duration
+}
+
+// AuthConfig 認證配置
+type AuthConfig struct {
+	// Enabled 關閉時所有路由皆不需認證，僅供本地開發
+	Enabled bool
+	// JWTSecret 使用者權杖的 HS256 簽章金鑰，至少 32 位元組
+	JWTSecret string
+	// JWTIssuer、JWTAudience 不為空時驗證權杖的 iss、aud
+	JWTIssuer   string
+	JWTAudience string
+	// AllowedOrigins 允許跨來源請求的來源，* 表示全部（此時不允許攜帶憑證）
+	AllowedOrigins []string
+}
+
+// SiteConfig 場站配置
+type SiteConfig struct {
+	ID           string
+	Name         string
+	APIURL       string
+	Username     string
+	Password     string
+	PollSchedule string
+	Timezone     *time.Location
+	// DispatchURL 場站閘道器接收派遣指令的URL
+	DispatchURL string
+	// StoragePowerKW 儲能系統額定功率，0 表示不參與投標
+	StoragePowerKW float64
+	// StorageCapacityKWh 儲能系統額定容量，0 時由遙測的可用能量與電量狀態推算
+	StorageCapacityKWh float64
+	// Latitude、Longitude 場站座標，用於計算晴空日照
+	Latitude  float64
+	Longitude float64
 }
 
 // 場站ID常數
@@ -42,32 +183,195 @@ const (
 	SiteSouth   = "south"
 )
 
-// Load 載入配置
-func Load() *Config {
+// defaultSiteNames 預設場站名稱
+var defaultSiteNames = map[string]string{
+	SiteNorth:   "北部場站",
+	SiteCentral: "中部場站",
+	SiteSouth:   "南部場站",
+}
+
+// defaultSiteCoordinates 預設場站座標（緯度、經度）
+var defaultSiteCoordinates = map[string][2]float64{
+	SiteNorth:   {25.03, 121.56},
+	SiteCentral: {24.15, 120.67},
+	SiteSouth:   {22.63, 120.30},
+}
+
+// registeredSites 已註冊的場站ID，由 Load 依配置更新
+var registeredSites = []string{SiteNorth, SiteCentral, SiteSouth}
+
+// Load 載入配置，場站配置無效或環境變數無法解析時返回錯誤
+func Load() (*Config, error) {
 	// 設置台灣時區 UTC+8
 	location, err := time.LoadLocation("Asia/Taipei")
 	if err != nil {
 		location = time.FixedZone("CST", 8*60*60)
 	}
 
-	return &Config{
+	env := &envReader{}
+	external := ExternalConfig{
+		YihongAPIURL:   getEnv("YIHONG_API_URL", "https://api.yihong-solar.com"),
+		YihongUsername: getEnv("YIHONG_USERNAME", ""),
+		YihongPassword: getEnv("YIHONG_PASSWORD", ""),
+		TaipowerURL:    getEnv("TAIPOWER_URL", "https://www.taipower.com.tw"),
+
+		TaipowerSource:    getEnv("TAIPOWER_SOURCE", "html"),
+		TaipowerSourceURL: getEnv("TAIPOWER_SOURCE_URL", ""),
+	}
+	schedulerCfg := SchedulerConfig{
+		Enabled:        env.getBool("SCHEDULER_ENABLED", true),
+		SolarCron:      getEnv("SOLAR_CRON", "*/15 * * * *"),
+		TaipowerCron:   getEnv("TAIPOWER_CRON", "0 2 * * *"),
+		CleanupCron:    getEnv("CLEANUP_CRON", "30 3 * * *"),
+		IdempotencyTTL: env.getDuration("IDEMPOTENCY_TTL", 7*24*time.Hour),
+	}
+	sites, err := loadSites(env, external, schedulerCfg, location)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", ""),
-			DBName:   getEnv("DB_NAME", "vpp_db"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:        getEnv("DB_HOST", "localhost"),
+			Port:        getEnv("DB_PORT", "5432"),
+			User:        getEnv("DB_USER", "postgres"),
+			Password:    getEnv("DB_PASSWORD", ""),
+			DBName:      getEnv("DB_NAME", "vpp_db"),
+			SSLMode:     getEnv("DB_SSLMODE", "disable"),
+			AutoMigrate: env.getBool("DB_AUTO_MIGRATE", false),
 		},
 		App: AppConfig{
-			Port:     getEnv("PORT", "8080"),
-			Timezone: location,
+			Port:            getEnv("PORT", "8080"),
+			ShutdownTimeout: env.getDuration("SHUTDOWN_TIMEOUT", 30*time.Second),
+			Timezone:        location,
+		},
+		External:  external,
+		Scheduler: schedulerCfg,
+		Dispatch: DispatchConfig{
+			Transport:   getEnv("DISPATCH_TRANSPORT", "log"),
+			Cron:        getEnv("DISPATCH_CRON", "* * * * *"),
+			LeadTime:    env.getDuration("DISPATCH_LEAD_TIME", 5*time.Minute),
+			MaxAttempts: env.getInt("DISPATCH_MAX_ATTEMPTS", 5),
+			Tolerance:   env.getFloat("DISPATCH_TOLERANCE", 0.2),
+			VerifyDelay: env.getDuration("DISPATCH_VERIFY_DELAY", 15*time.Minute),
+			CommandTTL:  env.getDuration("DEVICE_COMMAND_TTL", 24*time.Hour),
+		},
+		Market: MarketConfig{
+			LookbackDays:     env.getInt("BID_LOOKBACK_DAYS", 28),
+			MinSoC:           env.getFloat("BID_MIN_SOC", 10),
+			MaxSoC:           env.getFloat("BID_MAX_SOC", 95),
+			ActivationRate:   env.getFloat("BID_ACTIVATION_RATE", 0.1),
+			ChargeEfficiency: env.getFloat("BID_CHARGE_EFFICIENCY", 0.9),
+		},
+		Forecast: ForecastConfig{
+			SolarCron:      getEnv("SOLAR_FORECAST_CRON", "10 */6 * * *"),
+			LoadCron:       getEnv("LOAD_FORECAST_CRON", "20 */6 * * *"),
+			TrainingDays:   env.getInt("FORECAST_TRAINING_DAYS", 60),
+			Holidays:       getEnvList("HOLIDAYS", nil),
+			MakeupWorkdays: getEnvList("MAKEUP_WORKDAYS", nil),
 		},
-		External: ExternalConfig{
-			YihongAPIURL: getEnv("YIHONG_API_URL", "https://api.yihong-solar.com"),
-			TaipowerURL:  getEnv("TAIPOWER_URL", "https://www.taipower.com.tw"),
+		Stream: StreamConfig{
+			Heartbeat:    env.getDuration("STREAM_HEARTBEAT", 15*time.Second),
+			ReplaySize:   env.getInt("STREAM_REPLAY_SIZE", 1000),
+			ClientBuffer: env.getInt("STREAM_CLIENT_BUFFER", 256),
+			TicketTTL:    env.getDuration("STREAM_TICKET_TTL", 30*time.Second),
 		},
+		Events: EventsConfig{
+			Buffer:       env.getInt("EVENT_BUFFER", 1024),
+			BlockTimeout: env.getDuration("EVENT_BLOCK_TIMEOUT", 100*time.Millisecond),
+		},
+		Auth: AuthConfig{
+			Enabled:        env.getBool("AUTH_ENABLED", true),
+			JWTSecret:      getEnv("JWT_SECRET", ""),
+			JWTIssuer:      getEnv("JWT_ISSUER", ""),
+			JWTAudience:    getEnv("JWT_AUDIENCE", ""),
+			AllowedOrigins: getEnvList("CORS_ALLOWED_ORIGINS", nil),
+		},
+		Outbound: OutboundConfig{
+			Timeout:          env.getDuration("HTTP_TIMEOUT", 30*time.Second),
+			MaxRetries:       env.getInt("HTTP_MAX_RETRIES", 3),
+			BaseDelay:        env.getDuration("HTTP_RETRY_BASE_DELAY", 500*time.Millisecond),
+			MaxDelay:         env.getDuration("HTTP_RETRY_MAX_DELAY", 30*time.Second),
+			BreakerThreshold: env.getInt("HTTP_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:  env.getDuration("HTTP_BREAKER_COOLDOWN", time.Minute),
+		},
+		Sites: sites,
+	}
+	if err := env.err(); err != nil {
+		return nil, err
+	}
+
+	registeredSites = make([]string, 0, len(sites))
+	for _, site := range sites {
+		registeredSites = append(registeredSites, site.ID)
 	}
+	return cfg, nil
+}
+
+// loadSites 載入場站配置
+// 場站清單由 SITES 指定（逗號分隔），各場站以 SITE_<ID>_ 前綴的環境變數覆寫，未設定的排程沿用全域配置
+// 義鴻API的 URL 與帳密須逐場站設定，只有單一場站時才沿用 YIHONG_* 全域配置，避免多個場站收集同一來源；
+// 未設定 API URL 的場站不建立太陽能收集器（數據僅由閘道器上傳）
+func loadSites(env *envReader, external ExternalConfig, schedulerCfg SchedulerConfig, defaultLocation *time.Location) ([]SiteConfig, error) {
+	ids := getEnvList("SITES", []string{SiteNorth, SiteCentral, SiteSouth})
+
+	var fallback ExternalConfig
+	if len(ids) == 1 {
+		fallback = external
+	}
+
+	sites := make([]SiteConfig, 0, len(ids))
+	for _, id := range ids {
+		prefix := "SITE_" + strings.ToUpper(id) + "_"
+
+		name := defaultSiteNames[id]
+		if name == "" {
+			name = id
+		}
+
+		coordinates, ok := defaultSiteCoordinates[id]
+		if !ok {
+			coordinates = [2]float64{23.7, 120.96} // 台灣中心
+		}
+
+		location := defaultLocation
+		if tz := os.Getenv(prefix + "TIMEZONE"); tz != "" {
+			loc, err := time.LoadLocation(tz)
+			if err != nil {
+				return nil, fmt.Errorf("無效的 %sTIMEZONE %q: %w", prefix, tz, err)
+			}
+			location = loc
+		}
+
+		sites = append(sites, SiteConfig{
+			ID:           id,
+			Name:         getEnv(prefix+"NAME", name),
+			APIURL:       getEnv(prefix+"API_URL", fallback.YihongAPIURL),
+			Username:     getEnv(prefix+"USERNAME", fallback.YihongUsername),
+			Password:     getEnv(prefix+"PASSWORD", fallback.YihongPassword),
+			PollSchedule: getEnv(prefix+"POLL_SCHEDULE", schedulerCfg.SolarCron),
+			Timezone:     location,
+			DispatchURL:  os.Getenv(prefix + "DISPATCH_URL"),
+
+			StoragePowerKW:     env.getFloat(prefix+"STORAGE_POWER_KW", 0),
+			StorageCapacityKWh: env.getFloat(prefix+"STORAGE_CAPACITY_KWH", 0),
+
+			Latitude:  env.getFloat(prefix+"LATITUDE", coordinates[0]),
+			Longitude: env.getFloat(prefix+"LONGITUDE", coordinates[1]),
+		})
+	}
+
+	return sites, nil
+}
+
+// GetSite 依ID獲取場站配置
+func (c *Config) GetSite(siteID string) (SiteConfig, bool) {
+	for _, site := range c.Sites {
+		if site.ID == siteID {
+			return site, true
+		}
+	}
+	return SiteConfig{}, false
 }
 
 // GetDSN 獲取資料庫連接字符串
@@ -92,7 +396,110 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// envReader 讀取需解析的環境變數，已設定但無法解析的值記錄為錯誤，由 Load 一併返回
+type envReader struct {
+	errs []error
+}
+
+// lookup 返回已設定的環境變數值，未設定時返回 false
+func (r *envReader) lookup(key string) (string, bool) {
+	value := strings.TrimSpace(os.Getenv(key))
+	return value, value != ""
+}
+
+// fail 記錄無法解析的環境變數
+func (r *envReader) fail(key, value string, err error) {
+	r.errs = append(r.errs, fmt.Errorf("無效的 %s %q: %w", key, value, err))
+}
+
+// err 返回所有解析錯誤，沒有錯誤時返回 nil
+func (r *envReader) err() error {
+	return errors.Join(r.errs...)
+}
+
+// getBool 獲取布林環境變數，未設定時返回默認值
+func (r *envReader) getBool(key string, defaultValue bool) bool {
+	raw, ok := r.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.ParseBool(raw)
+	if err != nil {
+		r.fail(key, raw, err)
+		return defaultValue
+	}
+	return value
+}
+
+// getInt 獲取整數環境變數，未設定時返回默認值
+func (r *envReader) getInt(key string, defaultValue int) int {
+	raw, ok := r.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		r.fail(key, raw, err)
+		return defaultValue
+	}
+	return value
+}
+
+// getFloat 獲取浮點數環境變數，未設定時返回默認值
+func (r *envReader) getFloat(key string, defaultValue float64) float64 {
+	raw, ok := r.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		r.fail(key, raw, err)
+		return defaultValue
+	}
+	return value
+}
+
+// getDuration 獲取時間間隔環境變數（如 30s、5m，須帶單位），未設定時返回默認值
+func (r *envReader) getDuration(key string, defaultValue time.Duration) time.Duration {
+	raw, ok := r.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		r.fail(key, raw, err)
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvList 獲取逗號分隔的環境變數列表
+func getEnvList(key string, defaultValue []string) []string {
+	var values []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, strings.ToLower(v))
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
 // IsValidSite 檢查場站ID是否有效
 func IsValidSite(siteID string) bool {
-	return siteID == SiteNorth || siteID == SiteCentral || siteID == SiteSouth
+	for _, id := range registeredSites {
+		if id == siteID {
+			return true
+		}
+	}
+	return false
+}
+
+// SiteIDs 獲取所有已註冊的場站ID
+func SiteIDs() []string {
+	ids := make([]string, len(registeredSites))
+	copy(ids, registeredSites)
+	return ids
 }
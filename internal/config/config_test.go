@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEnvReader(t *testing.T) {
+	t.Setenv("TEST_BOOL", "false")
+	t.Setenv("TEST_INT", " 42 ")
+	t.Setenv("TEST_FLOAT", "0.5")
+	t.Setenv("TEST_DURATION", "5m")
+
+	env := &envReader{}
+	if got := env.getBool("TEST_BOOL", true); got {
+		t.Error("getBool = true, 預期 false")
+	}
+	if got := env.getInt("TEST_INT", 1); got != 42 {
+		t.Errorf("getInt = %d, 預期 42", got)
+	}
+	if got := env.getFloat("TEST_FLOAT", 1); got != 0.5 {
+		t.Errorf("getFloat = %g, 預期 0.5", got)
+	}
+	if got := env.getDuration("TEST_DURATION", time.Second); got != 5*time.Minute {
+		t.Errorf("getDuration = %v, 預期 5m", got)
+	}
+	// 未設定時使用預設值
+	if got := env.getInt("TEST_UNSET", 7); got != 7 {
+		t.Errorf("未設定時 getInt = %d, 預期預設值 7", got)
+	}
+	if err := env.err(); err != nil {
+		t.Errorf("不應有錯誤: %v", err)
+	}
+}
+
+func TestEnvReaderInvalid(t *testing.T) {
+	t.Setenv("TEST_BOOL", "flase")
+	t.Setenv("TEST_INT", "12x")
+	t.Setenv("TEST_FLOAT", "abc")
+	t.Setenv("TEST_DURATION", "30")
+
+	env := &envReader{}
+	env.getBool("TEST_BOOL", true)
+	env.getInt("TEST_INT", 1)
+	env.getFloat("TEST_FLOAT", 1)
+	env.getDuration("TEST_DURATION", time.Second)
+
+	err := env.err()
+	if err == nil {
+		t.Fatal("無法解析的值應返回錯誤")
+	}
+	for _, want := range []string{`TEST_BOOL "flase"`, `TEST_INT "12x"`, `TEST_FLOAT "abc"`, `TEST_DURATION "30"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("錯誤 %q 未列出 %s", err, want)
+		}
+	}
+}
+
+func TestLoadInvalidEnv(t *testing.T) {
+	t.Setenv("SITES", "north")
+	t.Setenv("SCHEDULER_ENABLED", "flase")
+	t.Setenv("HTTP_TIMEOUT", "30")
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "SCHEDULER_ENABLED") || !strings.Contains(err.Error(), "HTTP_TIMEOUT") {
+		t.Errorf("Load 錯誤 = %v, 預期列出 SCHEDULER_ENABLED 及 HTTP_TIMEOUT", err)
+	}
+}
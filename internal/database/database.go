@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -28,6 +29,14 @@ func InitDB(cfg *config.Config) (*sql.DB, error) {
 	db.SetMaxIdleConns(5)
 
 	log.Println("資料庫連接成功")
+
+	if cfg.Database.AutoMigrate {
+		if _, err := MigrateUp(context.Background(), db); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("自動遷移失敗: %w", err)
+		}
+	}
+
 	return db, nil
 }
 
@@ -62,25 +71,43 @@ func ExecuteInsert(db *sql.DB, query string, args ...interface{}) (int64, error)
 	return id, nil
 }
 
+// BatchStatement 批次語句及其參數列表
+type BatchStatement struct {
+	Query    string
+	DataList [][]interface{}
+}
+
 // ExecuteBatch 批次執行插入操作
 func ExecuteBatch(db *sql.DB, query string, dataList [][]interface{}) error {
+	return ExecuteBatches(db, BatchStatement{Query: query, DataList: dataList})
+}
+
+// ExecuteBatches 在同一個事務中依序批次執行多個語句，任一失敗即全部回滾
+func ExecuteBatches(db *sql.DB, statements ...BatchStatement) error {
 	tx, err := db.Begin()
 	if err != nil {
 		return fmt.Errorf("無法開始事務: %w", err)
 	}
 
-	stmt, err := tx.Prepare(query)
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("無法準備語句: %w", err)
-	}
-	defer stmt.Close()
+	for _, batch := range statements {
+		if len(batch.DataList) == 0 {
+			continue
+		}
 
-	for _, data := range dataList {
-		if _, err := stmt.Exec(data...); err != nil {
+		stmt, err := tx.Prepare(batch.Query)
+		if err != nil {
 			tx.Rollback()
-			return fmt.Errorf("批次執行失敗: %w", err)
+			return fmt.Errorf("無法準備語句: %w", err)
+		}
+
+		for _, data := range batch.DataList {
+			if _, err := stmt.Exec(data...); err != nil {
+				stmt.Close()
+				tx.Rollback()
+				return fmt.Errorf("批次執行失敗: %w", err)
+			}
 		}
+		stmt.Close()
 	}
 
 	if err := tx.Commit(); err != nil {
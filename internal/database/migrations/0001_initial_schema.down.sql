@@ -0,0 +1,6 @@
+-- 資料表可能是沿用的既有正式資料表，回滾只移除本遷移加上的索引與檢查，不刪除資料
+ALTER TABLE IF EXISTS taipower_reserve_data DROP CONSTRAINT IF EXISTS taipower_reserve_data_tran_hour_check;
+DROP INDEX IF EXISTS taipower_reserve_data_tran_date_tran_hour_key;
+DROP INDEX IF EXISTS load_data_site_id_datetime_key;
+DROP INDEX IF EXISTS solar_data_site_id_datetime_key;
+DROP INDEX IF EXISTS stu_site_id_timestamp_idx;
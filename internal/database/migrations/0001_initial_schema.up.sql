@@ -0,0 +1,116 @@
+-- 初始資料表，沿用原 Flask 專案的表名與欄位
+-- 已存在的表（由 init_db.py 建立）只補上 ON CONFLICT 所需的唯一索引；
+-- 舊資料可能有重複列或以 1~24 表示的時段，建立索引與檢查前先整理：重複列只保留最後寫入（id 最大）的一筆
+
+CREATE TABLE IF NOT EXISTS stu (
+    id        SERIAL PRIMARY KEY,
+    site_id   VARCHAR(50) NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    data      TEXT
+);
+
+CREATE INDEX IF NOT EXISTS stu_site_id_timestamp_idx ON stu (site_id, timestamp);
+
+CREATE TABLE IF NOT EXISTS solar_data (
+    id                           SERIAL PRIMARY KEY,
+    site_id                      VARCHAR(50) NOT NULL,
+    datetime                     TIMESTAMPTZ NOT NULL,
+    daily_generation             DOUBLE PRECISION NOT NULL DEFAULT 0,
+    solar_radiation              DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ac_avg_voltage               DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ac_total_power               DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ac_total_current             DOUBLE PRECISION NOT NULL DEFAULT 0,
+    dc_avg_voltage               DOUBLE PRECISION NOT NULL DEFAULT 0,
+    dc_total_power               DOUBLE PRECISION NOT NULL DEFAULT 0,
+    dc_total_current             DOUBLE PRECISION NOT NULL DEFAULT 0,
+    module_temperature           DOUBLE PRECISION NOT NULL DEFAULT 0,
+    total_accumulated_generation DOUBLE PRECISION NOT NULL DEFAULT 0,
+    co2_reduction                DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+
+DELETE FROM solar_data
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY site_id, datetime ORDER BY id DESC) AS rn
+        FROM solar_data
+    ) ranked
+    WHERE rn > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS solar_data_site_id_datetime_key ON solar_data (site_id, datetime);
+
+CREATE TABLE IF NOT EXISTS load_data (
+    id         SERIAL PRIMARY KEY,
+    site_id    VARCHAR(50) NOT NULL,
+    datetime   TIMESTAMPTZ NOT NULL,
+    load_value DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+
+DELETE FROM load_data
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY site_id, datetime ORDER BY id DESC) AS rn
+        FROM load_data
+    ) ranked
+    WHERE rn > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS load_data_site_id_datetime_key ON load_data (site_id, datetime);
+
+CREATE TABLE IF NOT EXISTS taipower_reserve_data (
+    id               SERIAL PRIMARY KEY,
+    tran_date        DATE NOT NULL,
+    tran_hour        SMALLINT NOT NULL,
+    sr_bid           DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sr_bid_qse       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sr_bid_nontrade  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sr_price         DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sr_perf_price_1  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sr_perf_price_2  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sr_perf_price_3  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sup_bid          DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sup_bid_qse      DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sup_bid_nontrade DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sup_price        DOUBLE PRECISION NOT NULL DEFAULT 0
+);
+
+-- 原收集器直接寫入台電表格的時段，有 24 而沒有 0 的日期視為 1~24，轉為 0~23（與收集器的 normalizeHours 相同）
+-- 先轉為負數再轉回，避免逐列更新時與同日其他時段衝突
+UPDATE taipower_reserve_data SET tran_hour = -tran_hour
+WHERE tran_date IN (
+    SELECT tran_date FROM taipower_reserve_data
+    GROUP BY tran_date
+    HAVING MAX(tran_hour) = 24 AND MIN(tran_hour) > 0
+);
+UPDATE taipower_reserve_data SET tran_hour = -tran_hour - 1 WHERE tran_hour < 0;
+
+DELETE FROM taipower_reserve_data
+WHERE id IN (
+    SELECT id FROM (
+        SELECT id, ROW_NUMBER() OVER (PARTITION BY tran_date, tran_hour ORDER BY id DESC) AS rn
+        FROM taipower_reserve_data
+    ) ranked
+    WHERE rn > 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS taipower_reserve_data_tran_date_tran_hour_key ON taipower_reserve_data (tran_date, tran_hour);
+
+-- 時段檢查以 NOT VALID 建立，只約束新寫入的資料；舊資料皆在範圍內時才驗證，
+-- 否則保留未驗證狀態，待人工整理後執行 ALTER TABLE taipower_reserve_data VALIDATE CONSTRAINT taipower_reserve_data_tran_hour_check
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conname = 'taipower_reserve_data_tran_hour_check'
+          AND conrelid = 'taipower_reserve_data'::regclass
+    ) THEN
+        ALTER TABLE taipower_reserve_data
+            ADD CONSTRAINT taipower_reserve_data_tran_hour_check CHECK (tran_hour BETWEEN 0 AND 23) NOT VALID;
+    END IF;
+
+    IF NOT EXISTS (SELECT 1 FROM taipower_reserve_data WHERE tran_hour NOT BETWEEN 0 AND 23) THEN
+        ALTER TABLE taipower_reserve_data VALIDATE CONSTRAINT taipower_reserve_data_tran_hour_check;
+    ELSE
+        RAISE WARNING 'taipower_reserve_data 有時段不在 0~23 的資料，時段檢查未驗證';
+    END IF;
+END $$;
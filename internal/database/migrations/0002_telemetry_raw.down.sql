@@ -0,0 +1 @@
+DROP TABLE IF EXISTS telemetry_raw;
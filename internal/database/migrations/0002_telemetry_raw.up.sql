@@ -0,0 +1,16 @@
+-- 原始遙測記錄存檔（含被拒絕的記錄）
+
+CREATE TABLE telemetry_raw (
+    id          BIGSERIAL PRIMARY KEY,
+    site_id     VARCHAR(50) NOT NULL,
+    device_id   VARCHAR(100) NOT NULL DEFAULT '',
+    record_type VARCHAR(20) NOT NULL,
+    timestamp   TIMESTAMPTZ,
+    payload     JSONB NOT NULL,
+    status      VARCHAR(20) NOT NULL,
+    error       TEXT NOT NULL DEFAULT '',
+    received_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX telemetry_raw_site_id_received_at_idx ON telemetry_raw (site_id, received_at);
+CREATE INDEX telemetry_raw_rejected_idx ON telemetry_raw (received_at) WHERE status = 'rejected';
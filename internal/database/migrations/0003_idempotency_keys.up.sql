@@ -0,0 +1,11 @@
+-- 批次上傳的冪等請求記錄，response 保留原始位元組以便原樣回放
+
+CREATE TABLE idempotency_keys (
+    key          VARCHAR(255) PRIMARY KEY,
+    request_hash CHAR(64) NOT NULL,
+    status_code  INTEGER NOT NULL,
+    response     BYTEA NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idempotency_keys_created_at_idx ON idempotency_keys (created_at);
@@ -0,0 +1,16 @@
+-- 儲能系統數據，charge_power / discharge_power 皆為正值
+
+CREATE TABLE storage_data (
+    id               SERIAL PRIMARY KEY,
+    site_id          VARCHAR(50) NOT NULL,
+    datetime         TIMESTAMPTZ NOT NULL,
+    soc              DOUBLE PRECISION NOT NULL CHECK (soc BETWEEN 0 AND 100),
+    soh              DOUBLE PRECISION NOT NULL DEFAULT 0,
+    charge_power     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    discharge_power  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    cell_temperature DOUBLE PRECISION NOT NULL DEFAULT 0,
+    available_energy DOUBLE PRECISION NOT NULL DEFAULT 0,
+    mode             VARCHAR(20) NOT NULL DEFAULT 'idle'
+);
+
+CREATE UNIQUE INDEX storage_data_site_id_datetime_key ON storage_data (site_id, datetime);
@@ -0,0 +1,33 @@
+-- 派遣指令，power_kw 正值放電、負值充電
+-- 狀態流程: pending -> sent -> acknowledged -> executed，任一階段可能轉為 failed
+
+CREATE TABLE dispatch_commands (
+    id                 BIGSERIAL PRIMARY KEY,
+    dispatch_id        VARCHAR(64) NOT NULL,
+    site_id            VARCHAR(50) NOT NULL,
+    device_id          VARCHAR(100) NOT NULL DEFAULT '',
+    start_time         TIMESTAMPTZ NOT NULL,
+    end_time           TIMESTAMPTZ NOT NULL,
+    power_kw           DOUBLE PRECISION NOT NULL,
+    reason             TEXT NOT NULL DEFAULT '',
+    status             VARCHAR(20) NOT NULL DEFAULT 'pending',
+    transport          VARCHAR(20) NOT NULL DEFAULT '',
+    attempts           INTEGER NOT NULL DEFAULT 0,
+    error              TEXT NOT NULL DEFAULT '',
+    measured_kw        DOUBLE PRECISION,
+    measurement_source VARCHAR(20) NOT NULL DEFAULT '',
+    deviation          DOUBLE PRECISION,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at            TIMESTAMPTZ,
+    acknowledged_at    TIMESTAMPTZ,
+    executed_at        TIMESTAMPTZ,
+    failed_at          TIMESTAMPTZ,
+    updated_at         TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (end_time > start_time),
+    CHECK (status IN ('pending', 'sent', 'acknowledged', 'executed', 'failed'))
+);
+
+CREATE INDEX dispatch_commands_dispatch_id_idx ON dispatch_commands (dispatch_id);
+CREATE INDEX dispatch_commands_site_id_start_time_idx ON dispatch_commands (site_id, start_time);
+CREATE INDEX dispatch_commands_open_idx ON dispatch_commands (status, start_time)
+    WHERE status IN ('pending', 'sent', 'acknowledged');
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS dispatch_commands_poll_idx;
+DROP TABLE IF EXISTS device_commands;
@@ -0,0 +1,23 @@
+-- 閘道器設定類指令（派遣指令仍在 dispatch_commands），由閘道器以長輪詢領取
+-- 狀態流程: pending -> sent -> acknowledged / failed
+
+CREATE TABLE device_commands (
+    id              BIGSERIAL PRIMARY KEY,
+    device_id       VARCHAR(100) NOT NULL,
+    site_id         VARCHAR(50) NOT NULL DEFAULT '',
+    type            VARCHAR(50) NOT NULL,
+    payload         JSONB NOT NULL DEFAULT '{}',
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending',
+    result          TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    sent_at         TIMESTAMPTZ,
+    acknowledged_at TIMESTAMPTZ,
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (status IN ('pending', 'sent', 'acknowledged', 'failed'))
+);
+
+CREATE INDEX device_commands_open_idx ON device_commands (device_id, created_at)
+    WHERE status IN ('pending', 'sent');
+
+CREATE INDEX dispatch_commands_poll_idx ON dispatch_commands (site_id, start_time)
+    WHERE transport = 'poll' AND status IN ('pending', 'sent');
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS bid_plan_hours;
+DROP TABLE IF EXISTS bid_plans;
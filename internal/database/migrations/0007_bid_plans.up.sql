@@ -0,0 +1,25 @@
+-- 備轉市場投標計畫，每次產生的計畫皆保存以便與實際結清價格比較
+-- product: sr（即時備轉）或 sup（補充備轉）；sites 為各場站分配的容量 (MW)
+
+CREATE TABLE bid_plans (
+    id                BIGSERIAL PRIMARY KEY,
+    plan_date         DATE NOT NULL,
+    performance_grade INTEGER NOT NULL,
+    lookback_days     INTEGER NOT NULL,
+    expected_revenue  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    sites             JSONB NOT NULL DEFAULT '[]',
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX bid_plans_plan_date_idx ON bid_plans (plan_date, created_at DESC);
+
+CREATE TABLE bid_plan_hours (
+    plan_id          BIGINT NOT NULL REFERENCES bid_plans (id) ON DELETE CASCADE,
+    hour             INTEGER NOT NULL CHECK (hour BETWEEN 0 AND 23),
+    product          VARCHAR(10) NOT NULL CHECK (product IN ('sr', 'sup')),
+    capacity_mw      DOUBLE PRECISION NOT NULL,
+    expected_price   DOUBLE PRECISION NOT NULL,
+    expected_revenue DOUBLE PRECISION NOT NULL,
+    sites            JSONB NOT NULL DEFAULT '{}',
+    PRIMARY KEY (plan_id, hour, product)
+);
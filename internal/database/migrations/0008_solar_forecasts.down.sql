@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS solar_forecasts;
+DROP TABLE IF EXISTS solar_forecast_runs;
@@ -0,0 +1,28 @@
+-- 太陽能發電預測：每次訓練產生一筆 run（含模型係數），預測值為 15 分鐘區間的平均交流功率 (kW)
+
+CREATE TABLE solar_forecast_runs (
+    id             BIGSERIAL PRIMARY KEY,
+    site_id        VARCHAR(50) NOT NULL,
+    issued_at      TIMESTAMPTZ NOT NULL,
+    training_start TIMESTAMPTZ NOT NULL,
+    training_end   TIMESTAMPTZ NOT NULL,
+    samples        INTEGER NOT NULL,
+    clearness      DOUBLE PRECISION NOT NULL,
+    coefficients   JSONB NOT NULL DEFAULT '[]',
+    fit_rmse       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX solar_forecast_runs_site_id_issued_at_idx ON solar_forecast_runs (site_id, issued_at DESC);
+
+CREATE TABLE solar_forecasts (
+    run_id       BIGINT NOT NULL REFERENCES solar_forecast_runs (id) ON DELETE CASCADE,
+    site_id      VARCHAR(50) NOT NULL,
+    target_time  TIMESTAMPTZ NOT NULL,
+    issued_at    TIMESTAMPTZ NOT NULL,
+    power_kw     DOUBLE PRECISION NOT NULL,
+    clear_sky    DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (run_id, target_time)
+);
+
+CREATE INDEX solar_forecasts_site_id_target_time_idx ON solar_forecasts (site_id, target_time, issued_at DESC);
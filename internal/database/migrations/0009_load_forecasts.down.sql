@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS load_forecasts;
+DROP TABLE IF EXISTS load_forecast_runs;
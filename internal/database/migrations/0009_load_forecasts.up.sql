@@ -0,0 +1,27 @@
+-- 負載預測：每次訓練產生一筆 run，預測值為 15 分鐘區間的平均負載 (kW)
+
+CREATE TABLE load_forecast_runs (
+    id             BIGSERIAL PRIMARY KEY,
+    site_id        VARCHAR(50) NOT NULL,
+    issued_at      TIMESTAMPTZ NOT NULL,
+    training_start TIMESTAMPTZ NOT NULL,
+    training_end   TIMESTAMPTZ NOT NULL,
+    samples        INTEGER NOT NULL,
+    level          DOUBLE PRECISION NOT NULL,
+    fit_rmse       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX load_forecast_runs_site_id_issued_at_idx ON load_forecast_runs (site_id, issued_at DESC);
+
+CREATE TABLE load_forecasts (
+    run_id      BIGINT NOT NULL REFERENCES load_forecast_runs (id) ON DELETE CASCADE,
+    site_id     VARCHAR(50) NOT NULL,
+    target_time TIMESTAMPTZ NOT NULL,
+    issued_at   TIMESTAMPTZ NOT NULL,
+    load_kw     DOUBLE PRECISION NOT NULL,
+    holiday     BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (run_id, target_time)
+);
+
+CREATE INDEX load_forecasts_site_id_target_time_idx ON load_forecasts (site_id, target_time, issued_at DESC);
@@ -0,0 +1,15 @@
+-- 閘道器上傳用的 API 金鑰，只保存 SHA-256 雜湊，每把金鑰限定一個設備與場站
+
+CREATE TABLE device_api_keys (
+    id           BIGSERIAL PRIMARY KEY,
+    device_id    VARCHAR(100) NOT NULL,
+    site_id      VARCHAR(50) NOT NULL,
+    name         VARCHAR(100) NOT NULL DEFAULT '',
+    key_prefix   VARCHAR(20) NOT NULL,
+    key_hash     CHAR(64) NOT NULL UNIQUE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at TIMESTAMPTZ,
+    revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX device_api_keys_device_id_idx ON device_api_keys (device_id);
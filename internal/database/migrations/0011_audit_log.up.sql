@@ -0,0 +1,39 @@
+-- 寫入與控制操作的稽核記錄，只可新增
+-- actor_type: user（使用者權杖）、device（閘道器 API 金鑰）、system（排程任務）、anonymous（未認證）
+-- result: success、denied（401/403）、failure
+
+CREATE TABLE audit_log (
+    id           BIGSERIAL PRIMARY KEY,
+    occurred_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor_type   VARCHAR(20) NOT NULL,
+    actor        VARCHAR(200) NOT NULL DEFAULT '',
+    action       VARCHAR(200) NOT NULL,
+    target       TEXT NOT NULL DEFAULT '',
+    sites        TEXT[] NOT NULL DEFAULT '{}',
+    payload_hash CHAR(64),
+    result       VARCHAR(20) NOT NULL,
+    status       INTEGER,
+    detail       TEXT NOT NULL DEFAULT '',
+    client_ip    VARCHAR(64) NOT NULL DEFAULT '',
+    duration_ms  BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX audit_log_occurred_at_idx ON audit_log (occurred_at DESC, id DESC);
+CREATE INDEX audit_log_actor_idx ON audit_log (actor, occurred_at DESC);
+CREATE INDEX audit_log_action_idx ON audit_log (action, occurred_at DESC);
+CREATE INDEX audit_log_sites_idx ON audit_log USING GIN (sites);
+
+-- 拒絕修改、刪除及清空
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log 只可新增記錄';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update_delete
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,5 @@
+-- 冪等記錄只供短期回放，回滾時清空以免不同設備的相同鍵違反主鍵約束
+DELETE FROM idempotency_keys;
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys DROP COLUMN device_id;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (key);
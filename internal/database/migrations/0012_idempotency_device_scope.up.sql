@@ -0,0 +1,6 @@
+-- 冪等鍵改為以設備區分，不同設備使用相同的鍵不會互相回放或衝突
+-- 既有記錄的 device_id 為空字串，過期後由 cleanup:idempotency 清除
+
+ALTER TABLE idempotency_keys ADD COLUMN device_id VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE idempotency_keys DROP CONSTRAINT idempotency_keys_pkey;
+ALTER TABLE idempotency_keys ADD PRIMARY KEY (device_id, key);
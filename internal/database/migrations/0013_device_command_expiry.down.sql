@@ -0,0 +1,6 @@
+-- 回滾時逾期的指令轉為 failed
+UPDATE device_commands SET status = 'failed' WHERE status = 'expired';
+ALTER TABLE device_commands DROP CONSTRAINT device_commands_status_check;
+ALTER TABLE device_commands ADD CONSTRAINT device_commands_status_check
+    CHECK (status IN ('pending', 'sent', 'acknowledged', 'failed'));
+ALTER TABLE device_commands DROP COLUMN expires_at;
@@ -0,0 +1,10 @@
+-- 設定類指令加上到期時間，逾期未確認的指令不再送出並標記為 expired
+-- 既有指令以建立時間加 24 小時為到期時間
+
+ALTER TABLE device_commands ADD COLUMN expires_at TIMESTAMPTZ;
+UPDATE device_commands SET expires_at = created_at + INTERVAL '24 hours';
+ALTER TABLE device_commands ALTER COLUMN expires_at SET NOT NULL;
+
+ALTER TABLE device_commands DROP CONSTRAINT device_commands_status_check;
+ALTER TABLE device_commands ADD CONSTRAINT device_commands_status_check
+    CHECK (status IN ('pending', 'sent', 'acknowledged', 'failed', 'expired'));
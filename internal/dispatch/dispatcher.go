@@ -0,0 +1,326 @@
+package dispatch
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/models"
+)
+
+const (
+	// batchSize 每次處理的指令數上限
+	batchSize = 200
+	// minToleranceKW 設定點接近零時的最小容許偏差
+	minToleranceKW = 1.0
+	// kickTimeout 新指令立即送出的逾時
+	kickTimeout = time.Minute
+)
+
+// 實測響應來源
+const (
+	SourceStorage = "storage"
+	SourceLoad    = "load"
+)
+
+// StorageSource 驗證用的儲能遙測
+type StorageSource interface {
+	// GetAveragePower 時段內的平均淨放電功率 (kW) 及樣本數
+	GetAveragePower(siteID string, start, end time.Time) (float64, int, error)
+}
+
+// LoadSource 驗證用的負載遙測
+type LoadSource interface {
+	// GetAverageLoad 時段內的平均負載 (kW) 及樣本數
+	GetAverageLoad(siteID string, start, end time.Time) (float64, int, error)
+}
+
+// Dispatcher 派遣指令的送出與執行驗證
+type Dispatcher struct {
+	Model     *models.DispatchModel
+	Commands  *models.DeviceCommandModel
+	Storage   StorageSource
+	Load      LoadSource
+	Transport Transport
+
+	LeadTime    time.Duration
+	MaxAttempts int
+	Tolerance   float64
+	VerifyDelay time.Duration
+	// CommandTTL 閘道器設定類指令的預設有效時間
+	CommandTTL time.Duration
+
+	mu sync.Mutex
+
+	// 新指令立即送出的背景 goroutine，Stop 時取消並等待結束
+	stopMu sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// 有新指令時關閉 updates 喚醒等待中的長輪詢
+	updatesMu sync.Mutex
+	updates   chan struct{}
+}
+
+// NewDispatcher 創建派遣器
+func NewDispatcher(db *sql.DB, transport Transport, cfg config.DispatchConfig) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		ctx:         ctx,
+		cancel:      cancel,
+		Model:       models.NewDispatchModel(db),
+		Commands:    models.NewDeviceCommandModel(db),
+		Storage:     models.NewStorageDataModel(db),
+		Load:        models.NewLoadDataModel(db),
+		Transport:   transport,
+		LeadTime:    cfg.LeadTime,
+		MaxAttempts: cfg.MaxAttempts,
+		Tolerance:   cfg.Tolerance,
+		VerifyDelay: cfg.VerifyDelay,
+		CommandTTL:  cfg.CommandTTL,
+	}
+}
+
+// Submit 保存派遣指令並在背景立即送出已到期的指令
+// 派遣器已停止時只保存，由下次啟動後的排程送出
+func (d *Dispatcher) Submit(commands []models.DispatchCommand) error {
+	for i := range commands {
+		commands[i].Transport = d.Transport.Name()
+	}
+	if err := d.Model.Insert(commands); err != nil {
+		return err
+	}
+	d.Notify()
+	if d.Transport.Name() == TransportPoll {
+		return nil
+	}
+
+	// 持有鎖檢查並 Add，確保 Stop 開始等待後不會再啟動
+	d.stopMu.Lock()
+	if d.ctx.Err() != nil {
+		d.stopMu.Unlock()
+		return nil
+	}
+	d.wg.Add(1)
+	d.stopMu.Unlock()
+
+	go func() {
+		defer d.wg.Done()
+		ctx, cancel := context.WithTimeout(d.ctx, kickTimeout)
+		defer cancel()
+		if err := d.Deliver(ctx); err != nil {
+			log.Printf("派遣指令送出失敗: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// Stop 取消背景送出並等待結束，ctx 逾時時返回錯誤
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.stopMu.Lock()
+	d.cancel()
+	d.stopMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待派遣指令送出結束逾時: %w", ctx.Err())
+	}
+}
+
+// Name 任務名稱
+func (d *Dispatcher) Name() string {
+	return "dispatch"
+}
+
+// Run 排程執行：送出到期指令並驗證已結束的指令
+func (d *Dispatcher) Run(ctx context.Context) error {
+	if err := d.Deliver(ctx); err != nil {
+		return err
+	}
+	return d.Verify(ctx)
+}
+
+// Deliver 送出即將開始的待送指令，逾期未送出者標記為失敗，逾期未確認的設定類指令標記為逾期
+func (d *Dispatcher) Deliver(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+	expired, err := d.Model.ExpirePending(now)
+	if err != nil {
+		return fmt.Errorf("標記逾期指令失敗: %w", err)
+	}
+	if expired > 0 {
+		log.Printf("%d 筆派遣指令逾期未送達\n", expired)
+	}
+	expired, err = d.Commands.ExpirePending(now)
+	if err != nil {
+		return fmt.Errorf("標記逾期設定類指令失敗: %w", err)
+	}
+	if expired > 0 {
+		log.Printf("%d 筆設定類指令逾期未確認\n", expired)
+	}
+
+	// 輪詢方式由閘道器主動領取
+	if d.Transport.Name() == TransportPoll {
+		return nil
+	}
+
+	commands, err := d.Model.ListDue(now.Add(d.LeadTime), now, batchSize)
+	if err != nil {
+		return fmt.Errorf("查詢待送指令失敗: %w", err)
+	}
+
+	for i := range commands {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		d.send(ctx, &commands[i])
+	}
+	return nil
+}
+
+// send 送出單筆指令並更新狀態
+func (d *Dispatcher) send(ctx context.Context, cmd *models.DispatchCommand) {
+	acknowledged, err := d.Transport.Send(ctx, cmd)
+	if err != nil {
+		attempts, recordErr := d.Model.RecordAttempt(cmd.ID, err.Error())
+		if recordErr != nil {
+			log.Printf("記錄派遣指令 #%d 送出失敗時出錯: %v\n", cmd.ID, recordErr)
+			return
+		}
+		log.Printf("派遣指令 #%d 送出失敗 (第 %d 次): %v\n", cmd.ID, attempts, err)
+		if attempts >= d.MaxAttempts {
+			d.transition(cmd.ID, models.DispatchFailed, fmt.Sprintf("送出失敗 %d 次: %v", attempts, err), models.DispatchPending)
+		}
+		return
+	}
+
+	d.transition(cmd.ID, models.DispatchSent, "", models.DispatchPending)
+	if acknowledged {
+		d.transition(cmd.ID, models.DispatchAcknowledged, "", models.DispatchSent)
+	}
+}
+
+// ClaimForDevice 閘道器領取即將開始的輪詢指令
+func (d *Dispatcher) ClaimForDevice(deviceID, siteID string) ([]models.DispatchCommand, error) {
+	now := time.Now()
+	return d.Model.ClaimForDevice(deviceID, siteID, TransportPoll, now.Add(d.LeadTime), now, batchSize)
+}
+
+// Notify 喚醒等待新指令的長輪詢
+func (d *Dispatcher) Notify() {
+	d.updatesMu.Lock()
+	defer d.updatesMu.Unlock()
+	if d.updates != nil {
+		close(d.updates)
+		d.updates = nil
+	}
+}
+
+// Updates 返回下次有新指令時關閉的通道
+func (d *Dispatcher) Updates() <-chan struct{} {
+	d.updatesMu.Lock()
+	defer d.updatesMu.Unlock()
+	if d.updates == nil {
+		d.updates = make(chan struct{})
+	}
+	return d.updates
+}
+
+// transition 更新指令狀態，失敗只記錄日誌
+func (d *Dispatcher) transition(id int64, to, errMsg string, from ...string) {
+	if _, err := d.Model.Transition(id, to, errMsg, from...); err != nil {
+		log.Printf("更新派遣指令 #%d 狀態為 %s 失敗: %v\n", id, to, err)
+	}
+}
+
+// Verify 以遙測數據驗證已結束的指令，記錄實測響應
+func (d *Dispatcher) Verify(ctx context.Context) error {
+	commands, err := d.Model.ListUnverified(time.Now().Add(-d.VerifyDelay), batchSize)
+	if err != nil {
+		return fmt.Errorf("查詢待驗證指令失敗: %w", err)
+	}
+
+	for i := range commands {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := d.verify(&commands[i]); err != nil {
+			log.Printf("驗證派遣指令 #%d 失敗: %v\n", commands[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// verify 驗證單筆指令
+// 優先以儲能淨功率為實測響應；場站無儲能數據時以負載相對前一個等長時段的降幅計算
+func (d *Dispatcher) verify(cmd *models.DispatchCommand) error {
+	measured, source, err := d.measure(cmd)
+	if err != nil {
+		return err
+	}
+
+	if source == "" {
+		_, err := d.Model.RecordMeasurement(cmd.ID, models.DispatchFailed, nil, "", nil, "時段內無遙測數據可驗證")
+		return err
+	}
+
+	status, deviation, errMsg := d.assess(cmd, measured)
+	_, err = d.Model.RecordMeasurement(cmd.ID, status, &measured, source, deviation, errMsg)
+	return err
+}
+
+// assess 比對實測響應與設定點，返回狀態、相對偏差（設定點為零時為 nil）及失敗原因
+// 容許偏差為設定點的 Tolerance 倍，至少 minToleranceKW
+func (d *Dispatcher) assess(cmd *models.DispatchCommand, measured float64) (string, *float64, string) {
+	var deviation *float64
+	if cmd.PowerKW != 0 {
+		ratio := (measured - cmd.PowerKW) / math.Abs(cmd.PowerKW)
+		deviation = &ratio
+	}
+
+	allowed := math.Max(d.Tolerance*math.Abs(cmd.PowerKW), minToleranceKW)
+	if math.Abs(measured-cmd.PowerKW) > allowed {
+		return models.DispatchFailed, deviation,
+			fmt.Sprintf("實測響應 %.2f kW 與設定點 %.2f kW 偏差超過容許值 %.2f kW", measured, cmd.PowerKW, allowed)
+	}
+	return models.DispatchExecuted, deviation, ""
+}
+
+// measure 計算指令時段內的實測響應（kW，正值放電或降載），無數據時 source 為空
+func (d *Dispatcher) measure(cmd *models.DispatchCommand) (float64, string, error) {
+	power, samples, err := d.Storage.GetAveragePower(cmd.SiteID, cmd.StartTime, cmd.EndTime)
+	if err != nil {
+		return 0, "", err
+	}
+	if samples > 0 {
+		return power, SourceStorage, nil
+	}
+
+	during, samples, err := d.Load.GetAverageLoad(cmd.SiteID, cmd.StartTime, cmd.EndTime)
+	if err != nil || samples == 0 {
+		return 0, "", err
+	}
+
+	duration := cmd.EndTime.Sub(cmd.StartTime)
+	baseline, samples, err := d.Load.GetAverageLoad(cmd.SiteID, cmd.StartTime.Add(-duration), cmd.StartTime)
+	if err != nil || samples == 0 {
+		return 0, "", err
+	}
+
+	return baseline - during, SourceLoad, nil
+}
@@ -0,0 +1,145 @@
+package dispatch
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/models"
+)
+
+// window 測試用的遙測時段平均值
+type window struct {
+	start, end time.Time
+	avg        float64
+	samples    int
+}
+
+// fakeTelemetry 依查詢時段返回固定平均值的遙測來源
+type fakeTelemetry []window
+
+func (f fakeTelemetry) average(start, end time.Time) (float64, int, error) {
+	for _, w := range f {
+		if w.start.Equal(start) && w.end.Equal(end) {
+			return w.avg, w.samples, nil
+		}
+	}
+	return 0, 0, nil
+}
+
+func (f fakeTelemetry) GetAveragePower(siteID string, start, end time.Time) (float64, int, error) {
+	return f.average(start, end)
+}
+
+func (f fakeTelemetry) GetAverageLoad(siteID string, start, end time.Time) (float64, int, error) {
+	return f.average(start, end)
+}
+
+func TestAssess(t *testing.T) {
+	d := &Dispatcher{Tolerance: 0.2}
+
+	tests := []struct {
+		name      string
+		setpoint  float64
+		measured  float64
+		status    string
+		deviation *float64
+	}{
+		{"偏差在容許內", 100, 85, models.DispatchExecuted, ptr(-0.15)},
+		{"偏差超過容許值", 100, 70, models.DispatchFailed, ptr(-0.3)},
+		{"充電指令", -50, -45, models.DispatchExecuted, ptr(0.1)},
+		{"充電不足", -50, -30, models.DispatchFailed, ptr(0.4)},
+		{"設定點為零時至少容許 1 kW", 0, 0.8, models.DispatchExecuted, nil},
+		{"設定點為零時超過 1 kW", 0, 2, models.DispatchFailed, nil},
+	}
+
+	for _, tt := range tests {
+		status, deviation, errMsg := d.assess(&models.DispatchCommand{PowerKW: tt.setpoint}, tt.measured)
+		if status != tt.status {
+			t.Errorf("%s: 狀態 = %s, 預期 %s", tt.name, status, tt.status)
+		}
+		if (status == models.DispatchFailed) != (errMsg != "") {
+			t.Errorf("%s: 狀態 %s 的失敗原因 %q", tt.name, status, errMsg)
+		}
+		switch {
+		case tt.deviation == nil && deviation != nil:
+			t.Errorf("%s: 偏差 = %g, 預期 nil", tt.name, *deviation)
+		case tt.deviation != nil && (deviation == nil || math.Abs(*deviation-*tt.deviation) > 1e-9):
+			t.Errorf("%s: 偏差 = %v, 預期 %g", tt.name, deviation, *tt.deviation)
+		}
+	}
+}
+
+func TestMeasure(t *testing.T) {
+	start := time.Date(2024, 6, 1, 18, 0, 0, 0, time.UTC)
+	end := start.Add(30 * time.Minute)
+	cmd := &models.DispatchCommand{SiteID: "north", StartTime: start, EndTime: end, PowerKW: 50}
+
+	tests := []struct {
+		name     string
+		storage  fakeTelemetry
+		load     fakeTelemetry
+		measured float64
+		source   string
+	}{
+		{
+			name:     "優先使用儲能淨功率",
+			storage:  fakeTelemetry{{start, end, 48, 6}},
+			load:     fakeTelemetry{{start, end, 80, 6}, {start.Add(-30 * time.Minute), start, 100, 6}},
+			measured: 48, source: SourceStorage,
+		},
+		{
+			name:     "無儲能數據時以負載相對前一個等長時段的降幅計算",
+			load:     fakeTelemetry{{start, end, 80, 6}, {start.Add(-30 * time.Minute), start, 130, 6}},
+			measured: 50, source: SourceLoad,
+		},
+		{
+			name:   "缺少基準時段的負載數據",
+			load:   fakeTelemetry{{start, end, 80, 6}},
+			source: "",
+		},
+		{
+			name:   "時段內無遙測數據",
+			source: "",
+		},
+	}
+
+	for _, tt := range tests {
+		d := &Dispatcher{Storage: tt.storage, Load: tt.load}
+		measured, source, err := d.measure(cmd)
+		if err != nil {
+			t.Errorf("%s: 錯誤 %v", tt.name, err)
+			continue
+		}
+		if source != tt.source || measured != tt.measured {
+			t.Errorf("%s: 實測 %g (%q), 預期 %g (%q)", tt.name, measured, source, tt.measured, tt.source)
+		}
+	}
+}
+
+func TestDispatcherStop(t *testing.T) {
+	d := NewDispatcher(nil, &LogTransport{}, config.DispatchConfig{})
+
+	// 模擬送出中的背景 goroutine，取消後才結束
+	d.wg.Add(1)
+	finished := make(chan struct{})
+	go func() {
+		defer d.wg.Done()
+		<-d.ctx.Done()
+		close(finished)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := d.Stop(ctx); err != nil {
+		t.Fatalf("Stop 錯誤: %v", err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Error("Stop 返回時背景送出尚未結束")
+	}
+}
+
+func ptr(v float64) *float64 { return &v }
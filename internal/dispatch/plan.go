@@ -0,0 +1,143 @@
+package dispatch
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/models"
+)
+
+// maxSetpointKW 單一設定點的功率上限
+const maxSetpointKW = 1e5
+
+// Request 派遣請求
+type Request struct {
+	Reason    string     `json:"reason"`
+	Schedules []Schedule `json:"schedules"`
+}
+
+// Schedule 單一場站的功率設定點排程
+type Schedule struct {
+	SiteID    string     `json:"site_id"`
+	DeviceID  string     `json:"device_id"` // 可選，指定接收指令的閘道器，須登記於該場站
+	Setpoints []Setpoint `json:"setpoints"`
+}
+
+// Setpoint 單一時段的功率設定點
+type Setpoint struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	PowerKW   float64   `json:"power_kw"` // 正值放電，負值充電
+}
+
+// ValidationError 派遣請求驗證錯誤
+type ValidationError struct {
+	Errors []string
+}
+
+// Error 實作 error 介面
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Errors, "; ")
+}
+
+// Plan 驗證派遣請求並展開為指令，同一請求的指令共用一個 dispatch_id
+// deviceSites 為各閘道器登記的場站，指定的閘道器須登記於排程的場站
+func Plan(req *Request, now time.Time, deviceSites map[string][]string) ([]models.DispatchCommand, error) {
+	var errs []string
+	if len(req.Schedules) == 0 {
+		errs = append(errs, "缺少排程")
+	}
+
+	dispatchID := newDispatchID()
+	var commands []models.DispatchCommand
+	seen := make(map[string]int, len(req.Schedules))
+
+	for i, schedule := range req.Schedules {
+		prefix := fmt.Sprintf("schedules[%d]", i)
+		if !config.IsValidSite(schedule.SiteID) {
+			errs = append(errs, fmt.Sprintf("%s.site_id: 無效的場站ID %q", prefix, schedule.SiteID))
+		} else if first, ok := seen[schedule.SiteID]; ok {
+			// 重疊檢查只在同一排程內，同一場站須合併為一個排程
+			errs = append(errs, fmt.Sprintf("%s.site_id: 場站 %q 與 schedules[%d] 重複", prefix, schedule.SiteID, first))
+		} else {
+			seen[schedule.SiteID] = i
+		}
+		if schedule.DeviceID != "" && !containsString(deviceSites[schedule.DeviceID], schedule.SiteID) {
+			errs = append(errs, fmt.Sprintf("%s.device_id: 閘道器 %q 未登記於場站 %q", prefix, schedule.DeviceID, schedule.SiteID))
+		}
+		if len(schedule.Setpoints) == 0 {
+			errs = append(errs, prefix+".setpoints: 缺少設定點")
+		}
+
+		// 依開始時間排序檢查重疊，錯誤訊息保留原始索引
+		order := make([]int, len(schedule.Setpoints))
+		for j := range order {
+			order[j] = j
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return schedule.Setpoints[order[a]].StartTime.Before(schedule.Setpoints[order[b]].StartTime)
+		})
+
+		var prev *Setpoint
+		for _, j := range order {
+			sp := schedule.Setpoints[j]
+			field := fmt.Sprintf("%s.setpoints[%d]", prefix, j)
+			switch {
+			case sp.StartTime.IsZero() || sp.EndTime.IsZero():
+				errs = append(errs, field+": 缺少開始或結束時間")
+				continue
+			case !sp.EndTime.After(sp.StartTime):
+				errs = append(errs, field+": 結束時間須晚於開始時間")
+				continue
+			case !sp.EndTime.After(now):
+				errs = append(errs, field+": 時段已結束")
+				continue
+			case math.IsNaN(sp.PowerKW) || math.Abs(sp.PowerKW) > maxSetpointKW:
+				errs = append(errs, fmt.Sprintf("%s: 功率須介於 ±%g kW", field, maxSetpointKW))
+				continue
+			}
+			if prev != nil && sp.StartTime.Before(prev.EndTime) {
+				errs = append(errs, field+": 與其他時段重疊")
+				continue
+			}
+			prev = &schedule.Setpoints[j]
+
+			commands = append(commands, models.DispatchCommand{
+				DispatchID: dispatchID,
+				SiteID:     schedule.SiteID,
+				DeviceID:   schedule.DeviceID,
+				StartTime:  sp.StartTime,
+				EndTime:    sp.EndTime,
+				PowerKW:    sp.PowerKW,
+				Reason:     req.Reason,
+			})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, &ValidationError{Errors: errs}
+	}
+	return commands, nil
+}
+
+// containsString 檢查列表是否包含字串
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// newDispatchID 產生隨機的派遣ID
+func newDispatchID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return time.Now().Format("20060102") + "-" + hex.EncodeToString(b)
+}
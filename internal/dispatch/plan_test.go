@@ -0,0 +1,104 @@
+package dispatch
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlan(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+
+	req := &Request{
+		Reason: "尖峰削減",
+		Schedules: []Schedule{
+			{SiteID: "north", DeviceID: "rpi-north-01", Setpoints: []Setpoint{
+				{StartTime: at(30), EndTime: at(45), PowerKW: -200},
+				{StartTime: at(15), EndTime: at(30), PowerKW: 500},
+			}},
+			{SiteID: "south", Setpoints: []Setpoint{
+				{StartTime: at(-5), EndTime: at(10), PowerKW: 100}, // 已開始但未結束
+			}},
+		},
+	}
+	devices := map[string][]string{"rpi-north-01": {"north"}}
+
+	commands, err := Plan(req, now, devices)
+	if err != nil {
+		t.Fatalf("Plan 錯誤: %v", err)
+	}
+	if len(commands) != 3 {
+		t.Fatalf("返回 %d 筆指令, 預期 3", len(commands))
+	}
+	for _, cmd := range commands {
+		if cmd.DispatchID != commands[0].DispatchID || cmd.Reason != "尖峰削減" {
+			t.Errorf("指令 %+v 未共用 dispatch_id 或原因", cmd)
+		}
+	}
+	// 同一排程依開始時間展開
+	if commands[0].PowerKW != 500 || commands[1].PowerKW != -200 || commands[0].DeviceID != "rpi-north-01" {
+		t.Errorf("north 指令 = %+v, %+v", commands[0], commands[1])
+	}
+	if commands[2].SiteID != "south" || commands[2].DeviceID != "" {
+		t.Errorf("south 指令 = %+v", commands[2])
+	}
+}
+
+func TestPlanValidation(t *testing.T) {
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return now.Add(time.Duration(minutes) * time.Minute) }
+	valid := []Setpoint{{StartTime: at(15), EndTime: at(30), PowerKW: 100}}
+	devices := map[string][]string{"rpi-north-01": {"north"}, "rpi-south-01": {"south"}}
+
+	tests := []struct {
+		name      string
+		schedules []Schedule
+		want      string
+	}{
+		{"缺少排程", nil, "缺少排程"},
+		{"無效場站", []Schedule{{SiteID: "mars", Setpoints: valid}}, `schedules[0].site_id: 無效的場站ID "mars"`},
+		{"場站重複", []Schedule{{SiteID: "north", Setpoints: valid}, {SiteID: "north", Setpoints: []Setpoint{{StartTime: at(60), EndTime: at(75)}}}}, `schedules[1].site_id: 場站 "north" 與 schedules[0] 重複`},
+		{"缺少設定點", []Schedule{{SiteID: "north"}}, "schedules[0].setpoints: 缺少設定點"},
+		{"缺少時間", []Schedule{{SiteID: "north", Setpoints: []Setpoint{{EndTime: at(30)}}}}, "setpoints[0]: 缺少開始或結束時間"},
+		{"結束早於開始", []Schedule{{SiteID: "north", Setpoints: []Setpoint{{StartTime: at(30), EndTime: at(15)}}}}, "setpoints[0]: 結束時間須晚於開始時間"},
+		{"時段已結束", []Schedule{{SiteID: "north", Setpoints: []Setpoint{{StartTime: at(-30), EndTime: at(0)}}}}, "setpoints[0]: 時段已結束"},
+		{"功率超過上限", []Schedule{{SiteID: "north", Setpoints: []Setpoint{{StartTime: at(15), EndTime: at(30), PowerKW: -maxSetpointKW - 1}}}}, "setpoints[0]: 功率須介於"},
+		{"功率非數值", []Schedule{{SiteID: "north", Setpoints: []Setpoint{{StartTime: at(15), EndTime: at(30), PowerKW: math.NaN()}}}}, "setpoints[0]: 功率須介於"},
+		{"時段重疊", []Schedule{{SiteID: "north", Setpoints: []Setpoint{
+			{StartTime: at(30), EndTime: at(60)},
+			{StartTime: at(15), EndTime: at(45)},
+		}}}, "setpoints[0]: 與其他時段重疊"},
+		{"閘道器未登記", []Schedule{{SiteID: "north", DeviceID: "rpi-unknown", Setpoints: valid}}, `閘道器 "rpi-unknown" 未登記於場站 "north"`},
+		{"閘道器屬於其他場站", []Schedule{{SiteID: "north", DeviceID: "rpi-south-01", Setpoints: valid}}, `閘道器 "rpi-south-01" 未登記於場站 "north"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commands, err := Plan(&Request{Schedules: tt.schedules}, now, devices)
+			if err == nil {
+				t.Fatalf("預期驗證錯誤, 返回 %d 筆指令", len(commands))
+			}
+			verr, ok := err.(*ValidationError)
+			if !ok {
+				t.Fatalf("錯誤型別 %T, 預期 *ValidationError", err)
+			}
+			if !strings.Contains(verr.Error(), tt.want) {
+				t.Errorf("錯誤 %q 未包含 %q", verr.Error(), tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanAdjacentSetpoints(t *testing.T) {
+	// 前一時段結束即下一時段開始時不算重疊
+	now := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	req := &Request{Schedules: []Schedule{{SiteID: "north", Setpoints: []Setpoint{
+		{StartTime: now.Add(15 * time.Minute), EndTime: now.Add(30 * time.Minute), PowerKW: maxSetpointKW},
+		{StartTime: now.Add(30 * time.Minute), EndTime: now.Add(45 * time.Minute), PowerKW: -maxSetpointKW},
+	}}}}
+	if commands, err := Plan(req, now, nil); err != nil || len(commands) != 2 {
+		t.Errorf("Plan = %d 筆, %v, 預期 2 筆", len(commands), err)
+	}
+}
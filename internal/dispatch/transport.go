@@ -0,0 +1,148 @@
+package dispatch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/httpclient"
+	"vpp-go/internal/models"
+)
+
+// 指令傳送方式
+const (
+	TransportHTTP = "http"
+	TransportLog  = "log"
+	TransportPoll = "poll"
+)
+
+// Transport 派遣指令傳送方式
+type Transport interface {
+	// Name 傳送方式名稱
+	Name() string
+	// Send 送出指令，返回 true 表示閘道器已同步確認接收
+	Send(ctx context.Context, cmd *models.DispatchCommand) (bool, error)
+}
+
+// NewTransport 依類型創建指令傳送方式
+func NewTransport(kind string, sites []config.SiteConfig, client *httpclient.Client) (Transport, error) {
+	switch strings.ToLower(kind) {
+	case TransportHTTP:
+		urls := make(map[string]string, len(sites))
+		for _, site := range sites {
+			if site.DispatchURL != "" {
+				urls[site.ID] = site.DispatchURL
+			}
+		}
+		return &HTTPTransport{URLs: urls, HTTP: client}, nil
+	case TransportLog, "":
+		return &LogTransport{}, nil
+	case TransportPoll:
+		return &PollTransport{}, nil
+	default:
+		return nil, fmt.Errorf("不支援的指令傳送方式: %s", kind)
+	}
+}
+
+// CommandPayload 送往閘道器的指令內容
+type CommandPayload struct {
+	CommandID  int64     `json:"command_id"`
+	DispatchID string    `json:"dispatch_id"`
+	SiteID     string    `json:"site_id"`
+	DeviceID   string    `json:"device_id,omitempty"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time"`
+	PowerKW    float64   `json:"power_kw"` // 正值放電，負值充電
+}
+
+// NewCommandPayload 由派遣指令建立傳送內容
+func NewCommandPayload(cmd *models.DispatchCommand) CommandPayload {
+	return CommandPayload{
+		CommandID:  cmd.ID,
+		DispatchID: cmd.DispatchID,
+		SiteID:     cmd.SiteID,
+		DeviceID:   cmd.DeviceID,
+		StartTime:  cmd.StartTime,
+		EndTime:    cmd.EndTime,
+		PowerKW:    cmd.PowerKW,
+	}
+}
+
+// HTTPTransport 以 HTTP POST 將指令送到場站閘道器，2xx 視為已確認接收
+type HTTPTransport struct {
+	URLs map[string]string // 場站ID -> 閘道器URL
+	HTTP *httpclient.Client
+}
+
+// Name 傳送方式名稱
+func (t *HTTPTransport) Name() string {
+	return TransportHTTP
+}
+
+// Send 送出指令，以指令ID作為冪等鍵，重試不會重複執行
+func (t *HTTPTransport) Send(ctx context.Context, cmd *models.DispatchCommand) (bool, error) {
+	url, ok := t.URLs[cmd.SiteID]
+	if !ok {
+		return false, fmt.Errorf("場站 %s 未配置派遣URL", cmd.SiteID)
+	}
+
+	body, err := json.Marshal(NewCommandPayload(cmd))
+	if err != nil {
+		return false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("建立請求失敗: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", "dispatch-"+strconv.FormatInt(cmd.ID, 10))
+
+	resp, err := t.HTTP.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("請求失敗: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, fmt.Errorf("閘道器返回錯誤狀態碼: %d", resp.StatusCode)
+	}
+	return true, nil
+}
+
+// LogTransport 只記錄日誌，不實際送出（開發與測試用）
+type LogTransport struct{}
+
+// Name 傳送方式名稱
+func (t *LogTransport) Name() string {
+	return TransportLog
+}
+
+// Send 記錄指令內容
+func (t *LogTransport) Send(ctx context.Context, cmd *models.DispatchCommand) (bool, error) {
+	log.Printf("派遣指令 #%d - 場站: %s, 時段: %s ~ %s, 功率: %.2f kW\n",
+		cmd.ID, cmd.SiteID, cmd.StartTime.Format(time.RFC3339), cmd.EndTime.Format(time.RFC3339), cmd.PowerKW)
+	return false, nil
+}
+
+// PollTransport 由閘道器以長輪詢主動領取指令，適用於位於 NAT 後無法接受連入的閘道器
+type PollTransport struct{}
+
+// Name 傳送方式名稱
+func (t *PollTransport) Name() string {
+	return TransportPoll
+}
+
+// Send 輪詢方式不主動送出，指令於閘道器領取時標記為已送出
+func (t *PollTransport) Send(ctx context.Context, cmd *models.DispatchCommand) (bool, error) {
+	return false, errors.New("輪詢傳送的指令由閘道器主動領取")
+}
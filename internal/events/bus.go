@@ -0,0 +1,266 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Policy 訂閱者緩衝已滿時的處理方式
+type Policy int
+
+const (
+	// DropNewest 丟棄新事件，發佈端不等待
+	DropNewest Policy = iota
+	// DropOldest 丟棄緩衝中最舊的事件以放入新事件，發佈端不等待
+	DropOldest
+	// Block 發佈端等待訂閱者消化，超過 BlockTimeout 仍無空位時丟棄新事件
+	Block
+)
+
+// String 返回處理方式名稱
+func (p Policy) String() string {
+	switch p {
+	case DropNewest:
+		return "drop_newest"
+	case DropOldest:
+		return "drop_oldest"
+	case Block:
+		return "block"
+	default:
+		return fmt.Sprintf("policy(%d)", int(p))
+	}
+}
+
+// ErrClosed 匯流排已關閉
+var ErrClosed = errors.New("事件匯流排已關閉")
+
+// 訂閱預設值
+const (
+	defaultBuffer       = 256
+	defaultBlockTimeout = 100 * time.Millisecond
+)
+
+// SubscribeOptions 訂閱選項
+type SubscribeOptions struct {
+	Kinds        []Kind // 空表示訂閱所有種類
+	Buffer       int    // 待處理事件上限，預設 256
+	Policy       Policy
+	BlockTimeout time.Duration // Block 的最長等待時間，預設 100ms
+}
+
+// SubscriberStats 訂閱者的處理統計
+type SubscriberStats struct {
+	Name      string `json:"name"`
+	Kinds     []Kind `json:"kinds"`
+	Policy    string `json:"policy"`
+	Buffer    int    `json:"buffer"`
+	Queued    int    `json:"queued"`
+	Delivered uint64 `json:"delivered"`
+	Dropped   uint64 `json:"dropped"`
+}
+
+// subscriber 已註冊的訂閱者，每個訂閱者由獨立的 goroutine 依序處理事件
+type subscriber struct {
+	name      string
+	kinds     map[Kind]bool
+	opts      SubscribeOptions
+	handler   func(Event)
+	ch        chan Event
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+}
+
+// accepts 檢查訂閱者是否訂閱該事件種類
+func (s *subscriber) accepts(kind Kind) bool {
+	return len(s.kinds) == 0 || s.kinds[kind]
+}
+
+// Bus 程序內的事件匯流排，發佈端不會因訂閱者緩慢而無限期等待
+type Bus struct {
+	mu         sync.RWMutex
+	subs       []*subscriber
+	closed     bool
+	done       chan struct{} // 關閉後訂閱者全部結束時關閉
+	publishing sync.WaitGroup
+	wg         sync.WaitGroup
+}
+
+// NewBus 創建事件匯流排
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe 註冊訂閱者，handler 在該訂閱者專屬的 goroutine 中依發佈順序執行
+// 匯流排已關閉時返回 ErrClosed
+func (b *Bus) Subscribe(name string, opts SubscribeOptions, handler func(Event)) error {
+	if opts.Buffer <= 0 {
+		opts.Buffer = defaultBuffer
+	}
+	if opts.BlockTimeout <= 0 {
+		opts.BlockTimeout = defaultBlockTimeout
+	}
+
+	sub := &subscriber{
+		name:    name,
+		kinds:   make(map[Kind]bool),
+		opts:    opts,
+		handler: handler,
+		ch:      make(chan Event, opts.Buffer),
+	}
+	for _, kind := range opts.Kinds {
+		sub.kinds[kind] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return fmt.Errorf("訂閱者 %s: %w", name, ErrClosed)
+	}
+	b.subs = append(b.subs, sub)
+
+	b.wg.Add(1)
+	go b.run(sub)
+	return nil
+}
+
+// run 依序處理訂閱者的事件，直到匯流排關閉且緩衝清空
+func (b *Bus) run(sub *subscriber) {
+	defer b.wg.Done()
+	for e := range sub.ch {
+		b.deliver(sub, e)
+	}
+}
+
+// deliver 執行訂閱者的處理函數，panic 只影響該筆事件
+func (b *Bus) deliver(sub *subscriber, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("事件訂閱者 %s 處理 %s 失敗: %v\n", sub.name, e.Kind(), r)
+		}
+	}()
+	sub.handler(e)
+	sub.delivered.Add(1)
+}
+
+// Publish 發佈事件給所有訂閱該種類的訂閱者，Bus 為 nil 時不做任何事
+// 放入事件時不持有鎖，Block 訂閱者的等待不會擋住其他發佈端、Subscribe 及 Stats
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return
+	}
+	subs := make([]*subscriber, 0, len(b.subs))
+	for _, sub := range b.subs {
+		if sub.accepts(e.Kind()) {
+			subs = append(subs, sub)
+		}
+	}
+	// Close 等待進行中的發佈結束後才關閉訂閱者的 channel
+	b.publishing.Add(1)
+	b.mu.RUnlock()
+	defer b.publishing.Done()
+
+	for _, sub := range subs {
+		b.enqueue(sub, e)
+	}
+}
+
+// enqueue 依訂閱者的處理方式放入事件
+func (b *Bus) enqueue(sub *subscriber, e Event) {
+	select {
+	case sub.ch <- e:
+		return
+	default:
+	}
+
+	switch sub.opts.Policy {
+	case DropOldest:
+		// 與訂閱者的 goroutine 競爭時可能已有空位，最多重試一次
+		select {
+		case <-sub.ch:
+			recordDrop(sub)
+		default:
+		}
+		select {
+		case sub.ch <- e:
+			return
+		default:
+		}
+	case Block:
+		timer := time.NewTimer(sub.opts.BlockTimeout)
+		defer timer.Stop()
+		select {
+		case sub.ch <- e:
+			return
+		case <-timer.C:
+		}
+	}
+
+	recordDrop(sub)
+}
+
+// recordDrop 累計丟棄數，數量為 2 的次方時記錄日誌，避免持續壅塞時洗版
+func recordDrop(sub *subscriber) {
+	if n := sub.dropped.Add(1); n&(n-1) == 0 {
+		log.Printf("事件訂閱者 %s 緩衝已滿，已丟棄 %d 筆事件\n", sub.name, n)
+	}
+}
+
+// Stats 返回各訂閱者的處理統計
+func (b *Bus) Stats() []SubscriberStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := make([]SubscriberStats, 0, len(b.subs))
+	for _, sub := range b.subs {
+		kinds := append([]Kind{}, sub.opts.Kinds...)
+		stats = append(stats, SubscriberStats{
+			Name:      sub.name,
+			Kinds:     kinds,
+			Policy:    sub.opts.Policy.String(),
+			Buffer:    sub.opts.Buffer,
+			Queued:    len(sub.ch),
+			Delivered: sub.delivered.Load(),
+			Dropped:   sub.dropped.Load(),
+		})
+	}
+	return stats
+}
+
+// Close 停止接受新事件，等待訂閱者處理完緩衝中的事件或 ctx 逾時
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		b.done = make(chan struct{})
+		subs := b.subs
+		go func() {
+			// 進行中的發佈最多等待 BlockTimeout
+			b.publishing.Wait()
+			for _, sub := range subs {
+				close(sub.ch)
+			}
+			b.wg.Wait()
+			close(b.done)
+		}()
+	}
+	done := b.done
+	b.mu.Unlock()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待事件訂閱者結束逾時: %w", ctx.Err())
+	}
+}
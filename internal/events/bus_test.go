@@ -0,0 +1,281 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// sample 以 SiteID 標示順序的測試事件
+func sample(n int) Event {
+	return SampleStored{Metric: MetricSolar, SiteID: fmt.Sprint(n)}
+}
+
+// gatedSubscriber 第一筆事件進入處理後停住，直到 release 才繼續，用於預先填滿緩衝
+type gatedSubscriber struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+	mu      sync.Mutex
+	got     []string
+}
+
+func newGatedSubscriber() *gatedSubscriber {
+	return &gatedSubscriber{started: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (g *gatedSubscriber) handle(e Event) {
+	g.once.Do(func() {
+		close(g.started)
+		<-g.release
+	})
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.got = append(g.got, e.(SampleStored).SiteID)
+}
+
+func (g *gatedSubscriber) received() []string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]string{}, g.got...)
+}
+
+// fill 發佈第一筆事件並等待訂閱者停住，再發佈 2..n
+func (g *gatedSubscriber) fill(t *testing.T, b *Bus, n int) {
+	t.Helper()
+	b.Publish(sample(1))
+	select {
+	case <-g.started:
+	case <-time.After(time.Second):
+		t.Fatal("訂閱者未開始處理事件")
+	}
+	for i := 2; i <= n; i++ {
+		b.Publish(sample(i))
+	}
+}
+
+func closeBus(t *testing.T, b *Bus) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := b.Close(ctx); err != nil {
+		t.Fatalf("Close 錯誤: %v", err)
+	}
+}
+
+func TestBusPolicies(t *testing.T) {
+	tests := []struct {
+		policy    Policy
+		want      []string
+		delivered uint64
+		dropped   uint64
+	}{
+		// 緩衝 2 筆，處理中 1 筆，第 4、5 筆無空位
+		{DropNewest, []string{"1", "2", "3"}, 3, 2},
+		{DropOldest, []string{"1", "4", "5"}, 3, 2},
+		{Block, []string{"1", "2", "3"}, 3, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			b := NewBus()
+			g := newGatedSubscriber()
+			if err := b.Subscribe("test", SubscribeOptions{Buffer: 2, Policy: tt.policy, BlockTimeout: 10 * time.Millisecond}, g.handle); err != nil {
+				t.Fatal(err)
+			}
+			g.fill(t, b, 5)
+
+			stats := b.Stats()
+			if len(stats) != 1 || stats[0].Queued != 2 || stats[0].Dropped != tt.dropped {
+				t.Errorf("處理前統計 = %+v, 預期待處理 2、丟棄 %d", stats, tt.dropped)
+			}
+
+			close(g.release)
+			closeBus(t, b)
+			if got := g.received(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("收到 %v, 預期 %v", got, tt.want)
+			}
+			stats = b.Stats()
+			want := SubscriberStats{Name: "test", Kinds: []Kind{}, Policy: tt.policy.String(), Buffer: 2, Delivered: tt.delivered, Dropped: tt.dropped}
+			if !reflect.DeepEqual(stats[0], want) {
+				t.Errorf("統計 = %+v, 預期 %+v", stats[0], want)
+			}
+		})
+	}
+}
+
+func TestBusBlockTimeout(t *testing.T) {
+	b := NewBus()
+	g := newGatedSubscriber()
+	b.Subscribe("test", SubscribeOptions{Buffer: 1, Policy: Block, BlockTimeout: 50 * time.Millisecond}, g.handle)
+	g.fill(t, b, 2)
+
+	// 無空位時等待 BlockTimeout 後丟棄
+	start := time.Now()
+	b.Publish(sample(3))
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("發佈等待 %v, 預期至少 50ms", elapsed)
+	}
+	if dropped := b.Stats()[0].Dropped; dropped != 1 {
+		t.Errorf("丟棄 %d 筆, 預期 1", dropped)
+	}
+
+	// 等待期間出現空位時放入事件
+	time.AfterFunc(10*time.Millisecond, func() { close(g.release) })
+	b.Publish(sample(4))
+	closeBus(t, b)
+	if got, want := g.received(), []string{"1", "2", "4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("收到 %v, 預期 %v", got, want)
+	}
+}
+
+func TestBusBlockDoesNotHoldLock(t *testing.T) {
+	b := NewBus()
+	g := newGatedSubscriber()
+	b.Subscribe("slow", SubscribeOptions{Buffer: 1, Policy: Block, BlockTimeout: time.Second}, g.handle)
+	g.fill(t, b, 2)
+
+	// 發佈端等待 Block 訂閱者時，Subscribe 及 Stats 不受影響
+	published := make(chan struct{})
+	go func() {
+		b.Publish(sample(3))
+		close(published)
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	done := make(chan error)
+	go func() {
+		err := b.Subscribe("other", SubscribeOptions{}, func(Event) {})
+		b.Stats()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Subscribe 錯誤: %v", err)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Error("Subscribe 等待發佈端釋放鎖")
+	}
+
+	close(g.release)
+	<-published
+	closeBus(t, b)
+}
+
+func TestBusKinds(t *testing.T) {
+	b := NewBus()
+	var mu sync.Mutex
+	var got []Kind
+	b.Subscribe("jobs", SubscribeOptions{Kinds: []Kind{KindJobFinished}}, func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, e.Kind())
+	})
+
+	b.Publish(sample(1))
+	b.Publish(JobFinished{Job: "solar"})
+	closeBus(t, b)
+	if want := []Kind{KindJobFinished}; !reflect.DeepEqual(got, want) {
+		t.Errorf("收到 %v, 預期 %v", got, want)
+	}
+}
+
+func TestBusHandlerPanic(t *testing.T) {
+	b := NewBus()
+	var got []string
+	b.Subscribe("test", SubscribeOptions{}, func(e Event) {
+		id := e.(SampleStored).SiteID
+		if id == "1" {
+			panic("boom")
+		}
+		got = append(got, id)
+	})
+
+	b.Publish(sample(1))
+	b.Publish(sample(2))
+	closeBus(t, b)
+	if want := []string{"2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("收到 %v, 預期 %v", got, want)
+	}
+	if delivered := b.Stats()[0].Delivered; delivered != 1 {
+		t.Errorf("完成 %d 筆, 預期 1", delivered)
+	}
+}
+
+func TestBusCloseDrains(t *testing.T) {
+	b := NewBus()
+	var mu sync.Mutex
+	var got int
+	b.Subscribe("test", SubscribeOptions{Buffer: 10}, func(Event) {
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		got++
+	})
+	for i := 1; i <= 10; i++ {
+		b.Publish(sample(i))
+	}
+
+	// Close 等待緩衝中的事件處理完畢
+	closeBus(t, b)
+	if got != 10 {
+		t.Errorf("處理 %d 筆, 預期 10", got)
+	}
+
+	// 關閉後發佈不做任何事，訂閱返回 ErrClosed
+	b.Publish(sample(11))
+	if err := b.Subscribe("late", SubscribeOptions{}, func(Event) {}); !errors.Is(err, ErrClosed) {
+		t.Errorf("關閉後訂閱錯誤 = %v, 預期 ErrClosed", err)
+	}
+	if len(b.Stats()) != 1 {
+		t.Errorf("關閉後仍加入訂閱者")
+	}
+}
+
+func TestBusCloseTimeout(t *testing.T) {
+	b := NewBus()
+	g := newGatedSubscriber()
+	b.Subscribe("test", SubscribeOptions{}, g.handle)
+	g.fill(t, b, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close 錯誤 = %v, 預期逾時", err)
+	}
+
+	// 再次 Close 等待同一批訂閱者結束
+	close(g.release)
+	closeBus(t, b)
+}
+
+func TestBusConcurrentPublishClose(t *testing.T) {
+	b := NewBus()
+	b.Subscribe("block", SubscribeOptions{Buffer: 1, Policy: Block, BlockTimeout: time.Millisecond}, func(Event) {})
+	b.Subscribe("oldest", SubscribeOptions{Buffer: 1, Policy: DropOldest}, func(Event) {})
+
+	// 發佈與關閉同時進行時不可寫入已關閉的 channel
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; n < 200; n++ {
+				b.Publish(sample(n))
+			}
+		}()
+	}
+	time.Sleep(time.Millisecond)
+	closeBus(t, b)
+	wg.Wait()
+}
+
+func TestNilBusPublish(t *testing.T) {
+	var b *Bus
+	b.Publish(sample(1))
+}
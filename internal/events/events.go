@@ -0,0 +1,82 @@
+package events
+
+import (
+	"time"
+	"vpp-go/internal/models"
+)
+
+// Kind 事件種類
+type Kind string
+
+// 事件種類
+const (
+	KindSampleStored     Kind = "sample.stored"      // 新的太陽能、負載或儲能數據已寫入
+	KindReserveDayStored Kind = "reserve_day.stored" // 一日的台電備轉資料已寫入
+	KindCollectionFailed Kind = "collection.failed"  // 收集器執行失敗
+	KindJobFinished      Kind = "job.finished"       // 排程任務執行結束
+)
+
+// 數據種類
+const (
+	MetricSolar   = "solar"
+	MetricLoad    = "load"
+	MetricStorage = "storage"
+)
+
+// 事件來源
+const (
+	SourceCollector = "collector"
+	SourceUpload    = "upload"
+	SourceBackfill  = "backfill"
+)
+
+// Event 匯流排上傳遞的事件
+type Event interface {
+	Kind() Kind
+}
+
+// SampleStored 新的太陽能、負載或儲能數據已寫入
+type SampleStored struct {
+	Metric string
+	SiteID string
+	Time   time.Time
+	Source string
+	// Data 為 *models.SolarData、*models.LoadData 或 *models.StorageData，訂閱者不可修改
+	Data interface{}
+}
+
+// Kind 實作 Event
+func (SampleStored) Kind() Kind { return KindSampleStored }
+
+// ReserveDayStored 一日的台電備轉資料已寫入
+type ReserveDayStored struct {
+	Date   time.Time
+	Source string
+	// Data 為該日已寫入的各時段資料，訂閱者不可修改
+	Data []models.TaipowerReserveData
+}
+
+// Kind 實作 Event
+func (ReserveDayStored) Kind() Kind { return KindReserveDayStored }
+
+// CollectionFailed 收集器執行失敗
+type CollectionFailed struct {
+	Collector string // 排程任務名稱
+	SiteID    string // 非場站收集器時為空
+	Time      time.Time
+	Err       error
+}
+
+// Kind 實作 Event
+func (CollectionFailed) Kind() Kind { return KindCollectionFailed }
+
+// JobFinished 排程任務執行結束（含手動觸發）
+type JobFinished struct {
+	Job      string // 排程任務名稱
+	Start    time.Time
+	Duration time.Duration
+	Err      error // 成功時為 nil
+}
+
+// Kind 實作 Event
+func (JobFinished) Kind() Kind { return KindJobFinished }
@@ -0,0 +1,51 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// csvEncoder CSV 格式，時間以 RFC 3339 表示
+type csvEncoder struct {
+	w      *csv.Writer
+	record []string
+}
+
+// newCSVEncoder 創建 CSV 寫入並寫入標題列
+func newCSVEncoder(w io.Writer, columns []column) (*csvEncoder, error) {
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Name
+	}
+
+	e := &csvEncoder{w: csv.NewWriter(w), record: make([]string, len(columns))}
+	if err := e.w.Write(header); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// writeRow 寫入一列，csv.Writer 緩衝滿時自動寫出
+func (e *csvEncoder) writeRow(values []interface{}) error {
+	for i, v := range values {
+		switch v := v.(type) {
+		case string:
+			e.record[i] = v
+		case int64:
+			e.record[i] = strconv.FormatInt(v, 10)
+		case float64:
+			e.record[i] = strconv.FormatFloat(v, 'f', -1, 64)
+		case time.Time:
+			e.record[i] = v.Format(time.RFC3339)
+		}
+	}
+	return e.w.Write(e.record)
+}
+
+// close 寫出緩衝內容
+func (e *csvEncoder) close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
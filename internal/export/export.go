@@ -0,0 +1,197 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 匯出格式
+const (
+	FormatCSV     = "csv"
+	FormatXLSX    = "xlsx"
+	FormatParquet = "parquet"
+)
+
+// contentTypes 匯出格式對應的 MIME 類型
+var contentTypes = map[string]string{
+	FormatCSV:     "text/csv; charset=utf-8",
+	FormatXLSX:    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FormatParquet: "application/vnd.apache.parquet",
+}
+
+// acceptTypes Accept 標頭可接受的 MIME 類型
+var acceptTypes = map[string]string{
+	"text/csv": FormatCSV,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": FormatXLSX,
+	"application/vnd.apache.parquet":                                    FormatParquet,
+	"application/x-parquet":                                             FormatParquet,
+}
+
+// Negotiate 依 format 參數或 Accept 標頭決定匯出格式，format 優先；要求 JSON 時返回空字串
+func Negotiate(format, accept string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(format)
+		if format == "json" {
+			return "", nil
+		}
+		if _, ok := contentTypes[format]; !ok {
+			return "", fmt.Errorf("不支援的匯出格式: %s（csv、xlsx、parquet）", format)
+		}
+		return format, nil
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if f, ok := acceptTypes[strings.ToLower(mediaType)]; ok {
+			return f, nil
+		}
+	}
+	return "", nil
+}
+
+// ContentType 匯出格式的 MIME 類型
+func ContentType(format string) string {
+	return contentTypes[format]
+}
+
+// 欄位類型
+const (
+	kindString = iota
+	kindInt
+	kindFloat
+	kindTime
+)
+
+// column 匯出欄位，名稱取自 JSON 標籤
+type column struct {
+	Name  string
+	Kind  int
+	index int
+}
+
+// encoder 各格式的逐列寫入
+type encoder interface {
+	writeRow(values []interface{}) error
+	close() error
+}
+
+// Writer 將結構體逐筆寫成指定格式，欄位依結構體的 JSON 標籤命名
+type Writer struct {
+	columns  []column
+	typ      reflect.Type
+	location *time.Location
+	enc      encoder
+	values   []interface{}
+}
+
+// NewWriter 創建匯出寫入器，sample 為要匯出的結構體（值或指標）
+// location 不為 nil 時時間欄位轉換為該時區
+func NewWriter(w io.Writer, format string, sample interface{}, location *time.Location) (*Writer, error) {
+	typ := reflect.TypeOf(sample)
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	columns, err := columnsOf(typ)
+	if err != nil {
+		return nil, err
+	}
+
+	var enc encoder
+	switch format {
+	case FormatCSV:
+		enc, err = newCSVEncoder(w, columns)
+	case FormatXLSX:
+		enc, err = newXLSXEncoder(w, columns)
+	case FormatParquet:
+		enc, err = newParquetEncoder(w, columns)
+	default:
+		err = fmt.Errorf("不支援的匯出格式: %s", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		columns:  columns,
+		typ:      typ,
+		location: location,
+		enc:      enc,
+		values:   make([]interface{}, len(columns)),
+	}, nil
+}
+
+// Write 寫入一筆資料，型別須與創建時的 sample 相同
+func (w *Writer) Write(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		rv = rv.Elem()
+	}
+	if rv.Type() != w.typ {
+		return fmt.Errorf("匯出資料型別不符: %s", rv.Type())
+	}
+
+	for i, col := range w.columns {
+		field := rv.Field(col.index)
+		switch col.Kind {
+		case kindString:
+			w.values[i] = field.String()
+		case kindInt:
+			w.values[i] = field.Int()
+		case kindFloat:
+			w.values[i] = field.Float()
+		case kindTime:
+			t := field.Interface().(time.Time)
+			if w.location != nil {
+				t = t.In(w.location)
+			}
+			w.values[i] = t
+		}
+	}
+	return w.enc.writeRow(w.values)
+}
+
+// Close 寫入檔案結尾，須在所有資料寫入後呼叫
+func (w *Writer) Close() error {
+	return w.enc.close()
+}
+
+// timeType time.Time 的反射型別
+var timeType = reflect.TypeOf(time.Time{})
+
+// columnsOf 依結構體的 JSON 標籤建立欄位，略過標籤為 - 的欄位
+func columnsOf(typ reflect.Type) ([]column, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("只能匯出結構體: %s", typ)
+	}
+
+	var columns []column
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		col := column{Name: name, index: i}
+		switch {
+		case field.Type == timeType:
+			col.Kind = kindTime
+		case field.Type.Kind() == reflect.String:
+			col.Kind = kindString
+		case field.Type.Kind() >= reflect.Int && field.Type.Kind() <= reflect.Int64:
+			col.Kind = kindInt
+		case field.Type.Kind() == reflect.Float32 || field.Type.Kind() == reflect.Float64:
+			col.Kind = kindFloat
+		default:
+			return nil, fmt.Errorf("欄位 %s 的型別 %s 不支援匯出", name, field.Type)
+		}
+		columns = append(columns, col)
+	}
+	return columns, nil
+}
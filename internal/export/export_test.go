@@ -0,0 +1,463 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// record 測試用的匯出結構體
+type record struct {
+	ID      int64     `json:"id"`
+	SiteID  string    `json:"site_id"`
+	Power   float64   `json:"power"`
+	Time    time.Time `json:"time"`
+	Count   int       `json:"count"`
+	Ignored string    `json:"-"`
+	hidden  string
+}
+
+var testLocation = time.FixedZone("Asia/Taipei", 8*3600)
+
+// testRecords 產生 n 筆資料，含需要跳脫的字串與負值
+func testRecords(n int) []record {
+	base := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	records := make([]record, n)
+	for i := range records {
+		records[i] = record{
+			ID:      int64(i + 1),
+			SiteID:  []string{"north", `南部 "A" <&>`, "east,1"}[i%3],
+			Power:   float64(i)*1.25 - 3.5,
+			Time:    base.Add(time.Duration(i) * 15 * time.Minute),
+			Count:   -i,
+			Ignored: "x",
+			hidden:  "y",
+		}
+	}
+	return records
+}
+
+// encode 以指定格式寫出資料
+func encode(t *testing.T, format string, records []record, location *time.Location) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, format, &record{}, location)
+	if err != nil {
+		t.Fatalf("創建寫入器失敗: %v", err)
+	}
+	for i := range records {
+		if err := w.Write(&records[i]); err != nil {
+			t.Fatalf("寫入第 %d 筆失敗: %v", i, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("關閉失敗: %v", err)
+	}
+	return buf.Bytes()
+}
+
+var wantHeader = []string{"id", "site_id", "power", "time", "count"}
+
+func TestCSVRoundTrip(t *testing.T) {
+	records := testRecords(10)
+	rows, err := csv.NewReader(bytes.NewReader(encode(t, FormatCSV, records, testLocation))).ReadAll()
+	if err != nil {
+		t.Fatalf("讀取 CSV 失敗: %v", err)
+	}
+	if len(rows) != len(records)+1 {
+		t.Fatalf("列數 = %d, 預期 %d", len(rows), len(records)+1)
+	}
+	if strings.Join(rows[0], ",") != strings.Join(wantHeader, ",") {
+		t.Errorf("標題列 = %v, 預期 %v", rows[0], wantHeader)
+	}
+
+	for i, r := range records {
+		row := rows[i+1]
+		tm, _ := time.Parse(time.RFC3339, row[3])
+		power, _ := strconv.ParseFloat(row[2], 64)
+		if row[0] != strconv.FormatInt(r.ID, 10) || row[1] != r.SiteID || power != r.Power ||
+			!tm.Equal(r.Time) || row[4] != strconv.Itoa(r.Count) {
+			t.Errorf("第 %d 列 = %v, 預期 %+v", i+1, row, r)
+		}
+		// 時間依指定時區輸出
+		if !strings.HasSuffix(row[3], "+08:00") {
+			t.Errorf("時間 %s 應為 +08:00", row[3])
+		}
+	}
+}
+
+func TestXLSXRoundTrip(t *testing.T) {
+	records := testRecords(30)
+	data := encode(t, FormatXLSX, records, testLocation)
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("讀取 zip 失敗: %v", err)
+	}
+	parts := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("開啟 %s 失敗: %v", f.Name, err)
+		}
+		parts[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("缺少 %s", name)
+		}
+	}
+	for name, content := range parts {
+		d := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			_, err := d.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Errorf("%s 不是有效的 XML: %v", name, err)
+				break
+			}
+		}
+	}
+
+	var sheet struct {
+		Rows []struct {
+			R     int `xml:"r,attr"`
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Style  string `xml:"s,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := xml.Unmarshal(parts["xl/worksheets/sheet1.xml"], &sheet); err != nil {
+		t.Fatalf("解析工作表失敗: %v", err)
+	}
+	if len(sheet.Rows) != len(records)+1 {
+		t.Fatalf("列數 = %d, 預期 %d", len(sheet.Rows), len(records)+1)
+	}
+
+	for i, cell := range sheet.Rows[0].Cells {
+		if cell.Inline != wantHeader[i] || cell.Ref != columnName(i)+"1" {
+			t.Errorf("標題 %s = %q, 預期 %q", cell.Ref, cell.Inline, wantHeader[i])
+		}
+	}
+	for i, r := range records {
+		row := sheet.Rows[i+1]
+		if row.R != i+2 || len(row.Cells) != len(wantHeader) {
+			t.Fatalf("第 %d 列 = %+v", i+2, row)
+		}
+		c := row.Cells
+		power, _ := strconv.ParseFloat(c[2].Value, 64)
+		serial, _ := strconv.ParseFloat(c[3].Value, 64)
+		if c[0].Value != strconv.FormatInt(r.ID, 10) || c[1].Type != "inlineStr" || c[1].Inline != r.SiteID ||
+			power != r.Power || c[4].Value != strconv.Itoa(r.Count) {
+			t.Errorf("第 %d 列 = %+v, 預期 %+v", i+2, c, r)
+		}
+		// 日期序號為當地時間，套用日期格式
+		local := r.Time.In(testLocation)
+		wall := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), 0, 0, time.UTC)
+		got := excelEpoch.Add(time.Duration(math.Round(serial*24*60)) * time.Minute)
+		if c[3].Style != "1" || !got.Equal(wall) {
+			t.Errorf("第 %d 列時間序號 %g = %v, 預期 %v", i+2, serial, got, wall)
+		}
+	}
+}
+
+func TestXLSXMaxRows(t *testing.T) {
+	e := &xlsxEncoder{rows: xlsxMaxRows}
+	if err := e.writeRow([]interface{}{int64(1)}); err == nil {
+		t.Error("超過列數上限應返回錯誤")
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for i, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d) = %s, 預期 %s", i, got, want)
+		}
+	}
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	// 超過一個 row group 的列數
+	records := testRecords(parquetRowGroupSize + 7)
+	file := readParquet(t, encode(t, FormatParquet, records, testLocation))
+
+	if file.rows != int64(len(records)) {
+		t.Fatalf("num_rows = %d, 預期 %d", file.rows, len(records))
+	}
+	wantSchema := []parquetSchemaElement{
+		{"id", parquetInt64, -1},
+		{"site_id", parquetByteArray, convertedUTF8},
+		{"power", parquetDouble, -1},
+		{"time", parquetInt64, convertedTimestampMillis},
+		{"count", parquetInt64, -1},
+	}
+	if len(file.schema) != len(wantSchema) {
+		t.Fatalf("schema = %+v, 預期 %+v", file.schema, wantSchema)
+	}
+	for i := range wantSchema {
+		if file.schema[i] != wantSchema[i] {
+			t.Errorf("schema[%d] = %+v, 預期 %+v", i, file.schema[i], wantSchema[i])
+		}
+	}
+	if len(file.groups) != 2 || file.groups[0] != parquetRowGroupSize || file.groups[1] != 7 {
+		t.Errorf("row groups = %v, 預期 [%d 7]", file.groups, parquetRowGroupSize)
+	}
+
+	for i, r := range records {
+		row := file.values[i]
+		if row[0] != r.ID || row[1] != r.SiteID || row[2] != r.Power || row[3] != r.Time.UnixMilli() || row[4] != int64(r.Count) {
+			t.Fatalf("第 %d 列 = %v, 預期 %+v", i, row, r)
+		}
+	}
+}
+
+func TestParquetEmpty(t *testing.T) {
+	file := readParquet(t, encode(t, FormatParquet, nil, nil))
+	if file.rows != 0 || len(file.groups) != 0 || len(file.schema) != len(wantHeader) {
+		t.Errorf("空檔案 = %+v", file)
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	if _, err := NewWriter(io.Discard, "json", &record{}, nil); err == nil {
+		t.Error("不支援的格式應返回錯誤")
+	}
+	if _, err := NewWriter(io.Discard, FormatCSV, struct{ M map[string]int }{}, nil); err == nil {
+		t.Error("不支援的欄位型別應返回錯誤")
+	}
+	w, _ := NewWriter(io.Discard, FormatCSV, record{}, nil)
+	if err := w.Write(struct{}{}); err == nil {
+		t.Error("型別不符應返回錯誤")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		format, accept, want string
+		err                  bool
+	}{
+		{"", "", "", false},
+		{"json", "text/csv", "", false},
+		{"CSV", "", FormatCSV, false},
+		{"parquet", "text/csv", FormatParquet, false},
+		{"pdf", "", "", true},
+		{"", "application/json, text/csv;q=0.9", FormatCSV, false},
+		{"", "application/x-parquet", FormatParquet, false},
+		{"", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", FormatXLSX, false},
+	}
+	for _, tt := range tests {
+		got, err := Negotiate(tt.format, tt.accept)
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("Negotiate(%q, %q) = %q, %v", tt.format, tt.accept, got, err)
+		}
+	}
+}
+
+// parquetSchemaElement 讀回的欄位定義
+type parquetSchemaElement struct {
+	name      string
+	physical  int64
+	converted int64
+}
+
+// parquetFile 讀回的 Parquet 內容
+type parquetFile struct {
+	rows   int64
+	schema []parquetSchemaElement
+	groups []int64 // 各 row group 的列數
+	values [][]interface{}
+}
+
+// readParquet 依 Parquet 規格讀回檔案：檢查首尾標記、解析檔尾 FileMetaData，再依 column chunk 位置讀出 PLAIN 編碼的值
+func readParquet(t *testing.T, data []byte) *parquetFile {
+	t.Helper()
+	if len(data) < 12 || string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		t.Fatal("缺少 PAR1 標記")
+	}
+	footerLen := int(uint32(data[len(data)-8]) | uint32(data[len(data)-7])<<8 | uint32(data[len(data)-6])<<16 | uint32(data[len(data)-5])<<24)
+	footerStart := len(data) - 8 - footerLen
+	r := &thriftReader{data: data[footerStart : len(data)-8]}
+	meta := r.readStruct(t)
+	if r.pos != footerLen {
+		t.Fatalf("FileMetaData 長度 %d, 檔尾記錄 %d", r.pos, footerLen)
+	}
+
+	file := &parquetFile{rows: meta[3].(int64)}
+	schema := meta[2].([]interface{})
+	root := schema[0].(map[int16]interface{})
+	if root[4] != "schema" || root[5] != int64(len(schema)-1) {
+		t.Fatalf("根節點 = %v", root)
+	}
+	for _, e := range schema[1:] {
+		el := e.(map[int16]interface{})
+		if el[3] != int64(0) {
+			t.Errorf("欄位 %v 應為 REQUIRED", el[4])
+		}
+		converted := int64(-1)
+		if v, ok := el[6]; ok {
+			converted = v.(int64)
+		}
+		file.schema = append(file.schema, parquetSchemaElement{el[4].(string), el[1].(int64), converted})
+	}
+
+	groups, _ := meta[4].([]interface{})
+	for _, g := range groups {
+		group := g.(map[int16]interface{})
+		rows := group[3].(int64)
+		file.groups = append(file.groups, rows)
+
+		columns := make([][]interface{}, len(file.schema))
+		for i, c := range group[1].([]interface{}) {
+			chunk := c.(map[int16]interface{})
+			cm := chunk[3].(map[int16]interface{})
+			offset := cm[9].(int64)
+			if cm[5] != rows || cm[4] != int64(0) || cm[1] != file.schema[i].physical {
+				t.Fatalf("column chunk %d 中繼資料 = %v", i, cm)
+			}
+			if path := cm[3].([]interface{}); len(path) != 1 || path[0] != file.schema[i].name {
+				t.Fatalf("column chunk %d 路徑 = %v", i, path)
+			}
+
+			page := &thriftReader{data: data[offset : offset+cm[6].(int64)]}
+			header := page.readStruct(t)
+			dataHeader := header[5].(map[int16]interface{})
+			if header[1] != int64(0) || dataHeader[1] != rows || dataHeader[2] != int64(0) {
+				t.Fatalf("page header = %v", header)
+			}
+			values := page.data[page.pos:]
+			if int64(len(values)) != header[3].(int64) {
+				t.Fatalf("page 長度 %d, 標頭記錄 %d", len(values), header[3])
+			}
+			columns[i] = decodePlain(t, values, file.schema[i].physical, int(rows))
+		}
+
+		for row := 0; row < int(rows); row++ {
+			values := make([]interface{}, len(columns))
+			for i := range columns {
+				values[i] = columns[i][row]
+			}
+			file.values = append(file.values, values)
+		}
+	}
+	return file
+}
+
+// decodePlain 解碼 PLAIN 編碼的值
+func decodePlain(t *testing.T, data []byte, physical int64, n int) []interface{} {
+	t.Helper()
+	values := make([]interface{}, 0, n)
+	le64 := func(b []byte) uint64 {
+		var v uint64
+		for i := 7; i >= 0; i-- {
+			v = v<<8 | uint64(b[i])
+		}
+		return v
+	}
+	for pos := 0; pos < len(data); {
+		switch physical {
+		case parquetInt64:
+			values = append(values, int64(le64(data[pos:])))
+			pos += 8
+		case parquetDouble:
+			values = append(values, math.Float64frombits(le64(data[pos:])))
+			pos += 8
+		case parquetByteArray:
+			l := int(uint32(data[pos]) | uint32(data[pos+1])<<8 | uint32(data[pos+2])<<16 | uint32(data[pos+3])<<24)
+			values = append(values, string(data[pos+4:pos+4+l]))
+			pos += 4 + l
+		default:
+			t.Fatalf("未知的物理型別 %d", physical)
+		}
+	}
+	if len(values) != n {
+		t.Fatalf("解碼出 %d 個值, 預期 %d", len(values), n)
+	}
+	return values
+}
+
+// thriftReader Thrift compact protocol 解碼，結構體解為 欄位ID -> 值
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) byte() byte {
+	b := r.data[r.pos]
+	r.pos++
+	return b
+}
+
+func (r *thriftReader) varint() uint64 {
+	var v uint64
+	for shift := 0; ; shift += 7 {
+		b := r.byte()
+		v |= uint64(b&0x7f) << shift
+		if b < 0x80 {
+			return v
+		}
+	}
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(t *testing.T, typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		l := int(r.varint())
+		s := string(r.data[r.pos : r.pos+l])
+		r.pos += l
+		return s
+	case thriftList:
+		header := r.byte()
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(r.varint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(t, header&0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct(t)
+	default:
+		t.Fatalf("未知的 Thrift 型別 %d", typ)
+		return nil
+	}
+}
+
+func (r *thriftReader) readStruct(t *testing.T) map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		header := r.byte()
+		if header == 0 {
+			return fields
+		}
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(t, header&0x0f)
+		last = id
+	}
+}
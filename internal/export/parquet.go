@@ -0,0 +1,232 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// parquetRowGroupSize 每個 row group 的列數，寫出前只緩衝一個 row group
+const parquetRowGroupSize = 50000
+
+// parquetMagic Parquet 檔案開頭與結尾的標記
+const parquetMagic = "PAR1"
+
+// Parquet 物理型別
+const (
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+)
+
+// Parquet 轉換型別
+const (
+	convertedUTF8            = 0
+	convertedTimestampMillis = 9
+)
+
+// parquetColumnChunk 已寫出的 column chunk 位置，用於檔尾的中繼資料
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+}
+
+// parquetRowGroup 已寫出的 row group
+type parquetRowGroup struct {
+	rows    int64
+	columns []parquetColumnChunk
+}
+
+// parquetEncoder Parquet 檔案，欄位皆為 REQUIRED，PLAIN 編碼且不壓縮
+// 整數為 INT64、浮點數為 DOUBLE、字串為 UTF8 BYTE_ARRAY、時間為 UTC 毫秒 TIMESTAMP_MILLIS
+type parquetEncoder struct {
+	w       *countingWriter
+	columns []column
+	buffers []bytes.Buffer
+	rows    int64
+	groups  []parquetRowGroup
+	scratch [8]byte
+}
+
+// countingWriter 記錄已寫出的位元組數
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// Write 實作 io.Writer
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// newParquetEncoder 創建 Parquet 寫入並寫入檔頭
+func newParquetEncoder(w io.Writer, columns []column) (*parquetEncoder, error) {
+	e := &parquetEncoder{
+		w:       &countingWriter{w: w},
+		columns: columns,
+		buffers: make([]bytes.Buffer, len(columns)),
+	}
+	if _, err := io.WriteString(e.w, parquetMagic); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// writeRow 將一列的值附加到各欄緩衝，滿一個 row group 時寫出
+func (e *parquetEncoder) writeRow(values []interface{}) error {
+	for i, v := range values {
+		buf := &e.buffers[i]
+		switch v := v.(type) {
+		case string:
+			binary.LittleEndian.PutUint32(e.scratch[:4], uint32(len(v)))
+			buf.Write(e.scratch[:4])
+			buf.WriteString(v)
+		case int64:
+			binary.LittleEndian.PutUint64(e.scratch[:], uint64(v))
+			buf.Write(e.scratch[:])
+		case float64:
+			binary.LittleEndian.PutUint64(e.scratch[:], math.Float64bits(v))
+			buf.Write(e.scratch[:])
+		case time.Time:
+			binary.LittleEndian.PutUint64(e.scratch[:], uint64(v.UnixMilli()))
+			buf.Write(e.scratch[:])
+		}
+	}
+
+	e.rows++
+	if e.rows >= parquetRowGroupSize {
+		return e.flushRowGroup()
+	}
+	return nil
+}
+
+// flushRowGroup 將緩衝的各欄寫成一個 row group，每欄一個 data page
+func (e *parquetEncoder) flushRowGroup() error {
+	if e.rows == 0 {
+		return nil
+	}
+
+	group := parquetRowGroup{rows: e.rows, columns: make([]parquetColumnChunk, len(e.columns))}
+	for i := range e.columns {
+		data := e.buffers[i].Bytes()
+
+		// PageHeader
+		t := &thriftWriter{}
+		t.i32(1, 0) // DATA_PAGE
+		t.i32(2, int32(len(data)))
+		t.i32(3, int32(len(data)))
+		t.beginStruct(5) // DataPageHeader
+		t.i32(1, int32(e.rows))
+		t.i32(2, 0) // PLAIN
+		t.i32(3, 3) // RLE
+		t.i32(4, 3) // RLE
+		t.endStruct()
+		t.stop()
+
+		offset := e.w.n
+		if _, err := e.w.Write(t.buf.Bytes()); err != nil {
+			return err
+		}
+		if _, err := e.w.Write(data); err != nil {
+			return err
+		}
+		group.columns[i] = parquetColumnChunk{offset: offset, size: e.w.n - offset}
+		e.buffers[i].Reset()
+	}
+
+	e.groups = append(e.groups, group)
+	e.rows = 0
+	return nil
+}
+
+// close 寫出剩餘資料及檔尾中繼資料
+func (e *parquetEncoder) close() error {
+	if err := e.flushRowGroup(); err != nil {
+		return err
+	}
+
+	var totalRows int64
+	for _, g := range e.groups {
+		totalRows += g.rows
+	}
+
+	// FileMetaData
+	t := &thriftWriter{}
+	t.i32(1, 1) // version
+	t.listBegin(2, thriftStruct, len(e.columns)+1)
+	t.elemBegin()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(e.columns)))
+	t.elemEnd()
+	for _, col := range e.columns {
+		physical, converted := parquetType(col.Kind)
+		t.elemBegin()
+		t.i32(1, physical)
+		t.i32(3, 0) // REQUIRED
+		t.binary(4, col.Name)
+		if converted >= 0 {
+			t.i32(6, converted)
+		}
+		t.elemEnd()
+	}
+	t.i64(3, totalRows)
+	t.listBegin(4, thriftStruct, len(e.groups))
+	for _, g := range e.groups {
+		var groupSize int64
+		t.elemBegin()
+		t.listBegin(1, thriftStruct, len(g.columns))
+		for i, chunk := range g.columns {
+			physical, _ := parquetType(e.columns[i].Kind)
+			groupSize += chunk.size
+			t.elemBegin()
+			t.i64(2, chunk.offset)
+			t.beginStruct(3) // ColumnMetaData
+			t.i32(1, physical)
+			t.listBegin(2, thriftI32, 1)
+			t.listI32(0) // PLAIN
+			t.listBegin(3, thriftBinary, 1)
+			t.listBinary(e.columns[i].Name)
+			t.i32(4, 0) // UNCOMPRESSED
+			t.i64(5, g.rows)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.endStruct()
+			t.elemEnd()
+		}
+		t.i64(2, groupSize)
+		t.i64(3, g.rows)
+		t.elemEnd()
+	}
+	t.binary(6, "vpp-go")
+	t.stop()
+
+	footer := t.buf.Bytes()
+	if _, err := e.w.Write(footer); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(e.scratch[:4], uint32(len(footer)))
+	if _, err := e.w.Write(e.scratch[:4]); err != nil {
+		return err
+	}
+	_, err := io.WriteString(e.w, parquetMagic)
+	return err
+}
+
+// parquetType 欄位類型對應的物理型別與轉換型別，無轉換型別時為 -1
+func parquetType(kind int) (int32, int32) {
+	switch kind {
+	case kindString:
+		return parquetByteArray, convertedUTF8
+	case kindFloat:
+		return parquetDouble, -1
+	case kindTime:
+		return parquetInt64, convertedTimestampMillis
+	default:
+		return parquetInt64, -1
+	}
+}
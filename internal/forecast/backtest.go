@@ -0,0 +1,75 @@
+package forecast
+
+import (
+	"time"
+	"vpp-go/internal/models"
+)
+
+// Point 單一區間的預測值
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+// PredictFunc 以 issuedAt 之前的數據訓練並返回預測
+type PredictFunc func(issuedAt time.Time) ([]Point, error)
+
+// LeadAccuracy 依預測時距分組的準確度
+type LeadAccuracy struct {
+	Lead string `json:"lead"`
+	Accuracy
+}
+
+// BacktestResult 回測結果
+type BacktestResult struct {
+	Runs    int            `json:"runs"`
+	Skipped int            `json:"skipped"` // 訓練數據不足而略過的發布次數
+	Overall Accuracy       `json:"overall"`
+	ByLead  []LeadAccuracy `json:"by_lead"`
+}
+
+// Backtest 在 start 至 end 之間每天 0 點（依 location）發布一次預測，與實際值比較
+// 預測時距分為 0-24 小時與 24-48 小時兩組，超出 end 的目標時間不計入
+func Backtest(start, end time.Time, location *time.Location, actual map[time.Time]float64, predict PredictFunc) (*BacktestResult, error) {
+	result := &BacktestResult{}
+	var all []models.ForecastActual
+	byLead := make([][]models.ForecastActual, 2)
+
+	start = start.In(location)
+	for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, location); day.Before(end); day = day.AddDate(0, 0, 1) {
+		points, err := predict(day)
+		if err == ErrInsufficientData {
+			result.Skipped++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.Runs++
+
+		for _, p := range points {
+			if !p.Time.Before(end) {
+				continue
+			}
+			value, ok := actual[p.Time.UTC()]
+			if !ok {
+				continue
+			}
+			pair := models.ForecastActual{Time: p.Time, Forecast: p.Value, Actual: value}
+			all = append(all, pair)
+			bucket := int(p.Time.Sub(day) / (24 * time.Hour))
+			if bucket >= 0 && bucket < len(byLead) {
+				byLead[bucket] = append(byLead[bucket], pair)
+			}
+		}
+	}
+
+	result.Overall = ComputeAccuracy(all, start, end)
+	for i, pairs := range byLead {
+		result.ByLead = append(result.ByLead, LeadAccuracy{
+			Lead:     []string{"0-24h", "24-48h"}[i],
+			Accuracy: ComputeAccuracy(pairs, start, end),
+		})
+	}
+	return result, nil
+}
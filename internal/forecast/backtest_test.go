@@ -0,0 +1,74 @@
+package forecast
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBacktest(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, testLocation)
+	end := start.AddDate(0, 0, 3)
+
+	actual := make(map[time.Time]float64)
+	for ts := start; ts.Before(end.AddDate(0, 0, 2)); ts = ts.Add(Interval) {
+		actual[ts.UTC()] = 100
+	}
+
+	// 第一天數據不足；0-24 小時誤差 1 kW，24-48 小時誤差 2 kW
+	var issued []time.Time
+	predict := func(issuedAt time.Time) ([]Point, error) {
+		issued = append(issued, issuedAt)
+		if issuedAt.Equal(start) {
+			return nil, ErrInsufficientData
+		}
+		var points []Point
+		for i := 0; i < int(48*time.Hour/Interval); i++ {
+			ts := issuedAt.Add(time.Duration(i) * Interval)
+			offset := 1.0
+			if i >= slotsPerDay {
+				offset = 2
+			}
+			points = append(points, Point{Time: ts, Value: 100 + offset})
+		}
+		return points, nil
+	}
+
+	result, err := Backtest(start, end, testLocation, actual, predict)
+	if err != nil {
+		t.Fatalf("回測失敗: %v", err)
+	}
+	if len(issued) != 3 || result.Runs != 2 || result.Skipped != 1 {
+		t.Errorf("發布 %d 次、執行 %d、略過 %d, 預期 3、2、1", len(issued), result.Runs, result.Skipped)
+	}
+	for _, issuedAt := range issued {
+		if issuedAt.In(testLocation).Hour() != 0 {
+			t.Errorf("發布時間 %v 應為當地 0 點", issuedAt)
+		}
+	}
+
+	// 6/2 發布的 192 筆全數計入，6/3 發布的只計入 end 之前的 96 筆
+	check := func(name string, acc Accuracy, samples int, rmse, mape float64) {
+		t.Helper()
+		if acc.Samples != samples || acc.RMSE == nil || *acc.RMSE != rmse || acc.MAPE == nil || *acc.MAPE != mape {
+			t.Errorf("%s = 樣本 %d、RMSE %v、MAPE %v, 預期 %d、%g、%g", name, acc.Samples, acc.RMSE, acc.MAPE, samples, rmse, mape)
+		}
+	}
+	check("overall", result.Overall, 288, 1.414, 1.333)
+	if len(result.ByLead) != 2 {
+		t.Fatalf("ByLead = %d 組, 預期 2", len(result.ByLead))
+	}
+	check(result.ByLead[0].Lead, result.ByLead[0].Accuracy, 192, 1, 1)
+	check(result.ByLead[1].Lead, result.ByLead[1].Accuracy, 96, 2, 2)
+}
+
+func TestBacktestError(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, testLocation)
+	failure := errors.New("查詢失敗")
+	_, err := Backtest(start, start.AddDate(0, 0, 1), testLocation, nil, func(time.Time) ([]Point, error) {
+		return nil, failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("錯誤 = %v, 預期返回預測函式的錯誤", err)
+	}
+}
@@ -0,0 +1,138 @@
+package forecast
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCalendarNotCovered 行事曆缺少該年度的假日資料
+var ErrCalendarNotCovered = errors.New("行事曆缺少國定假日資料")
+
+// fixedHolidays 每年固定日期的國定假日（月-日）
+var fixedHolidays = []string{
+	"01-01", // 元旦
+	"02-28", // 和平紀念日
+	"04-04", // 兒童節
+	"05-01", // 勞動節
+	"10-10", // 國慶日
+}
+
+// yearlyHolidays 農曆節日、清明、補假及調整放假，依人事行政總處公告
+var yearlyHolidays = map[int][]string{
+	2024: {
+		"2024-02-08", "2024-02-09", "2024-02-10", "2024-02-11", "2024-02-12", "2024-02-13", "2024-02-14", // 春節
+		"2024-04-05", // 清明節
+		"2024-06-10", // 端午節
+		"2024-09-17", // 中秋節
+	},
+	2025: {
+		"2025-01-27", "2025-01-28", "2025-01-29", "2025-01-30", "2025-01-31", // 春節
+		"2025-04-03", // 兒童節補假
+		"2025-05-30", // 端午節補假
+		"2025-09-29", // 教師節補假
+		"2025-10-06", // 中秋節
+		"2025-10-24", // 光復節補假
+		"2025-12-25", // 行憲紀念日
+	},
+	2026: {
+		"2026-02-16", "2026-02-17", "2026-02-18", "2026-02-19", "2026-02-20", // 春節
+		"2026-02-27", // 和平紀念日補假
+		"2026-04-03", // 兒童節補假
+		"2026-04-06", // 清明節補假
+		"2026-06-19", // 端午節
+		"2026-09-25", // 中秋節
+		"2026-09-28", // 教師節
+		"2026-10-09", // 國慶日補假
+		"2026-10-26", // 光復節補假
+		"2026-12-25", // 行憲紀念日
+	},
+}
+
+// yearlyWorkdays 週末補行上班日
+var yearlyWorkdays = map[int][]string{
+	2024: {"2024-02-17"},
+	2025: {"2025-02-08"},
+}
+
+// Calendar 台灣的國定假日與補行上班日
+type Calendar struct {
+	holidays map[string]bool
+	workdays map[string]bool
+	years    map[int]bool // 已有農曆節日及補假資料的年度
+}
+
+// NewCalendar 創建行事曆，內建 2024-2026 年的假日，extraHolidays、extraWorkdays（YYYY-MM-DD）可補充其他年度或臨時調整
+// extraHolidays 中出現的年度視為已涵蓋
+func NewCalendar(extraHolidays, extraWorkdays []string) (*Calendar, error) {
+	c := &Calendar{holidays: make(map[string]bool), workdays: make(map[string]bool), years: make(map[int]bool)}
+	for year, days := range yearlyHolidays {
+		c.years[year] = true
+		for _, d := range days {
+			c.holidays[d] = true
+		}
+	}
+	for _, days := range yearlyWorkdays {
+		for _, d := range days {
+			c.workdays[d] = true
+		}
+	}
+
+	for _, d := range extraHolidays {
+		date, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			return nil, fmt.Errorf("無效的假日日期 %q: %w", d, err)
+		}
+		c.holidays[d] = true
+		c.years[date.Year()] = true
+	}
+	for _, d := range extraWorkdays {
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return nil, fmt.Errorf("無效的補行上班日期 %q: %w", d, err)
+		}
+		c.workdays[d] = true
+	}
+	return c, nil
+}
+
+// CheckCoverage 檢查 start 至 end（含）之間的每個年度都有假日資料
+// 缺少的年度只能辨識固定日期的假日，春節等農曆節日會被當作平日
+func (c *Calendar) CheckCoverage(start, end time.Time) error {
+	var missing []string
+	for year := start.Year(); year <= end.Year(); year++ {
+		if !c.years[year] {
+			missing = append(missing, strconv.Itoa(year))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s 年，請以 HOLIDAYS 補充", ErrCalendarNotCovered, strings.Join(missing, "、"))
+	}
+	return nil
+}
+
+// IsHoliday 是否為國定假日（不含一般週末）
+func (c *Calendar) IsHoliday(date time.Time) bool {
+	key := date.Format("2006-01-02")
+	if c.holidays[key] {
+		return true
+	}
+	for _, d := range fixedHolidays {
+		if key[5:] == d {
+			return true
+		}
+	}
+	return false
+}
+
+// DayType 返回用於負載預測的日別：國定假日視同週日（0），補行上班日視同週一（1），其餘為星期幾
+func (c *Calendar) DayType(date time.Time) int {
+	if c.workdays[date.Format("2006-01-02")] {
+		return int(time.Monday)
+	}
+	if c.IsHoliday(date) {
+		return int(time.Sunday)
+	}
+	return int(date.Weekday())
+}
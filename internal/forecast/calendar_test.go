@@ -0,0 +1,86 @@
+package forecast
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDayType(t *testing.T) {
+	calendar, err := NewCalendar([]string{"2027-02-08"}, []string{"2027-02-20"})
+	if err != nil {
+		t.Fatalf("創建行事曆失敗: %v", err)
+	}
+
+	tests := []struct {
+		date    string
+		want    time.Weekday
+		holiday bool
+	}{
+		{"2024-06-11", time.Tuesday, false},  // 一般平日
+		{"2024-06-15", time.Saturday, false}, // 一般週末
+		{"2024-02-14", time.Sunday, true},    // 春節（週三）
+		{"2024-02-17", time.Monday, false},   // 補行上班日（週六）
+		{"2024-10-10", time.Sunday, true},    // 固定日期的國慶日
+		{"2025-10-24", time.Sunday, true},    // 光復節補假
+		{"2027-10-11", time.Monday, false},   // 內建年度以外的平日
+		{"2027-10-10", time.Sunday, true},    // 內建年度以外仍可辨識固定日期
+		{"2027-02-08", time.Sunday, true},    // 以 HOLIDAYS 補充的假日
+		{"2027-02-20", time.Monday, false},   // 以 MAKEUP_WORKDAYS 補充的補班日
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			date, _ := time.Parse("2006-01-02", tt.date)
+			if got := calendar.DayType(date); got != int(tt.want) {
+				t.Errorf("DayType = %v, 預期 %v", time.Weekday(got), tt.want)
+			}
+			if got := calendar.IsHoliday(date); got != tt.holiday {
+				t.Errorf("IsHoliday = %v, 預期 %v", got, tt.holiday)
+			}
+		})
+	}
+}
+
+func TestNewCalendarInvalid(t *testing.T) {
+	if _, err := NewCalendar([]string{"2027/02/08"}, nil); err == nil {
+		t.Error("無效的假日日期應返回錯誤")
+	}
+	if _, err := NewCalendar(nil, []string{"20270220"}); err == nil {
+		t.Error("無效的補班日期應返回錯誤")
+	}
+}
+
+func TestCheckCoverage(t *testing.T) {
+	builtin, _ := NewCalendar(nil, nil)
+	extended, _ := NewCalendar([]string{"2027-02-08"}, nil)
+	date := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		calendar *Calendar
+		start    string
+		end      string
+		covered  bool
+	}{
+		{"內建年度", builtin, "2024-01-01", "2026-12-31", true},
+		{"預測跨入未涵蓋的年度", builtin, "2026-12-01", "2027-01-01", false},
+		{"訓練期間涵蓋前一年度", builtin, "2023-12-15", "2024-01-10", false},
+		{"以 HOLIDAYS 補充的年度", extended, "2026-12-01", "2027-01-01", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.calendar.CheckCoverage(date(tt.start), date(tt.end))
+			if tt.covered && err != nil {
+				t.Errorf("錯誤 = %v, 預期已涵蓋", err)
+			}
+			if !tt.covered && !errors.Is(err, ErrCalendarNotCovered) {
+				t.Errorf("錯誤 = %v, 預期 ErrCalendarNotCovered", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package forecast
+
+import (
+	"math"
+	"time"
+)
+
+// solarPosition 以 NOAA 簡化公式計算太陽天頂角餘弦與時角（弧度）
+func solarPosition(t time.Time, latitude, longitude float64) (cosZenith, hourAngle float64) {
+	t = t.UTC()
+	hours := float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+	gamma := 2 * math.Pi / 365 * (float64(t.YearDay()-1) + (hours-12)/24)
+
+	// 均時差（分鐘）與赤緯（弧度）
+	eqTime := 229.18 * (0.000075 + 0.001868*math.Cos(gamma) - 0.032077*math.Sin(gamma) -
+		0.014615*math.Cos(2*gamma) - 0.040849*math.Sin(2*gamma))
+	declination := 0.006918 - 0.399912*math.Cos(gamma) + 0.070257*math.Sin(gamma) -
+		0.006758*math.Cos(2*gamma) + 0.000907*math.Sin(2*gamma) -
+		0.002697*math.Cos(3*gamma) + 0.00148*math.Sin(3*gamma)
+
+	trueSolarMinutes := hours*60 + eqTime + 4*longitude
+	hourAngle = (trueSolarMinutes/4 - 180) * math.Pi / 180
+
+	lat := latitude * math.Pi / 180
+	cosZenith = math.Sin(lat)*math.Sin(declination) + math.Cos(lat)*math.Cos(declination)*math.Cos(hourAngle)
+	return cosZenith, hourAngle
+}
+
+// ClearSkyGHI 以 Haurwitz 模型計算晴空水平面日照 (W/m²)
+func ClearSkyGHI(t time.Time, latitude, longitude float64) float64 {
+	cosZenith, _ := solarPosition(t, latitude, longitude)
+	if cosZenith <= 0 {
+		return 0
+	}
+	return 1098 * cosZenith * math.Exp(-0.057/cosZenith)
+}
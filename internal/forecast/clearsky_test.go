@@ -0,0 +1,78 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSolarPosition(t *testing.T) {
+	tests := []struct {
+		name      string
+		time      time.Time
+		latitude  float64
+		longitude float64
+		cosZenith float64
+	}{
+		// 春分赤道的太陽正午（均時差約 -7.5 分鐘），太陽在天頂
+		{"春分赤道正午", time.Date(2024, 3, 20, 12, 7, 30, 0, time.UTC), 0, 0, 1},
+		// 時角 60°，天頂角 60°
+		{"春分赤道下午四時", time.Date(2024, 3, 20, 16, 7, 30, 0, time.UTC), 0, 0, 0.5},
+		// 夏至北回歸線的太陽正午（均時差約 -1.7 分鐘）
+		{"夏至北回歸線正午", time.Date(2024, 6, 20, 12, 1, 40, 0, time.UTC), 23.44, 0, 1},
+		// 冬至北回歸線正午，天頂角約 46.9°
+		{"冬至北回歸線正午", time.Date(2024, 12, 21, 11, 58, 0, 0, time.UTC), 23.44, 0, math.Cos(46.88 * math.Pi / 180)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := solarPosition(tt.time, tt.latitude, tt.longitude)
+			if math.Abs(got-tt.cosZenith) > 0.005 {
+				t.Errorf("cos(天頂角) = %.4f, 預期 %.4f", got, tt.cosZenith)
+			}
+		})
+	}
+}
+
+func TestClearSkyGHI(t *testing.T) {
+	tests := []struct {
+		name      string
+		time      time.Time
+		latitude  float64
+		longitude float64
+		want      float64
+		tolerance float64
+	}{
+		// 天頂時為 1098·e^(-0.057)
+		{"天頂", time.Date(2024, 3, 20, 12, 7, 30, 0, time.UTC), 0, 0, 1037.2, 2},
+		// 天頂角 60° 時為 1098·0.5·e^(-0.114)
+		{"天頂角60度", time.Date(2024, 3, 20, 16, 7, 30, 0, time.UTC), 0, 0, 489.8, 5},
+		// 台北午夜
+		{"夜間", time.Date(2024, 6, 20, 16, 0, 0, 0, time.UTC), 25.03, 121.56, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ClearSkyGHI(tt.time, tt.latitude, tt.longitude)
+			if math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("ClearSkyGHI = %.1f, 預期 %.1f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClearSkyGHISymmetric(t *testing.T) {
+	// 台北太陽正午前後對稱，正午最大
+	noon := time.Date(2024, 6, 20, 3, 55, 22, 0, time.UTC) // 東經 121.56° 加上均時差，太陽正午約為 UTC 03:55
+	peak := ClearSkyGHI(noon, 25.03, 121.56)
+	for _, d := range []time.Duration{time.Hour, 3 * time.Hour} {
+		before := ClearSkyGHI(noon.Add(-d), 25.03, 121.56)
+		after := ClearSkyGHI(noon.Add(d), 25.03, 121.56)
+		if before >= peak || after >= peak {
+			t.Errorf("±%v 的日照 %.1f、%.1f 不應超過正午 %.1f", d, before, after, peak)
+		}
+		if math.Abs(before-after) > 5 {
+			t.Errorf("±%v 的日照 %.1f、%.1f 應對稱", d, before, after)
+		}
+	}
+}
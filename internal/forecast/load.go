@@ -0,0 +1,129 @@
+package forecast
+
+import (
+	"math"
+	"time"
+	"vpp-go/internal/models"
+)
+
+const (
+	// LoadHorizon 負載預測的時間範圍
+	LoadHorizon = 48 * time.Hour
+	// slotsPerDay 每日的 15 分鐘區間數
+	slotsPerDay = int(24 * time.Hour / Interval)
+	// minLoadDays 訓練所需的最少天數
+	minLoadDays = 7
+	// loadHalfLife 歷史樣本權重的半衰期，越近的日子權重越高
+	loadHalfLife = 14 * 24 * time.Hour
+	// levelDecay 近期趨勢對預測的影響隨預測時距衰減的時間常數
+	levelDecay = 24 * time.Hour
+)
+
+// LoadModel 負載預測模型
+// 典型日曲線依日別（星期幾，國定假日視同週日）與 15 分鐘區間以加權平均求得，
+// 再乘上近 24 小時實際負載相對典型日的比例（趨勢），該比例隨預測時距衰減
+type LoadModel struct {
+	Location *time.Location
+	Calendar *Calendar
+	IssuedAt time.Time
+	Level    float64
+
+	profile [7][]float64
+	weights [7][]float64
+	overall []float64 // 不分日別的曲線，日別無數據時使用
+}
+
+// slotOf 返回時間所屬的日別與區間
+func (m *LoadModel) slotOf(t time.Time) (int, int) {
+	local := t.In(m.Location)
+	return m.Calendar.DayType(local), (local.Hour()*60 + local.Minute()) / int(Interval/time.Minute)
+}
+
+// baseline 典型日曲線的負載
+func (m *LoadModel) baseline(t time.Time) float64 {
+	dayType, slot := m.slotOf(t)
+	if m.weights[dayType][slot] > 0 {
+		return m.profile[dayType][slot]
+	}
+	return m.overall[slot]
+}
+
+// TrainLoad 以發布時間前的歷史 15 分鐘平均負載訓練模型，返回模型、樣本數與擬合 RMSE
+func TrainLoad(history []models.LoadInterval, issuedAt time.Time, calendar *Calendar, location *time.Location) (*LoadModel, int, float64, error) {
+	m := &LoadModel{Location: location, Calendar: calendar, IssuedAt: issuedAt, Level: 1}
+	for d := range m.profile {
+		m.profile[d] = make([]float64, slotsPerDay)
+		m.weights[d] = make([]float64, slotsPerDay)
+	}
+	m.overall = make([]float64, slotsPerDay)
+	overallWeights := make([]float64, slotsPerDay)
+
+	days := make(map[string]bool)
+	samples := 0
+	for _, h := range history {
+		if !h.Time.Before(issuedAt) {
+			continue
+		}
+		age := issuedAt.Sub(h.Time)
+		w := math.Pow(0.5, float64(age)/float64(loadHalfLife))
+		dayType, slot := m.slotOf(h.Time)
+		m.profile[dayType][slot] += w * h.LoadValue
+		m.weights[dayType][slot] += w
+		m.overall[slot] += w * h.LoadValue
+		overallWeights[slot] += w
+		days[h.Time.In(location).Format("2006-01-02")] = true
+		samples++
+	}
+	if len(days) < minLoadDays {
+		return nil, samples, 0, ErrInsufficientData
+	}
+
+	for d := range m.profile {
+		for s := range m.profile[d] {
+			if m.weights[d][s] > 0 {
+				m.profile[d][s] /= m.weights[d][s]
+			}
+		}
+	}
+	for s := range m.overall {
+		if overallWeights[s] > 0 {
+			m.overall[s] /= overallWeights[s]
+		}
+	}
+
+	// 近 24 小時實際負載相對典型日的比例
+	var actual, expected, sumSquared float64
+	for _, h := range history {
+		if !h.Time.Before(issuedAt) {
+			continue
+		}
+		base := m.baseline(h.Time)
+		diff := base - h.LoadValue
+		sumSquared += diff * diff
+		if issuedAt.Sub(h.Time) <= 24*time.Hour {
+			actual += h.LoadValue
+			expected += base
+		}
+	}
+	if expected > 0 {
+		m.Level = math.Max(0.5, math.Min(actual/expected, 1.5))
+	}
+
+	return m, samples, round3(math.Sqrt(sumSquared / float64(samples))), nil
+}
+
+// Predict 產生從 start 起 n 個區間的預測
+func (m *LoadModel) Predict(start time.Time, n int) []models.LoadForecast {
+	forecasts := make([]models.LoadForecast, 0, n)
+	for i := 0; i < n; i++ {
+		t := start.Add(time.Duration(i) * Interval)
+		lead := t.Sub(m.IssuedAt)
+		adjustment := 1 + (m.Level-1)*math.Exp(-float64(lead)/float64(levelDecay))
+		forecasts = append(forecasts, models.LoadForecast{
+			TargetTime: t,
+			LoadKW:     round3(math.Max(0, m.baseline(t)*adjustment)),
+			Holiday:    m.Calendar.IsHoliday(t.In(m.Location)),
+		})
+	}
+	return forecasts
+}
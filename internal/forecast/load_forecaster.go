@@ -0,0 +1,84 @@
+package forecast
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/models"
+)
+
+// LoadForecaster 場站負載預測任務：以近期歷史重新訓練模型並產生未來 48 小時的預測
+type LoadForecaster struct {
+	Load      *models.LoadDataModel
+	Forecasts *models.LoadForecastModel
+
+	Site         config.SiteConfig
+	Calendar     *Calendar
+	TrainingDays int
+}
+
+// NewLoadForecaster 創建場站負載預測任務
+func NewLoadForecaster(db *sql.DB, site config.SiteConfig, calendar *Calendar, cfg config.ForecastConfig) *LoadForecaster {
+	return &LoadForecaster{
+		Load:         models.NewLoadDataModel(db),
+		Forecasts:    models.NewLoadForecastModel(db),
+		Site:         site,
+		Calendar:     calendar,
+		TrainingDays: cfg.TrainingDays,
+	}
+}
+
+// Name 排程任務名稱
+func (f *LoadForecaster) Name() string {
+	return "forecast:load:" + f.Site.ID
+}
+
+// Run 排程執行入口
+func (f *LoadForecaster) Run(ctx context.Context) error {
+	_, err := f.Forecast(time.Now())
+	return err
+}
+
+// Forecast 訓練模型並保存從下一個區間開始的預測
+func (f *LoadForecaster) Forecast(now time.Time) (*models.LoadForecastRun, error) {
+	location := f.Site.Timezone
+	if location == nil {
+		location = time.Local
+	}
+
+	issuedAt := now.Truncate(Interval)
+	trainingStart := issuedAt.AddDate(0, 0, -f.TrainingDays)
+	// 缺少假日資料時春節等假日會被當作平日，寧可失敗也不產生錯誤的預測
+	if err := f.Calendar.CheckCoverage(trainingStart.In(location), issuedAt.Add(LoadHorizon).In(location)); err != nil {
+		return nil, fmt.Errorf("場站 %s 無法預測: %w", f.Site.ID, err)
+	}
+	history, err := f.Load.GetIntervals(f.Site.ID, trainingStart, issuedAt, Interval)
+	if err != nil {
+		return nil, fmt.Errorf("查詢歷史數據失敗: %w", err)
+	}
+
+	model, samples, fitRMSE, err := TrainLoad(history, issuedAt, f.Calendar, location)
+	if err != nil {
+		return nil, fmt.Errorf("場站 %s 訓練失敗（%d 個樣本）: %w", f.Site.ID, samples, err)
+	}
+
+	run := &models.LoadForecastRun{
+		SiteID:        f.Site.ID,
+		IssuedAt:      issuedAt,
+		TrainingStart: trainingStart,
+		TrainingEnd:   issuedAt,
+		Samples:       samples,
+		Level:         round3(model.Level),
+		FitRMSE:       fitRMSE,
+	}
+	forecasts := model.Predict(issuedAt.Add(Interval), int(LoadHorizon/Interval))
+	if err := f.Forecasts.Insert(run, forecasts); err != nil {
+		return nil, err
+	}
+
+	log.Printf("負載預測完成 - 場站: %s, 樣本: %d, 擬合 RMSE: %.3f kW\n", f.Site.ID, samples, fitRMSE)
+	return run, nil
+}
@@ -0,0 +1,96 @@
+package forecast
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+	"vpp-go/internal/models"
+)
+
+// syntheticLoad 平日負載隨時段變化，週日與國定假日為固定值
+func syntheticLoad(calendar *Calendar, t time.Time) float64 {
+	local := t.In(testLocation)
+	if calendar.DayType(local) == int(time.Sunday) {
+		return 60
+	}
+	slot := float64(local.Hour()*4 + local.Minute()/15)
+	return 100 + 50*math.Sin(2*math.Pi*slot/float64(slotsPerDay))
+}
+
+// loadHistory 產生 start 至 end 之間 15 分鐘一筆的負載
+func loadHistory(calendar *Calendar, start, end time.Time) []models.LoadInterval {
+	var history []models.LoadInterval
+	for t := start; t.Before(end); t = t.Add(Interval) {
+		history = append(history, models.LoadInterval{Time: t, LoadValue: syntheticLoad(calendar, t)})
+	}
+	return history
+}
+
+func TestTrainLoad(t *testing.T) {
+	calendar, _ := NewCalendar(nil, nil)
+	// 2024-04-04 兒童節為週四，預測範圍內含國定假日
+	issuedAt := time.Date(2024, 4, 3, 0, 0, 0, 0, testLocation)
+	history := loadHistory(calendar, issuedAt.AddDate(0, 0, -28), issuedAt.AddDate(0, 0, 2))
+
+	model, samples, rmse, err := TrainLoad(history, issuedAt, calendar, testLocation)
+	if err != nil {
+		t.Fatalf("訓練失敗: %v", err)
+	}
+	// 發布時間之後的數據不可用於訓練
+	if samples != 28*slotsPerDay {
+		t.Errorf("樣本數 = %d, 預期 %d", samples, 28*slotsPerDay)
+	}
+	if rmse > 1e-6 || math.Abs(model.Level-1) > 1e-9 {
+		t.Errorf("擬合 RMSE %g、level %g, 無雜訊數據應完全擬合", rmse, model.Level)
+	}
+
+	forecasts := model.Predict(issuedAt, int(LoadHorizon/Interval))
+	for _, f := range forecasts {
+		want := syntheticLoad(calendar, f.TargetTime)
+		if math.Abs(f.LoadKW-want) > 1e-3 {
+			t.Errorf("%s 預測 %.3f kW, 預期 %.3f kW", f.TargetTime.Format("01-02 15:04"), f.LoadKW, want)
+		}
+		holiday := f.TargetTime.In(testLocation).Day() == 4
+		if f.Holiday != holiday {
+			t.Errorf("%s Holiday = %v, 預期 %v", f.TargetTime.Format("01-02 15:04"), f.Holiday, holiday)
+		}
+	}
+}
+
+func TestTrainLoadLevel(t *testing.T) {
+	calendar, _ := NewCalendar(nil, nil)
+	issuedAt := time.Date(2024, 6, 12, 0, 0, 0, 0, testLocation)
+	history := loadHistory(calendar, issuedAt.AddDate(0, 0, -28), issuedAt)
+	// 近 24 小時負載高出 20%
+	for i := len(history) - slotsPerDay; i < len(history); i++ {
+		history[i].LoadValue *= 1.2
+	}
+
+	model, _, _, err := TrainLoad(history, issuedAt, calendar, testLocation)
+	if err != nil {
+		t.Fatalf("訓練失敗: %v", err)
+	}
+	// 典型日曲線也包含該日，比例略低於 1.2
+	if model.Level <= 1.1 || model.Level >= 1.2 {
+		t.Fatalf("level = %g, 預期介於 1.1 與 1.2", model.Level)
+	}
+
+	// 趨勢的影響隨預測時距遞減
+	forecasts := model.Predict(issuedAt, int(LoadHorizon/Interval))
+	near := forecasts[0].LoadKW / model.baseline(forecasts[0].TargetTime)
+	far := forecasts[len(forecasts)-1].LoadKW / model.baseline(forecasts[len(forecasts)-1].TargetTime)
+	if math.Abs(near-model.Level) > 1e-3 || far >= near || far <= 1 {
+		t.Errorf("調整比例 近 %g、遠 %g, level %g", near, far, model.Level)
+	}
+}
+
+func TestTrainLoadInsufficientData(t *testing.T) {
+	calendar, _ := NewCalendar(nil, nil)
+	issuedAt := time.Date(2024, 6, 12, 0, 0, 0, 0, testLocation)
+	history := loadHistory(calendar, issuedAt.AddDate(0, 0, -minLoadDays+1), issuedAt)
+
+	if _, _, _, err := TrainLoad(history, issuedAt, calendar, testLocation); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("錯誤 = %v, 預期 ErrInsufficientData", err)
+	}
+}
@@ -0,0 +1,63 @@
+package forecast
+
+import (
+	"math"
+	"time"
+	"vpp-go/internal/models"
+)
+
+// mapeFloor 計算 MAPE 時實際值須達區間內最大實際值的比例，避免夜間或極小值放大誤差
+const mapeFloor = 0.05
+
+// Accuracy 預測準確度
+type Accuracy struct {
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Samples int       `json:"samples"`
+	MAPE    *float64  `json:"mape"` // %，無有效樣本時為 null
+	RMSE    *float64  `json:"rmse"`
+	MAE     *float64  `json:"mae"`
+}
+
+// ComputeAccuracy 計算預測值與實際值的 MAPE、RMSE、MAE
+// 預測與實際皆為零的區間（如夜間）不計入；MAPE 只計實際值不低於最大實際值 5% 的區間
+func ComputeAccuracy(pairs []models.ForecastActual, start, end time.Time) Accuracy {
+	result := Accuracy{Start: start, End: end}
+
+	maxActual := 0.0
+	for _, p := range pairs {
+		maxActual = math.Max(maxActual, p.Actual)
+	}
+
+	var sumSquared, sumAbs, sumPct float64
+	var pctSamples int
+	for _, p := range pairs {
+		if p.Forecast == 0 && p.Actual == 0 {
+			continue
+		}
+		diff := p.Forecast - p.Actual
+		sumSquared += diff * diff
+		sumAbs += math.Abs(diff)
+		result.Samples++
+		if p.Actual > 0 && p.Actual >= maxActual*mapeFloor {
+			sumPct += math.Abs(diff) / p.Actual
+			pctSamples++
+		}
+	}
+
+	if result.Samples > 0 {
+		rmse := round3(math.Sqrt(sumSquared / float64(result.Samples)))
+		mae := round3(sumAbs / float64(result.Samples))
+		result.RMSE, result.MAE = &rmse, &mae
+	}
+	if pctSamples > 0 {
+		mape := round3(sumPct / float64(pctSamples) * 100)
+		result.MAPE = &mape
+	}
+	return result
+}
+
+// round3 四捨五入至小數第三位
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,50 @@
+package forecast
+
+import (
+	"testing"
+	"time"
+	"vpp-go/internal/models"
+)
+
+func TestComputeAccuracy(t *testing.T) {
+	pairs := []models.ForecastActual{
+		{Forecast: 0, Actual: 0}, // 夜間不計入
+		{Forecast: 110, Actual: 100},
+		{Forecast: 90, Actual: 100},
+		{Forecast: 50, Actual: 40},
+		{Forecast: 2, Actual: 1}, // 低於最大實際值 5%，不計入 MAPE
+		{Forecast: 0, Actual: 0},
+	}
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	got := ComputeAccuracy(pairs, start, end)
+	if got.Samples != 4 {
+		t.Errorf("Samples = %d, 預期 4", got.Samples)
+	}
+	// 誤差 10、-10、10、1
+	check := func(name string, v *float64, want float64) {
+		if v == nil || *v != want {
+			t.Errorf("%s = %v, 預期 %g", name, v, want)
+		}
+	}
+	check("RMSE", got.RMSE, 8.675) // √(301/4)
+	check("MAE", got.MAE, 7.75)    // 31/4
+	check("MAPE", got.MAPE, 15)    // (10% + 10% + 25%) / 3
+	if !got.Start.Equal(start) || !got.End.Equal(end) {
+		t.Errorf("期間 = %v ~ %v", got.Start, got.End)
+	}
+}
+
+func TestComputeAccuracyEmpty(t *testing.T) {
+	got := ComputeAccuracy([]models.ForecastActual{{Forecast: 0, Actual: 0}}, time.Time{}, time.Time{})
+	if got.Samples != 0 || got.RMSE != nil || got.MAE != nil || got.MAPE != nil {
+		t.Errorf("無有效樣本時 = %+v, 預期皆為 null", got)
+	}
+
+	// 實際值皆為零時只有 RMSE 與 MAE
+	got = ComputeAccuracy([]models.ForecastActual{{Forecast: 3, Actual: 0}}, time.Time{}, time.Time{})
+	if got.RMSE == nil || *got.RMSE != 3 || got.MAPE != nil {
+		t.Errorf("實際值為零時 = %+v, 預期 RMSE 3、MAPE null", got)
+	}
+}
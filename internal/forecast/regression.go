@@ -0,0 +1,59 @@
+package forecast
+
+import (
+	"errors"
+	"math"
+)
+
+// ridgeRegression 以正規方程式求解脊迴歸係數：(XᵀX + λI) w = Xᵀy
+func ridgeRegression(xs [][]float64, ys []float64, lambda float64) ([]float64, error) {
+	if len(xs) == 0 {
+		return nil, errors.New("沒有訓練樣本")
+	}
+	n := len(xs[0])
+
+	// 增廣矩陣 [XᵀX + λI | Xᵀy]
+	a := make([][]float64, n)
+	for i := range a {
+		a[i] = make([]float64, n+1)
+		a[i][i] = lambda
+	}
+	for k, x := range xs {
+		for i := 0; i < n; i++ {
+			for j := 0; j < n; j++ {
+				a[i][j] += x[i] * x[j]
+			}
+			a[i][n] += x[i] * ys[k]
+		}
+	}
+
+	// 部分主元高斯消去
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, errors.New("特徵矩陣奇異，無法求解")
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+
+		for row := 0; row < n; row++ {
+			if row == col {
+				continue
+			}
+			factor := a[row][col] / a[col][col]
+			for j := col; j <= n; j++ {
+				a[row][j] -= factor * a[col][j]
+			}
+		}
+	}
+
+	w := make([]float64, n)
+	for i := range w {
+		w[i] = a[i][n] / a[i][i]
+	}
+	return w, nil
+}
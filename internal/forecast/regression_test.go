@@ -0,0 +1,61 @@
+package forecast
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRidgeRegression(t *testing.T) {
+	// y = 2·x0 - 3·x1 + 0.5·x2，λ 極小時應還原係數
+	want := []float64{2, -3, 0.5}
+	var xs [][]float64
+	var ys []float64
+	for i := 0; i < 50; i++ {
+		x := []float64{1, math.Sin(float64(i)), float64(i%7) / 7}
+		xs = append(xs, x)
+		ys = append(ys, want[0]*x[0]+want[1]*x[1]+want[2]*x[2])
+	}
+
+	got, err := ridgeRegression(xs, ys, 1e-9)
+	if err != nil {
+		t.Fatalf("求解失敗: %v", err)
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-6 {
+			t.Errorf("w[%d] = %g, 預期 %g", i, got[i], want[i])
+		}
+	}
+}
+
+func TestRidgeRegressionShrinkage(t *testing.T) {
+	// 單一特徵時 w = Σxy / (Σx² + λ)
+	xs := [][]float64{{1}, {2}, {3}}
+	ys := []float64{2, 4, 6}
+	for _, lambda := range []float64{0, 1, 14} {
+		got, err := ridgeRegression(xs, ys, lambda)
+		if err != nil {
+			t.Fatalf("λ=%g 求解失敗: %v", lambda, err)
+		}
+		want := 28 / (14 + lambda)
+		if math.Abs(got[0]-want) > 1e-12 {
+			t.Errorf("λ=%g 時 w = %g, 預期 %g", lambda, got[0], want)
+		}
+	}
+}
+
+func TestRidgeRegressionErrors(t *testing.T) {
+	if _, err := ridgeRegression(nil, nil, 1); err == nil {
+		t.Error("沒有樣本時應返回錯誤")
+	}
+
+	// 兩個特徵完全相同且不正則化時矩陣奇異
+	xs := [][]float64{{1, 1}, {2, 2}, {3, 3}}
+	if _, err := ridgeRegression(xs, []float64{1, 2, 3}, 0); err == nil {
+		t.Error("奇異矩陣應返回錯誤")
+	}
+	// 加上正則化後可求解，且兩個係數相等
+	w, err := ridgeRegression(xs, []float64{1, 2, 3}, 1)
+	if err != nil || math.Abs(w[0]-w[1]) > 1e-12 {
+		t.Errorf("正則化後 w = %v, %v", w, err)
+	}
+}
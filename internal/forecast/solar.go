@@ -0,0 +1,182 @@
+package forecast
+
+import (
+	"errors"
+	"math"
+	"time"
+	"vpp-go/internal/models"
+)
+
+const (
+	// Interval 預測區間
+	Interval = 15 * time.Minute
+	// SolarHorizon 太陽能預測的時間範圍
+	SolarHorizon = 48 * time.Hour
+	// minSolarSamples 訓練所需的最少白天樣本數（約 5 天）
+	minSolarSamples = 200
+	// ridgeLambda 迴歸的正則化係數
+	ridgeLambda = 1.0
+)
+
+// ErrInsufficientData 歷史數據不足以訓練模型
+var ErrInsufficientData = errors.New("歷史數據不足")
+
+// SolarModel 太陽能發電迴歸模型
+// 預測值 = 晴空日照 ×（常數 + 前一日晴空指數 + 季節 + 上下午不對稱）的線性組合，夜間為零
+type SolarModel struct {
+	Latitude     float64
+	Longitude    float64
+	Location     *time.Location
+	Coefficients []float64
+	MaxPowerKW   float64
+	// useRadiation 有日照計數據時以日照計算晴空指數，否則以發電量乘上 scale (kW per W/m²) 推算
+	useRadiation bool
+	scale        float64
+}
+
+// features 建立單一區間的特徵，晴空日照為零時返回 nil
+func (m *SolarModel) features(t time.Time, clearness float64) ([]float64, float64) {
+	mid := t.Add(Interval / 2)
+	clearSky := ClearSkyGHI(mid, m.Latitude, m.Longitude)
+	if clearSky <= 0 {
+		return nil, 0
+	}
+	_, hourAngle := solarPosition(mid, m.Latitude, m.Longitude)
+	season := 2 * math.Pi * float64(mid.YearDay()) / 365.25
+
+	cs := clearSky / 1000
+	return []float64{
+		cs,
+		cs * clearness,
+		cs * math.Sin(season),
+		cs * math.Cos(season),
+		cs * math.Sin(hourAngle),
+	}, clearSky
+}
+
+// TrainSolar 以歷史 15 分鐘平均值訓練場站的太陽能模型，返回模型、訓練樣本數與擬合 RMSE
+// 每個樣本使用前一日的晴空指數（有日照計數據時以日照計算，否則以發電量推算）
+func TrainSolar(history []models.SolarInterval, latitude, longitude float64, location *time.Location) (*SolarModel, int, float64, error) {
+	model := &SolarModel{Latitude: latitude, Longitude: longitude, Location: location}
+	for _, h := range history {
+		model.MaxPowerKW = math.Max(model.MaxPowerKW, h.ACTotalPower)
+	}
+	if model.MaxPowerKW <= 0 {
+		return nil, 0, 0, ErrInsufficientData
+	}
+	model.scale = model.MaxPowerKW / 1000
+	for _, h := range history {
+		if h.SolarRadiation > 0 {
+			model.useRadiation = true
+			break
+		}
+	}
+
+	daily := model.dailyClearness(history)
+
+	var xs [][]float64
+	var ys []float64
+	for _, h := range history {
+		prev := h.Time.In(location).AddDate(0, 0, -1).Format("2006-01-02")
+		clearness, ok := daily[prev]
+		if !ok {
+			continue
+		}
+		x, _ := model.features(h.Time, clearness)
+		if x == nil {
+			continue
+		}
+		xs = append(xs, x)
+		ys = append(ys, h.ACTotalPower)
+	}
+	if len(xs) < minSolarSamples {
+		return nil, len(xs), 0, ErrInsufficientData
+	}
+
+	coefficients, err := ridgeRegression(xs, ys, ridgeLambda)
+	if err != nil {
+		return nil, len(xs), 0, err
+	}
+	model.Coefficients = coefficients
+
+	var sumSquared float64
+	for i, x := range xs {
+		diff := model.predict(x) - ys[i]
+		sumSquared += diff * diff
+	}
+	return model, len(xs), round3(math.Sqrt(sumSquared / float64(len(xs)))), nil
+}
+
+// dailyClearness 計算各日（依場站時區）的晴空指數
+func (m *SolarModel) dailyClearness(history []models.SolarInterval) map[string]float64 {
+	byDay := make(map[string][]models.SolarInterval)
+	for _, h := range history {
+		day := h.Time.In(m.Location).Format("2006-01-02")
+		byDay[day] = append(byDay[day], h)
+	}
+
+	daily := make(map[string]float64, len(byDay))
+	for day, intervals := range byDay {
+		if clearness, ok := m.clearness(intervals); ok {
+			daily[day] = clearness
+		}
+	}
+	return daily
+}
+
+// Clearness 計算一段期間（通常為發布前 24 小時）的晴空指數，無白天數據時返回 fallback
+func (m *SolarModel) Clearness(recent []models.SolarInterval, fallback float64) float64 {
+	if clearness, ok := m.clearness(recent); ok {
+		return clearness
+	}
+	return fallback
+}
+
+// clearness 實測日照（或以發電量推算）與晴空日照的比值
+func (m *SolarModel) clearness(intervals []models.SolarInterval) (float64, bool) {
+	var measured, clearSky float64
+	for _, h := range intervals {
+		cs := ClearSkyGHI(h.Time.Add(Interval/2), m.Latitude, m.Longitude)
+		if cs <= 0 {
+			continue
+		}
+		if m.useRadiation {
+			measured += h.SolarRadiation
+		} else {
+			measured += h.ACTotalPower / m.scale
+		}
+		clearSky += cs
+	}
+	if clearSky == 0 {
+		return 0, false
+	}
+	return math.Min(measured/clearSky, 1.5), true
+}
+
+// predict 依特徵計算預測功率，限制在 0 與歷史最大值的 110% 之間
+func (m *SolarModel) predict(x []float64) float64 {
+	var p float64
+	for i, c := range m.Coefficients {
+		p += c * x[i]
+	}
+	return math.Max(0, math.Min(p, m.MaxPowerKW*1.1))
+}
+
+// Predict 產生從 start 起 n 個區間的預測
+func (m *SolarModel) Predict(start time.Time, n int, clearness float64) []models.SolarForecast {
+	forecasts := make([]models.SolarForecast, 0, n)
+	for i := 0; i < n; i++ {
+		t := start.Add(time.Duration(i) * Interval)
+		x, clearSky := m.features(t, clearness)
+		power := 0.0
+		if x != nil {
+			power = round3(m.predict(x))
+		}
+		forecasts = append(forecasts, models.SolarForecast{
+			TargetTime: t,
+			PowerKW:    power,
+			ClearSky:   math.Round(clearSky*10) / 10,
+		})
+	}
+	return forecasts
+}
@@ -0,0 +1,88 @@
+package forecast
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/models"
+)
+
+// SolarForecaster 場站太陽能預測任務：以近期歷史重新訓練模型並產生未來 48 小時的預測
+type SolarForecaster struct {
+	Solar     *models.SolarDataModel
+	Forecasts *models.SolarForecastModel
+
+	Site         config.SiteConfig
+	TrainingDays int
+}
+
+// NewSolarForecaster 創建場站太陽能預測任務
+func NewSolarForecaster(db *sql.DB, site config.SiteConfig, cfg config.ForecastConfig) *SolarForecaster {
+	return &SolarForecaster{
+		Solar:        models.NewSolarDataModel(db),
+		Forecasts:    models.NewSolarForecastModel(db),
+		Site:         site,
+		TrainingDays: cfg.TrainingDays,
+	}
+}
+
+// Name 排程任務名稱
+func (f *SolarForecaster) Name() string {
+	return "forecast:solar:" + f.Site.ID
+}
+
+// Run 排程執行入口
+func (f *SolarForecaster) Run(ctx context.Context) error {
+	_, err := f.Forecast(time.Now())
+	return err
+}
+
+// Forecast 訓練模型並保存從下一個區間開始的預測
+func (f *SolarForecaster) Forecast(now time.Time) (*models.SolarForecastRun, error) {
+	location := f.Site.Timezone
+	if location == nil {
+		location = time.Local
+	}
+
+	issuedAt := now.Truncate(Interval)
+	trainingStart := issuedAt.AddDate(0, 0, -f.TrainingDays)
+	history, err := f.Solar.GetIntervals(f.Site.ID, trainingStart, issuedAt, Interval)
+	if err != nil {
+		return nil, fmt.Errorf("查詢歷史數據失敗: %w", err)
+	}
+
+	model, samples, fitRMSE, err := TrainSolar(history, f.Site.Latitude, f.Site.Longitude, location)
+	if err != nil {
+		return nil, fmt.Errorf("場站 %s 訓練失敗（%d 個樣本）: %w", f.Site.ID, samples, err)
+	}
+
+	// 以發布前 24 小時的晴空指數預測，無數據時視為晴天
+	recent := make([]models.SolarInterval, 0, 96)
+	for _, h := range history {
+		if !h.Time.Before(issuedAt.Add(-24 * time.Hour)) {
+			recent = append(recent, h)
+		}
+	}
+	clearness := model.Clearness(recent, 1)
+
+	run := &models.SolarForecastRun{
+		SiteID:        f.Site.ID,
+		IssuedAt:      issuedAt,
+		TrainingStart: trainingStart,
+		TrainingEnd:   issuedAt,
+		Samples:       samples,
+		Clearness:     round3(clearness),
+		Coefficients:  model.Coefficients,
+		FitRMSE:       fitRMSE,
+	}
+	forecasts := model.Predict(issuedAt.Add(Interval), int(SolarHorizon/Interval), clearness)
+	if err := f.Forecasts.Insert(run, forecasts); err != nil {
+		return nil, err
+	}
+
+	log.Printf("太陽能預測完成 - 場站: %s, 樣本: %d, 擬合 RMSE: %.3f kW\n", f.Site.ID, samples, fitRMSE)
+	return run, nil
+}
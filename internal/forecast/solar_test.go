@@ -0,0 +1,129 @@
+package forecast
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+	"vpp-go/internal/models"
+)
+
+// 測試場站位置（台南）
+const (
+	testLatitude  = 23.0
+	testLongitude = 120.2
+)
+
+var testLocation = time.FixedZone("Asia/Taipei", 8*3600)
+
+// trueSolarCoefficients 產生合成數據所用的係數
+var trueSolarCoefficients = []float64{600, 400, 30, -20, 15}
+
+// dayClearness 第 d 天的晴空指數，在 0.4 ~ 1.0 之間變化
+func dayClearness(d int) float64 {
+	return 0.7 + 0.3*math.Sin(float64(d)*1.3)
+}
+
+// syntheticSolar 產生 days 天 15 分鐘一筆的歷史數據
+// 日照為晴空日照乘上當日晴空指數，功率依已知係數與前一日晴空指數計算
+func syntheticSolar(start time.Time, days int) []models.SolarInterval {
+	truth := &SolarModel{Latitude: testLatitude, Longitude: testLongitude, Location: testLocation,
+		Coefficients: trueSolarCoefficients, MaxPowerKW: math.Inf(1)}
+
+	var history []models.SolarInterval
+	for d := 0; d < days; d++ {
+		for i := 0; i < 96; i++ {
+			t := start.AddDate(0, 0, d).Add(time.Duration(i) * Interval)
+			interval := models.SolarInterval{Time: t}
+			if x, clearSky := truth.features(t, dayClearness(d-1)); x != nil {
+				interval.SolarRadiation = clearSky * dayClearness(d)
+				if d > 0 {
+					interval.ACTotalPower = truth.predict(x)
+				}
+			}
+			history = append(history, interval)
+		}
+	}
+	return history
+}
+
+func TestTrainSolar(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, testLocation)
+	history := syntheticSolar(start, 30)
+
+	model, samples, rmse, err := TrainSolar(history, testLatitude, testLongitude, testLocation)
+	if err != nil {
+		t.Fatalf("訓練失敗: %v", err)
+	}
+	if samples < minSolarSamples {
+		t.Errorf("樣本數 = %d, 預期至少 %d", samples, minSolarSamples)
+	}
+	// 正則化會使係數略為縮小，擬合誤差應在峰值的 1% 內
+	tolerance := model.MaxPowerKW * 0.01
+	if rmse > tolerance {
+		t.Errorf("擬合 RMSE = %g, 預期不超過 %g", rmse, tolerance)
+	}
+
+	// 晴空指數與上下午不對稱的係數可從數據辨識
+	for _, i := range []int{1, 4} {
+		if math.Abs(model.Coefficients[i]-trueSolarCoefficients[i]) > 0.02*math.Abs(trueSolarCoefficients[i]) {
+			t.Errorf("係數[%d] = %g, 預期 %g", i, model.Coefficients[i], trueSolarCoefficients[i])
+		}
+	}
+
+	// 以隔日的晴空指數預測，與已知係數的結果一致
+	next := start.AddDate(0, 0, 30)
+	clearness := model.Clearness(history[len(history)-96:], 0.5)
+	if math.Abs(clearness-dayClearness(29)) > 1e-9 {
+		t.Errorf("晴空指數 = %g, 預期 %g", clearness, dayClearness(29))
+	}
+	truth := syntheticSolar(start, 31)[30*96:]
+	for i, f := range model.Predict(next, 96, clearness) {
+		if !f.TargetTime.Equal(truth[i].Time) {
+			t.Fatalf("第 %d 筆時間 = %v, 預期 %v", i, f.TargetTime, truth[i].Time)
+		}
+		if math.Abs(f.PowerKW-truth[i].ACTotalPower) > tolerance {
+			t.Errorf("%s 預測 %.1f kW, 預期 %.1f kW", f.TargetTime.Format("15:04"), f.PowerKW, truth[i].ACTotalPower)
+		}
+		if truth[i].ACTotalPower == 0 && f.PowerKW != 0 {
+			t.Errorf("%s 夜間預測 %.1f kW, 預期 0", f.TargetTime.Format("15:04"), f.PowerKW)
+		}
+	}
+}
+
+func TestTrainSolarWithoutRadiation(t *testing.T) {
+	// 沒有日照計時以發電量推算晴空指數，為近似值，擬合誤差應在峰值的 10% 內
+	history := syntheticSolar(time.Date(2024, 5, 1, 0, 0, 0, 0, testLocation), 30)
+	for i := range history {
+		history[i].SolarRadiation = 0
+	}
+
+	model, samples, rmse, err := TrainSolar(history, testLatitude, testLongitude, testLocation)
+	if err != nil {
+		t.Fatalf("訓練失敗: %v", err)
+	}
+	if samples < minSolarSamples || rmse > model.MaxPowerKW*0.1 {
+		t.Errorf("樣本數 %d、擬合 RMSE %g", samples, rmse)
+	}
+}
+
+func TestTrainSolarInsufficientData(t *testing.T) {
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, testLocation)
+	tests := []struct {
+		name    string
+		history []models.SolarInterval
+	}{
+		{"沒有數據", nil},
+		{"發電量皆為零", make([]models.SolarInterval, 96)},
+		{"天數不足", syntheticSolar(start, 3)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := TrainSolar(tt.history, testLatitude, testLongitude, testLocation)
+			if !errors.Is(err, ErrInsufficientData) {
+				t.Errorf("錯誤 = %v, 預期 ErrInsufficientData", err)
+			}
+		})
+	}
+}
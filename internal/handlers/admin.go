@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/collectors"
+	"vpp-go/internal/httpclient"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBackfillDays 單次API回補的最大天數
+const maxBackfillDays = 93
+
+// BackfillRequest 回補請求結構
+type BackfillRequest struct {
+	StartDate   string `json:"start_date" binding:"required"`
+	EndDate     string `json:"end_date" binding:"required"`
+	Concurrency int    `json:"concurrency"`
+	IntervalMS  int    `json:"interval_ms"`
+	DryRun      bool   `json:"dry_run"`
+}
+
+// GetJobs 獲取所有排程任務的執行狀態
+func (h *Handler) GetJobs(c *gin.Context) {
+	if !h.authorizeAll(c, auth.PermAdmin) {
+		return
+	}
+
+	if h.Scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "排程器未啟用"})
+		return
+	}
+
+	jobs := h.Scheduler.Status()
+	c.JSON(http.StatusOK, gin.H{
+		"count":     len(jobs),
+		"jobs":      jobs,
+		"upstreams": httpclient.Default().BreakerStates(),
+	})
+}
+
+// GetJob 獲取單一任務的執行狀態，一次性任務（如回補）完成後含結果
+func (h *Handler) GetJob(c *gin.Context) {
+	if !h.authorizeAll(c, auth.PermAdmin) {
+		return
+	}
+
+	if h.Scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "排程器未啟用"})
+		return
+	}
+
+	job, ok := h.Scheduler.Job(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到任務: " + c.Param("name")})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// GetEventSubscribers 獲取事件匯流排各訂閱者的處理統計
+func (h *Handler) GetEventSubscribers(c *gin.Context) {
+	if !h.authorizeAll(c, auth.PermAdmin) {
+		return
+	}
+
+	if h.Events == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "事件匯流排未啟用"})
+		return
+	}
+
+	subscribers := h.Events.Stats()
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(subscribers),
+		"subscribers": subscribers,
+	})
+}
+
+// RunJob 立即執行指定排程任務
+func (h *Handler) RunJob(c *gin.Context) {
+	if !h.authorizeAll(c, auth.PermAdmin) {
+		return
+	}
+
+	if h.Scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "排程器未啟用"})
+		return
+	}
+
+	name := c.Param("name")
+	if err := h.Scheduler.RunNow(name); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "任務已開始執行",
+		"job":     name,
+	})
+}
+
+// BackfillReserve 在背景回補日期區間內缺漏的台電備轉資料，返回 202 及任務ID
+// 進度與結果由 GET /api/admin/jobs/:name 查詢，服務關閉時取消
+func (h *Handler) BackfillReserve(c *gin.Context) {
+	if !h.authorizeAll(c, auth.PermAdmin) {
+		return
+	}
+
+	if h.TaipowerCollector == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "台電收集器未啟用"})
+		return
+	}
+	if h.Scheduler == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "排程器未啟用，請改用 backfill 命令"})
+		return
+	}
+
+	var req BackfillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的請求數據"})
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的開始日期格式"})
+		return
+	}
+
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的結束日期格式"})
+		return
+	}
+
+	if endDate.Before(startDate) || endDate.Sub(startDate) > maxBackfillDays*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "日期區間無效或超過上限"})
+		return
+	}
+
+	if req.Concurrency <= 0 {
+		req.Concurrency = 2
+	}
+	if req.IntervalMS <= 0 {
+		req.IntervalMS = 2000
+	}
+
+	job := collectors.NewBackfillJob(h.TaipowerCollector, startDate, endDate, collectors.BackfillOptions{
+		Concurrency: req.Concurrency,
+		Interval:    time.Duration(req.IntervalMS) * time.Millisecond,
+		DryRun:      req.DryRun,
+	})
+	if err := h.Scheduler.Submit(job); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "回補任務已開始執行",
+		"job":        job.Name(),
+		"status_url": "/api/admin/jobs/" + job.Name(),
+	})
+}
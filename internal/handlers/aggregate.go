@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	"vpp-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAggregateBuckets 單次聚合查詢的區間數上限
+const maxAggregateBuckets = 10000
+
+// aggregateFunc 依時間區間聚合單一場站數據
+type aggregateFunc func(siteID string, q models.AggregateQuery) ([]models.AggregateBucket, error)
+
+// aggregateHistory 以 interval、agg、fill 參數聚合歷史數據
+// 日期依場站時區解析，end_date 當日包含在內；未帶 end_date 時至目前為止
+func (h *Handler) aggregateHistory(c *gin.Context, siteID string, fn aggregateFunc) {
+	q := models.AggregateQuery{
+		Interval: c.Query("interval"),
+		Agg:      c.DefaultQuery("agg", models.AggAvg),
+		Fill:     c.DefaultQuery("fill", models.FillNull),
+		Location: h.siteLocation(siteID),
+	}
+	if !models.IsValidInterval(q.Interval) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的interval參數（15m、1h、1d、1M）"})
+		return
+	}
+	if !models.IsValidAgg(q.Agg) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的agg參數（avg、min、max、sum、last）"})
+		return
+	}
+	if !models.IsValidFill(q.Fill) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的fill參數（null、zero、previous）"})
+		return
+	}
+	if c.Query("format") != "" && c.Query("format") != "json" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "聚合查詢只支援JSON"})
+		return
+	}
+
+	now := time.Now()
+	q.Start = now.AddDate(0, 0, -30) // 預設30天前
+	q.End = now
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		startDate, err := time.ParseInLocation("2006-01-02", startDateStr, q.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的開始日期格式"})
+			return
+		}
+		q.Start = startDate
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		endDate, err := time.ParseInLocation("2006-01-02", endDateStr, q.Location)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的結束日期格式"})
+			return
+		}
+		q.End = endDate.AddDate(0, 0, 1)
+	}
+	if !q.End.After(q.Start) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "結束日期須晚於開始日期"})
+		return
+	}
+	if q.BucketCount() > maxAggregateBuckets {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("查詢區間過多（上限 %d），請縮短日期範圍或加大interval", maxAggregateBuckets)})
+		return
+	}
+
+	buckets, err := fn(siteID, q)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"site_id":    siteID,
+		"start_date": q.Start.Format("2006-01-02"),
+		"end_date":   q.End.AddDate(0, 0, -1).Format("2006-01-02"),
+		"interval":   q.Interval,
+		"agg":        q.Agg,
+		"fill":       q.Fill,
+		"count":      len(buckets),
+		"data":       buckets,
+	})
+}
+
+// siteLocation 獲取場站時區，找不到場站配置時使用系統時區
+func (h *Handler) siteLocation(siteID string) *time.Location {
+	for _, site := range h.Sites {
+		if site.ID == siteID && site.Timezone != nil {
+			return site.Timezone
+		}
+	}
+	return time.Local
+}
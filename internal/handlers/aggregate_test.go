@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// aggregateRouter 以假的聚合函數掛上 aggregateHistory，返回收到的查詢條件
+func aggregateRouter(h *Handler, err error) (*gin.Engine, *[]models.AggregateQuery) {
+	gin.SetMode(gin.TestMode)
+	var calls []models.AggregateQuery
+	fn := func(siteID string, q models.AggregateQuery) ([]models.AggregateBucket, error) {
+		calls = append(calls, q)
+		if err != nil {
+			return nil, err
+		}
+		return []models.AggregateBucket{{Time: q.AlignStart()}}, nil
+	}
+	r := gin.New()
+	r.GET("/history", func(c *gin.Context) { h.aggregateHistory(c, "north", fn) })
+	return r, &calls
+}
+
+func TestAggregateHistoryValidation(t *testing.T) {
+	h := &Handler{Sites: []config.SiteConfig{{ID: "north", Timezone: time.FixedZone("CST", 8*60*60)}}}
+
+	tests := []struct {
+		name  string
+		query string
+		code  int
+		error string
+	}{
+		{"缺少 interval", "", http.StatusBadRequest, "interval"},
+		{"無效的 interval", "interval=2h", http.StatusBadRequest, "interval"},
+		{"無效的 agg", "interval=1h&agg=median", http.StatusBadRequest, "agg"},
+		{"無效的 fill", "interval=1h&fill=linear", http.StatusBadRequest, "fill"},
+		{"不支援 CSV", "interval=1h&format=csv", http.StatusBadRequest, "JSON"},
+		{"開始日期格式", "interval=1h&start_date=2024/06/01", http.StatusBadRequest, "開始日期"},
+		{"結束日期格式", "interval=1h&start_date=2024-06-01&end_date=06-02", http.StatusBadRequest, "結束日期"},
+		{"結束早於開始", "interval=1h&start_date=2024-06-02&end_date=2024-06-01", http.StatusBadRequest, "結束日期須晚於開始日期"},
+		// 15 分鐘區間 104 天為 9985 個區間，105 天為 10081 個
+		{"區間數未超過上限", "interval=15m&start_date=2024-01-01&end_date=2024-04-13", http.StatusOK, ""},
+		{"區間數超過上限", "interval=15m&start_date=2024-01-01&end_date=2024-04-14", http.StatusBadRequest, "10000"},
+		{"1M 以 30 天估算", "interval=1M&start_date=2000-01-01&end_date=2024-12-31", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		r, calls := aggregateRouter(h, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history?"+tt.query, nil))
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.error) {
+			t.Errorf("%s: 狀態 %d、%s, 預期 %d 且包含 %q", tt.name, w.Code, w.Body.String(), tt.code, tt.error)
+		}
+		if tt.code != http.StatusOK && len(*calls) != 0 {
+			t.Errorf("%s: 驗證失敗時仍執行了查詢", tt.name)
+		}
+	}
+}
+
+func TestAggregateHistorySiteTimezone(t *testing.T) {
+	loc := time.FixedZone("CST", 8*60*60)
+	h := &Handler{Sites: []config.SiteConfig{{ID: "north", Timezone: loc}}}
+	r, calls := aggregateRouter(h, nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history?interval=1d&start_date=2024-06-01&end_date=2024-06-02", nil))
+	if w.Code != http.StatusOK || len(*calls) != 1 {
+		t.Fatalf("狀態 %d、%s, 預期 200", w.Code, w.Body.String())
+	}
+
+	// 日期依場站時區解析，結束日期當日包含在內
+	q := (*calls)[0]
+	if want := time.Date(2024, 6, 1, 0, 0, 0, 0, loc); !q.Start.Equal(want) {
+		t.Errorf("Start = %v, 預期 %v", q.Start, want)
+	}
+	if want := time.Date(2024, 6, 3, 0, 0, 0, 0, loc); !q.End.Equal(want) {
+		t.Errorf("End = %v, 預期 %v", q.End, want)
+	}
+	if q.Location != loc || q.Agg != models.AggAvg || q.Fill != models.FillNull {
+		t.Errorf("查詢條件 = %s、%s、%s, 預期場站時區及預設 avg、null", q.Location, q.Agg, q.Fill)
+	}
+
+	var body struct {
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+		Count     int    `json:"count"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.StartDate != "2024-06-01" || body.EndDate != "2024-06-02" || body.Count != 1 {
+		t.Errorf("回應 = %+v, 預期 2024-06-01 ~ 2024-06-02 共 1 筆", body)
+	}
+}
+
+func TestAggregateHistoryQueryError(t *testing.T) {
+	r, _ := aggregateRouter(&Handler{}, errors.New("查詢失敗"))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history?interval=1h", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("狀態 %d, 預期 500", w.Code)
+	}
+}
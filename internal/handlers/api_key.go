@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/config"
+	"vpp-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateAPIKeyRequest 建立閘道器 API 金鑰
+type CreateAPIKeyRequest struct {
+	DeviceID string `json:"device_id" binding:"required"`
+	SiteID   string `json:"site_id" binding:"required"`
+	Name     string `json:"name"`
+}
+
+// CreateAPIKey 建立閘道器 API 金鑰，明文金鑰只在此時返回一次
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的請求數據"})
+		return
+	}
+	if len(req.DeviceID) > maxDeviceID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的設備ID"})
+		return
+	}
+	if !config.IsValidSite(req.SiteID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
+		return
+	}
+	if !h.authorizeSite(c, auth.PermAdmin, req.SiteID) {
+		return
+	}
+	if len(req.Name) > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "名稱過長"})
+		return
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	data := &models.DeviceAPIKey{
+		DeviceID:  req.DeviceID,
+		SiteID:    req.SiteID,
+		Name:      req.Name,
+		KeyPrefix: prefix,
+		KeyHash:   hash,
+	}
+	if err := h.APIKeyModel.Insert(data); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"key":     key,
+		"api_key": data,
+	})
+}
+
+// ListAPIKeys 查詢閘道器 API 金鑰
+// 參數: device_id、site_id（可選）、include_revoked（預設 false），只返回使用者可管理場站的金鑰
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	siteID := c.Query("site_id")
+	if siteID != "" && !config.IsValidSite(siteID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
+		return
+	}
+	if siteID != "" && !h.authorizeSite(c, auth.PermAdmin, siteID) {
+		return
+	}
+	includeRevoked, _ := strconv.ParseBool(c.Query("include_revoked"))
+
+	dataList, err := h.APIKeyModel.List(c.Query("device_id"), siteID, includeRevoked)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 只返回使用者可管理場站的金鑰
+	scope := h.scope(c, auth.PermAdmin)
+	if !scope.all {
+		filtered := make([]models.DeviceAPIKey, 0, len(dataList))
+		for _, data := range dataList {
+			if scope.allows(data.SiteID) {
+				filtered = append(filtered, data)
+			}
+		}
+		dataList = filtered
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(dataList),
+		"data":  dataList,
+	})
+}
+
+// RevokeAPIKey 撤銷閘道器 API 金鑰，撤銷後立即失效
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的金鑰ID"})
+		return
+	}
+
+	data, err := h.APIKeyModel.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if data == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到API金鑰"})
+		return
+	}
+	if !h.authorizeSite(c, auth.PermAdmin, data.SiteID) {
+		return
+	}
+
+	data, err = h.APIKeyModel.Revoke(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if data == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到API金鑰"})
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
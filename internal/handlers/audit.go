@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"time"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/config"
+	"vpp-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ctxAuditSites 請求涉及的場站在 gin.Context 中的鍵，由場站授權檢查記錄
+const ctxAuditSites = "audit.sites"
+
+// Audit 記錄寫入與控制請求（POST、PUT、PATCH、DELETE）的稽核記錄
+// 置於認證中介層之前，認證失敗的請求亦會記錄；寫入失敗只記錄日誌，不影響請求
+func (h *Handler) Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		var body *hashingBody
+		if c.Request.Body != nil {
+			body = &hashingBody{ReadCloser: c.Request.Body, hash: sha256.New()}
+			c.Request.Body = body
+		}
+
+		start := time.Now()
+		c.Next()
+
+		entry := &models.AuditEntry{
+			OccurredAt: start,
+			Action:     c.Request.Method + " " + c.FullPath(),
+			Target:     c.Request.URL.Path,
+			Sites:      auditSites(c),
+			Result:     auditResult(c.Writer.Status()),
+			ClientIP:   c.ClientIP(),
+			DurationMs: time.Since(start).Milliseconds(),
+		}
+		entry.ActorType, entry.Actor = auditActor(c)
+		status := c.Writer.Status()
+		entry.Status = &status
+		if body != nil {
+			entry.PayloadHash = body.sum()
+		}
+
+		if err := h.AuditModel.Insert(entry); err != nil {
+			log.Printf("寫入稽核記錄失敗: %s, 錯誤: %v\n", entry.Action, err)
+		}
+	}
+}
+
+// hashingBody 計算處理器讀取的請求主體雜湊
+type hashingBody struct {
+	io.ReadCloser
+	hash hash.Hash
+	n    int64
+}
+
+// Read 讀取時同時計算雜湊
+func (b *hashingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.hash.Write(p[:n])
+	b.n += int64(n)
+	return n, err
+}
+
+// sum 返回處理器已讀取部分的雜湊，未讀取時（如認證失敗）返回 nil
+// 不主動讀取剩餘主體，避免為被拒絕的請求讀入大量數據
+func (b *hashingBody) sum() *string {
+	if b.n == 0 {
+		return nil
+	}
+	s := hex.EncodeToString(b.hash.Sum(nil))
+	return &s
+}
+
+// auditActor 請求的操作者，依使用者權杖、API 金鑰判定
+func auditActor(c *gin.Context) (string, string) {
+	if claims := userClaims(c); claims != nil {
+		return models.ActorUser, claims.Subject
+	}
+	if key := deviceKey(c); key != nil {
+		return models.ActorDevice, key.DeviceID
+	}
+	return models.ActorAnonymous, ""
+}
+
+// auditResult 依狀態碼判定請求結果
+func auditResult(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return models.AuditDenied
+	case status >= http.StatusBadRequest:
+		return models.AuditFailure
+	default:
+		return models.AuditSuccess
+	}
+}
+
+// addAuditSite 記錄請求涉及的場站
+func addAuditSite(c *gin.Context, siteID string) {
+	sites := auditSites(c)
+	for _, s := range sites {
+		if s == siteID {
+			return
+		}
+	}
+	c.Set(ctxAuditSites, append(sites, siteID))
+}
+
+// auditSites 請求涉及的場站
+func auditSites(c *gin.Context) []string {
+	if v, ok := c.Get(ctxAuditSites); ok {
+		return v.([]string)
+	}
+	return nil
+}
+
+// GetAuditLog 查詢稽核記錄，依時間倒序
+// 參數: actor_type、actor、action、site_id、result、start_date、end_date（可選）、limit（預設 100）、cursor
+// 不具有所有場站的管理權限時只返回所涉場站皆可管理的記錄
+func (h *Handler) GetAuditLog(c *gin.Context) {
+	if !h.authorizeAny(c, auth.PermAdmin) {
+		return
+	}
+
+	filter := models.AuditFilter{
+		ActorType: c.Query("actor_type"),
+		Actor:     c.Query("actor"),
+		Action:    c.Query("action"),
+		SiteID:    c.Query("site_id"),
+		Result:    c.Query("result"),
+	}
+	switch filter.ActorType {
+	case "", models.ActorUser, models.ActorDevice, models.ActorSystem, models.ActorAnonymous:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的actor_type參數（user、device、system、anonymous）"})
+		return
+	}
+	switch filter.Result {
+	case "", models.AuditSuccess, models.AuditDenied, models.AuditFailure:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的result參數（success、denied、failure）"})
+		return
+	}
+	if filter.SiteID != "" {
+		if !config.IsValidSite(filter.SiteID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
+			return
+		}
+		if !h.authorizeSite(c, auth.PermAdmin, filter.SiteID) {
+			return
+		}
+	}
+	filter.Sites = h.scope(c, auth.PermAdmin).list()
+
+	var err error
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		filter.Start, err = time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的開始日期格式"})
+			return
+		}
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		endDate, err := time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的結束日期格式"})
+			return
+		}
+		filter.End = endDate.AddDate(0, 0, 1) // 包含結束日
+	}
+
+	limit, after, err := parsePage(c, 100)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	dataList, next, err := h.AuditModel.List(filter, limit, after)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count":       len(dataList),
+		"limit":       limit,
+		"data":        dataList,
+		"next_cursor": nextCursor(next),
+	})
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestHashingBody(t *testing.T) {
+	// 處理器未讀取時（如認證失敗）不計算雜湊，也不讀取剩餘主體
+	src := strings.NewReader(strings.Repeat("x", 1<<20))
+	body := &hashingBody{ReadCloser: io.NopCloser(src), hash: sha256.New()}
+	if got := body.sum(); got != nil {
+		t.Errorf("未讀取時雜湊 = %q, 預期 nil", *got)
+	}
+	if src.Len() != 1<<20 {
+		t.Errorf("剩餘 %d 位元組, 預期未被讀取", src.Len())
+	}
+
+	// 只計算處理器已讀取的部分
+	body = &hashingBody{ReadCloser: io.NopCloser(strings.NewReader(`{"a":1}trailing`)), hash: sha256.New()}
+	buf := make([]byte, 7)
+	io.ReadFull(body, buf)
+	want := sha256.Sum256([]byte(`{"a":1}`))
+	if got := body.sum(); got == nil || *got != hex.EncodeToString(want[:]) {
+		t.Errorf("雜湊 = %v, 預期已讀取部分的雜湊", got)
+	}
+}
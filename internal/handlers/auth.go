@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/config"
+	"vpp-go/internal/models"
+	"vpp-go/internal/telemetry"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 認證結果在 gin.Context 中的鍵
+const (
+	ctxDeviceKey = "auth.device_key"
+	ctxClaims    = "auth.claims"
+)
+
+// apiKeyHeader 閘道器帶 API 金鑰的標頭
+const apiKeyHeader = "X-API-Key"
+
+// RequireDevice 驗證閘道器的 API 金鑰，路徑帶 device_id 時須與金鑰的設備相同
+func (h *Handler) RequireDevice() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.AuthEnabled {
+			return
+		}
+
+		key := strings.TrimSpace(c.GetHeader(apiKeyHeader))
+		if key == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "缺少 API 金鑰"})
+			return
+		}
+
+		data, err := h.APIKeyModel.Authenticate(auth.HashAPIKey(key))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if data == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "無效或已撤銷的 API 金鑰"})
+			return
+		}
+		if deviceID := c.Param("device_id"); deviceID != "" && deviceID != data.DeviceID {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API 金鑰不屬於此設備"})
+			return
+		}
+
+		c.Set(ctxDeviceKey, data)
+	}
+}
+
+// RequireUser 驗證使用者的 Bearer 權杖
+// 瀏覽器的 EventSource 與 WebSocket 無法設定標頭，即時推送請求可改用 ticket 參數帶一次性票證
+func (h *Handler) RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !h.AuthEnabled {
+			return
+		}
+
+		if value := c.Query("ticket"); value != "" && isStreamRequest(c) {
+			claims, ok := h.StreamTickets.Redeem(value)
+			if !ok {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "無效、已使用或已過期的票證"})
+				return
+			}
+			c.Set(ctxClaims, claims)
+			return
+		}
+
+		token := bearerToken(c)
+		if token == "" {
+			c.Header("WWW-Authenticate", `Bearer realm="vpp"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "缺少認證權杖"})
+			return
+		}
+
+		claims, err := h.Tokens.Verify(token)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="vpp", error="invalid_token"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.Set(ctxClaims, claims)
+	}
+}
+
+// RequirePermission 須至少在一個場站具有該權限，置於 RequireUser 之後
+// 個別場站的權限由各處理器檢查
+func (h *Handler) RequirePermission(perm auth.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.authorizeAny(c, perm)
+	}
+}
+
+// bearerToken 從 Authorization 標頭取出權杖，即時推送請求另接受 access_token 參數（建議改用票證，避免權杖出現在網址中）
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	if isStreamRequest(c) {
+		return c.Query("access_token")
+	}
+	return ""
+}
+
+// isStreamRequest 檢查是否為 SSE 或 WebSocket 請求
+func isStreamRequest(c *gin.Context) bool {
+	if c.Request.Method != http.MethodGet {
+		return false
+	}
+	return strings.EqualFold(c.GetHeader("Upgrade"), "websocket") ||
+		strings.Contains(c.GetHeader("Accept"), "text/event-stream")
+}
+
+// siteScope 使用者具有某權限的場站範圍
+type siteScope struct {
+	all   bool
+	sites map[string]bool
+}
+
+// allows 檢查場站是否在範圍內
+func (s siteScope) allows(siteID string) bool {
+	return s.all || s.sites[siteID]
+}
+
+// list 範圍內的場站，適用所有場站時返回 nil（查詢不限場站）
+func (s siteScope) list() []string {
+	if s.all {
+		return nil
+	}
+	sites := make([]string, 0, len(s.sites))
+	for _, site := range config.SiteIDs() {
+		if s.sites[site] {
+			sites = append(sites, site)
+		}
+	}
+	return sites
+}
+
+// scope 返回使用者具有該權限的場站範圍，認證關閉時適用所有場站
+func (h *Handler) scope(c *gin.Context, perm auth.Permission) siteScope {
+	if !h.AuthEnabled {
+		return siteScope{all: true}
+	}
+	claims := userClaims(c)
+	if claims == nil {
+		return siteScope{}
+	}
+
+	all, sites := claims.SiteScope(perm)
+	scope := siteScope{all: all, sites: make(map[string]bool, len(sites))}
+	for _, site := range sites {
+		scope.sites[site] = true
+	}
+	return scope
+}
+
+// authorizeSite 檢查使用者具有該場站的權限，不允許時回應 403；檢查的場站記入稽核記錄
+func (h *Handler) authorizeSite(c *gin.Context, perm auth.Permission, siteID string) bool {
+	addAuditSite(c, siteID)
+	if h.scope(c, perm).allows(siteID) {
+		return true
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "無權存取此場站", "site_id": siteID, "permission": perm})
+	return false
+}
+
+// authorizeAll 檢查使用者具有所有場站的權限（跨場站的全域操作），不允許時回應 403
+func (h *Handler) authorizeAll(c *gin.Context, perm auth.Permission) bool {
+	if h.scope(c, perm).all {
+		return true
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "權限不足，須具有所有場站的權限", "permission": perm})
+	return false
+}
+
+// authorizeAny 檢查使用者至少在一個場站具有該權限（與場站無關的數據），不允許時回應 403
+func (h *Handler) authorizeAny(c *gin.Context, perm auth.Permission) bool {
+	scope := h.scope(c, perm)
+	if scope.all || len(scope.sites) > 0 {
+		return true
+	}
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "權限不足", "permission": perm})
+	return false
+}
+
+// userClaims 返回已驗證的使用者權杖聲明，認證關閉時為 nil
+func userClaims(c *gin.Context) *auth.Claims {
+	if v, ok := c.Get(ctxClaims); ok {
+		return v.(*auth.Claims)
+	}
+	return nil
+}
+
+// deviceKey 返回已驗證的 API 金鑰，認證關閉時為 nil
+func deviceKey(c *gin.Context) *models.DeviceAPIKey {
+	if v, ok := c.Get(ctxDeviceKey); ok {
+		return v.(*models.DeviceAPIKey)
+	}
+	return nil
+}
+
+// authorizeDeviceSite 檢查 API 金鑰可寫入該場站，不允許時回應 403；檢查的場站記入稽核記錄
+func authorizeDeviceSite(c *gin.Context, siteID string) bool {
+	addAuditSite(c, siteID)
+	key := deviceKey(c)
+	if key == nil || key.SiteID == siteID {
+		return true
+	}
+	c.JSON(http.StatusForbidden, gin.H{"error": "API 金鑰無權寫入此場站", "site_id": siteID})
+	return false
+}
+
+// authorizeEnvelope 檢查上傳的設備與每筆記錄的場站皆在 API 金鑰的範圍內，未填設備ID時以金鑰的設備補上
+func authorizeEnvelope(c *gin.Context, env *telemetry.Envelope) bool {
+	key := deviceKey(c)
+	if key == nil {
+		return true
+	}
+
+	if env.DeviceID == "" {
+		env.DeviceID = key.DeviceID
+	} else if env.DeviceID != key.DeviceID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "API 金鑰不屬於此設備", "device_id": env.DeviceID})
+		return false
+	}
+
+	for _, rec := range env.Records {
+		siteID := rec.SiteID
+		if siteID == "" {
+			siteID = env.SiteID
+		}
+		// 缺少場站的記錄由欄位驗證拒絕
+		if siteID != "" && !authorizeDeviceSite(c, siteID) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,389 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"vpp-go/internal/auth"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeDB 不需資料庫的測試連線，查詢結果由 rows 依查詢內容決定，並記錄每次查詢的參數
+type fakeDB struct {
+	rows func(query string, args []driver.Value) [][]driver.Value
+
+	mu      sync.Mutex
+	queries []fakeQuery
+}
+
+// fakeQuery 已執行的查詢
+type fakeQuery struct {
+	query string
+	args  []driver.Value
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return fakeConn{f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return nil }
+
+// find 返回查詢內容包含 keyword 的查詢
+func (f *fakeDB) find(keyword string) []fakeQuery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var found []fakeQuery
+	for _, q := range f.queries {
+		if strings.Contains(q.query, keyword) {
+			found = append(found, q)
+		}
+	}
+	return found
+}
+
+func (f *fakeDB) record(query string, named []driver.NamedValue) []driver.Value {
+	args := make([]driver.Value, len(named))
+	for i, v := range named {
+		args[i] = v.Value
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queries = append(f.queries, fakeQuery{query: query, args: args})
+	return args
+}
+
+// fakeConn 只支援不經 Prepare 的查詢及寫入
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(string) (driver.Stmt, error) { return nil, errors.New("不支援 Prepare") }
+func (c fakeConn) Close() error                        { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)           { return nil, errors.New("不支援交易") }
+
+func (c fakeConn) QueryContext(_ context.Context, query string, named []driver.NamedValue) (driver.Rows, error) {
+	args := c.db.record(query, named)
+	var rows [][]driver.Value
+	if c.db.rows != nil {
+		rows = c.db.rows(query, args)
+	}
+	return &fakeRows{rows: rows}, nil
+}
+
+func (c fakeConn) ExecContext(_ context.Context, query string, named []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query, named)
+	return driver.RowsAffected(1), nil
+}
+
+// fakeRows 預設的資料列，欄位數取自第一列
+type fakeRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	n := 1
+	if len(r.rows) > 0 {
+		n = len(r.rows[0])
+	}
+	return make([]string, n)
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+// testSecret 測試用的 JWT 簽章金鑰
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// 測試使用者，各自限定於不同場站
+var (
+	viewerNorth     = auth.Claims{Subject: "viewer", Grants: []auth.Grant{{Role: auth.RoleViewer, Sites: []string{"north"}}}}
+	viewerTwoSites  = auth.Claims{Subject: "viewer2", Grants: []auth.Grant{{Role: auth.RoleViewer, Sites: []string{"north", "central"}}}}
+	operatorCentral = auth.Claims{Subject: "operator", Grants: []auth.Grant{{Role: auth.RoleOperator, Sites: []string{"central"}}}}
+	traderSouth     = auth.Claims{Subject: "trader", Grants: []auth.Grant{{Role: auth.RoleTrader, Sites: []string{"south"}}}}
+	admin           = auth.Claims{Subject: "admin", Roles: []string{auth.RoleAdmin}}
+)
+
+// deviceKeys 測試用的閘道器 API 金鑰，金鑰只屬於 north 的 rpi-north-01
+var deviceKeys = map[string][]driver.Value{
+	auth.HashAPIKey("key-north"): {int64(1), "rpi-north-01", "north", "北區閘道器", "vpp_nort", auth.HashAPIKey("key-north"), time.Now(), nil, nil},
+}
+
+// latestRows 三個場站各一筆的最新數據
+func latestRows(query string, args []driver.Value) [][]driver.Value {
+	now := time.Now()
+	var rows [][]driver.Value
+	for i, site := range []string{"central", "north", "south"} {
+		id := int64(i + 1)
+		switch {
+		case strings.Contains(query, "FROM solar_data"):
+			rows = append(rows, []driver.Value{id, site, now, 1.0, 2.0, 3.0, 4.0, 5.0, 6.0, 7.0, 8.0, 9.0, 10.0, 11.0})
+		case strings.Contains(query, "FROM load_data"):
+			rows = append(rows, []driver.Value{id, site, now, 100.0})
+		case strings.Contains(query, "FROM storage_data"):
+			rows = append(rows, []driver.Value{id, site, now, 50.0, 99.0, 0.0, 10.0, 25.0, 200.0, "discharge"})
+		}
+	}
+	if strings.Contains(query, "FROM device_api_keys") {
+		if row, ok := deviceKeys[args[0].(string)]; ok {
+			return [][]driver.Value{row}
+		}
+		return nil
+	}
+	// 指定場站的查詢只返回該場站
+	if strings.Contains(query, "WHERE site_id = $1") && len(rows) > 0 {
+		for _, row := range rows {
+			if row[1] == args[0] {
+				return [][]driver.Value{row}
+			}
+		}
+		return nil
+	}
+	return rows
+}
+
+// authRouter 以正式環境的認證中介層掛上處理器，返回路由及簽發權杖的函數
+func authRouter(t *testing.T) (*gin.Engine, *fakeDB, func(auth.Claims) string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db := &fakeDB{rows: latestRows}
+	tokens, err := auth.NewJWT(testSecret, "vpp", "vpp-api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(sql.OpenDB(db))
+	h.AuthEnabled = true
+	h.Tokens = tokens
+
+	r := gin.New()
+	api := r.Group("/api")
+	upload := api.Group("/upload", h.RequireDevice())
+	upload.POST("", h.UploadData)
+	vpp := api.Group("/vpp", h.RequireUser())
+	vpp.GET("/realdata", h.GetAllRealtimeData)
+	vpp.GET("/realdata/:site_id", h.GetSiteRealtimeData)
+	vpp.GET("/solar/latest", h.GetLatestSolarData)
+	vpp.GET("/solar/history", h.GetSolarHistory)
+	vpp.GET("/load/latest", h.GetLatestLoadData)
+	vpp.GET("/storage/latest", h.GetLatestStorageData)
+	vpp.POST("/dispatch", h.CreateDispatch)
+	market := api.Group("/market", h.RequireUser())
+	market.POST("/revenue", h.CalculateRevenue)
+	market.GET("/bid-plan", h.ListBidPlans)
+	devices := api.Group("/devices/:device_id", h.RequireDevice())
+	devices.GET("/commands", h.PollDeviceCommands)
+	adminGroup := api.Group("/admin", h.RequireUser(), h.RequirePermission(auth.PermAdmin))
+	adminGroup.GET("/api-keys", h.ListAPIKeys)
+
+	sign := func(claims auth.Claims) string {
+		claims.ExpiresAt = time.Now().Add(time.Hour).Unix()
+		token, err := tokens.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	return r, db, sign
+}
+
+// serve 以權杖發出請求，token 為空時不帶 Authorization 標頭
+func serve(r *gin.Engine, method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUserSiteAuthorization(t *testing.T) {
+	r, _, sign := authRouter(t)
+	dispatchNorth := `{"schedules": [{"site_id": "north"}]}`
+
+	tests := []struct {
+		name   string
+		user   *auth.Claims
+		method string
+		path   string
+		body   string
+		code   int
+	}{
+		{"未帶權杖", nil, http.MethodGet, "/api/vpp/realdata/north", "", http.StatusUnauthorized},
+		{"查詢者查詢所屬場站", &viewerNorth, http.MethodGet, "/api/vpp/realdata/north", "", http.StatusOK},
+		{"查詢者查詢其他場站", &viewerNorth, http.MethodGet, "/api/vpp/realdata/central", "", http.StatusForbidden},
+		{"查詢者以參數查詢其他場站", &viewerNorth, http.MethodGet, "/api/vpp/solar/latest?site_id=south", "", http.StatusForbidden},
+		{"查詢者查詢其他場站歷史", &viewerNorth, http.MethodGet, "/api/vpp/solar/history?site_id=central", "", http.StatusForbidden},
+		{"查詢者不可派遣", &viewerNorth, http.MethodPost, "/api/vpp/dispatch", dispatchNorth, http.StatusForbidden},
+		{"調度員查詢所屬場站", &operatorCentral, http.MethodGet, "/api/vpp/solar/latest?site_id=central", "", http.StatusOK},
+		{"調度員派遣其他場站", &operatorCentral, http.MethodPost, "/api/vpp/dispatch", dispatchNorth, http.StatusForbidden},
+		{"調度員不可試算收益", &operatorCentral, http.MethodPost, "/api/market/revenue", `{}`, http.StatusForbidden},
+		{"交易員查詢投標計畫", &traderSouth, http.MethodGet, "/api/market/bid-plan", "", http.StatusOK},
+		{"交易員查詢其他場站", &traderSouth, http.MethodGet, "/api/vpp/realdata/north", "", http.StatusForbidden},
+		{"查詢者不可查詢投標計畫", &viewerNorth, http.MethodGet, "/api/market/bid-plan", "", http.StatusForbidden},
+		{"非管理員不可管理金鑰", &traderSouth, http.MethodGet, "/api/admin/api-keys", "", http.StatusForbidden},
+		{"管理員查詢任一場站", &admin, http.MethodGet, "/api/vpp/realdata/south", "", http.StatusOK},
+		{"管理員管理金鑰", &admin, http.MethodGet, "/api/admin/api-keys", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		token := ""
+		if tt.user != nil {
+			token = sign(*tt.user)
+		}
+		w := serve(r, tt.method, tt.path, token, tt.body)
+		if w.Code != tt.code {
+			t.Errorf("%s: 狀態 %d、%s, 預期 %d", tt.name, w.Code, w.Body.String(), tt.code)
+		}
+	}
+}
+
+// siteIDs 返回 JSON 陣列中每筆數據的 site_id
+func siteIDs(t *testing.T, raw json.RawMessage) []string {
+	t.Helper()
+	var list []struct {
+		SiteID string `json:"site_id"`
+	}
+	if err := json.Unmarshal(raw, &list); err != nil {
+		t.Fatalf("無法解析 %s: %v", raw, err)
+	}
+	ids := make([]string, len(list))
+	for i, data := range list {
+		ids[i] = data.SiteID
+	}
+	return ids
+}
+
+func TestUserListFiltered(t *testing.T) {
+	r, _, sign := authRouter(t)
+
+	tests := []struct {
+		name string
+		user auth.Claims
+		want []string
+	}{
+		{"單一場站", viewerNorth, []string{"north"}},
+		{"兩個場站", viewerTwoSites, []string{"central", "north"}},
+		{"交易員的查詢權限", traderSouth, []string{"south"}},
+		{"所有場站", admin, []string{"central", "north", "south"}},
+	}
+
+	for _, tt := range tests {
+		token := sign(tt.user)
+		for _, path := range []string{"/api/vpp/solar/latest", "/api/vpp/load/latest", "/api/vpp/storage/latest"} {
+			w := serve(r, http.MethodGet, path, token, "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("%s %s: 狀態 %d、%s", tt.name, path, w.Code, w.Body.String())
+			}
+			if got := siteIDs(t, w.Body.Bytes()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s %s: 場站 %v, 預期 %v", tt.name, path, got, tt.want)
+			}
+		}
+
+		w := serve(r, http.MethodGet, "/api/vpp/realdata", token, "")
+		var body map[string]json.RawMessage
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s realdata: %v", tt.name, err)
+		}
+		for _, metric := range []string{"solar", "load", "storage"} {
+			if got := siteIDs(t, body[metric]); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s realdata %s: 場站 %v, 預期 %v", tt.name, metric, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestBidPlanListScope(t *testing.T) {
+	r, db, sign := authRouter(t)
+
+	// 可交易的場站作為查詢條件，所有場站時不限制
+	tests := []struct {
+		user  auth.Claims
+		sites driver.Value
+	}{
+		{traderSouth, `{"south"}`},
+		{auth.Claims{Subject: "trader2", Grants: []auth.Grant{{Role: auth.RoleTrader, Sites: []string{"south", "north"}}}}, `{"north","south"}`},
+		{admin, nil},
+	}
+
+	for _, tt := range tests {
+		before := len(db.find("FROM bid_plans"))
+		w := serve(r, http.MethodGet, "/api/market/bid-plan", sign(tt.user), "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: 狀態 %d、%s", tt.user.Subject, w.Code, w.Body.String())
+		}
+		queries := db.find("FROM bid_plans")
+		if len(queries) != before+1 {
+			t.Fatalf("%s: 未查詢投標計畫", tt.user.Subject)
+		}
+		if got := queries[len(queries)-1].args[3]; got != tt.sites {
+			t.Errorf("%s: 場站條件 %v, 預期 %v", tt.user.Subject, got, tt.sites)
+		}
+	}
+}
+
+func TestDeviceUploadSite(t *testing.T) {
+	r, db, _ := authRouter(t)
+	upload := func(key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/upload", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(apiKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+	record := `{"type": "load", "timestamp": "2024-06-01T00:00:00Z", "measurements": {"load_value": {"value": 1, "unit": "kW"}}}`
+
+	tests := []struct {
+		name string
+		key  string
+		body string
+		code int
+	}{
+		{"缺少金鑰", "", `{"site_id": "north", "data": {"value": 1}}`, http.StatusUnauthorized},
+		{"無效金鑰", "key-other", `{"site_id": "north", "data": {"value": 1}}`, http.StatusUnauthorized},
+		{"舊格式寫入其他場站", "key-north", `{"site_id": "central", "data": {"value": 1}}`, http.StatusForbidden},
+		{"版本化格式寫入其他場站", "key-north", `{"schema_version": 2, "site_id": "south", "records": [` + record + `]}`, http.StatusForbidden},
+		{"記錄指定其他場站", "key-north", `{"schema_version": 2, "site_id": "north", "records": [` + strings.Replace(record, `{"type"`, `{"site_id": "central", "type"`, 1) + `]}`, http.StatusForbidden},
+		{"冒用其他設備", "key-north", `{"schema_version": 2, "site_id": "north", "device_id": "rpi-central-01", "records": [` + record + `]}`, http.StatusForbidden},
+		{"舊格式寫入所屬場站", "key-north", `{"site_id": "north", "data": {"value": 1}}`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		w := upload(tt.key, tt.body)
+		if w.Code != tt.code {
+			t.Errorf("%s: 狀態 %d、%s, 預期 %d", tt.name, w.Code, w.Body.String(), tt.code)
+		}
+	}
+
+	// 只有所屬場站的上傳寫入資料庫
+	inserts := db.find("INSERT INTO stu")
+	if len(inserts) != 1 || inserts[0].args[0] != "north" {
+		t.Errorf("寫入 %v, 預期只寫入 north", inserts)
+	}
+
+	// 閘道器不可輪詢其他設備的指令
+	req := httptest.NewRequest(http.MethodGet, "/api/devices/rpi-central-01/commands", nil)
+	req.Header.Set(apiKeyHeader, "key-north")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("輪詢其他設備: 狀態 %d, 預期 403", w.Code)
+	}
+}
@@ -0,0 +1,266 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/config"
+	"vpp-go/internal/dispatch"
+	"vpp-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPollWait = 25 * time.Second
+	maxPollWait     = 60 * time.Second
+	// pollRecheck 長輪詢期間重新查詢的間隔（其他實例建立的指令不會喚醒本實例）
+	pollRecheck  = 2 * time.Second
+	maxDeviceID  = 100
+	maxClaimSize = 50
+)
+
+// 閘道器指令種類
+const (
+	kindDispatch = "dispatch"
+	kindDevice   = "device"
+)
+
+// DeviceCommandItem 閘道器領取的指令
+type DeviceCommandItem struct {
+	Kind    string      `json:"kind"` // dispatch 或 device
+	ID      int64       `json:"id"`
+	Type    string      `json:"type"`
+	Payload interface{} `json:"payload"`
+}
+
+// AckRequest 閘道器回報的指令執行結果
+type AckRequest struct {
+	Results []AckResult `json:"results" binding:"required"`
+}
+
+// AckResult 單筆指令的執行結果
+type AckResult struct {
+	Kind    string `json:"kind"`
+	ID      int64  `json:"id"`
+	Status  string `json:"status"` // acknowledged 或 failed
+	Message string `json:"message"`
+}
+
+// EnqueueDeviceCommandRequest 建立閘道器設定類指令
+type EnqueueDeviceCommandRequest struct {
+	SiteID  string          `json:"site_id"`
+	Type    string          `json:"type" binding:"required"`
+	Payload json.RawMessage `json:"payload"`
+	// ExpiresAt 可選，逾期未確認即不再送出，未帶時為建立後 DEVICE_COMMAND_TTL
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// PollDeviceCommands 閘道器以長輪詢領取指令
+// 有待送指令時立即返回，否則等待至有新指令或逾時（wait 秒，預設 25，最多 60）
+// 帶 site_id 時一併領取該場站未指定閘道器的派遣指令
+func (h *Handler) PollDeviceCommands(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" || len(deviceID) > maxDeviceID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的設備ID"})
+		return
+	}
+
+	siteID := c.Query("site_id")
+	if siteID != "" && !config.IsValidSite(siteID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
+		return
+	}
+	// 以 API 金鑰認證時只能領取金鑰所屬場站的派遣指令
+	if key := deviceKey(c); key != nil && siteID == "" {
+		siteID = key.SiteID
+	} else if !authorizeDeviceSite(c, siteID) {
+		return
+	}
+
+	wait := defaultPollWait
+	if waitStr := c.Query("wait"); waitStr != "" {
+		seconds, err := strconv.Atoi(waitStr)
+		if err != nil || seconds < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的wait參數"})
+			return
+		}
+		wait = time.Duration(seconds) * time.Second
+		if wait > maxPollWait {
+			wait = maxPollWait
+		}
+	}
+
+	deadline := time.NewTimer(wait)
+	defer deadline.Stop()
+	recheck := time.NewTicker(pollRecheck)
+	defer recheck.Stop()
+
+	for {
+		// 先取得通知通道再查詢，避免查詢後才建立的指令漏掉喚醒
+		updates := h.Dispatcher.Updates()
+
+		items, err := h.claimDeviceCommands(deviceID, siteID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if len(items) > 0 {
+			c.JSON(http.StatusOK, gin.H{"device_id": deviceID, "commands": items})
+			return
+		}
+
+		select {
+		case <-updates:
+		case <-recheck.C:
+		case <-deadline.C:
+			c.JSON(http.StatusOK, gin.H{"device_id": deviceID, "commands": []DeviceCommandItem{}})
+			return
+		case <-h.Done:
+			c.JSON(http.StatusOK, gin.H{"device_id": deviceID, "commands": []DeviceCommandItem{}})
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// claimDeviceCommands 領取閘道器的派遣與設定類指令
+func (h *Handler) claimDeviceCommands(deviceID, siteID string) ([]DeviceCommandItem, error) {
+	dispatches, err := h.Dispatcher.ClaimForDevice(deviceID, siteID)
+	if err != nil {
+		return nil, err
+	}
+	commands, err := h.DeviceCommandModel.Claim(deviceID, time.Now(), maxClaimSize)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]DeviceCommandItem, 0, len(dispatches)+len(commands))
+	for i := range dispatches {
+		items = append(items, DeviceCommandItem{
+			Kind:    kindDispatch,
+			ID:      dispatches[i].ID,
+			Type:    "setpoint",
+			Payload: dispatch.NewCommandPayload(&dispatches[i]),
+		})
+	}
+	for _, cmd := range commands {
+		items = append(items, DeviceCommandItem{
+			Kind:    kindDevice,
+			ID:      cmd.ID,
+			Type:    cmd.Type,
+			Payload: cmd.Payload,
+		})
+	}
+	return items, nil
+}
+
+// AckDeviceCommands 閘道器回報指令執行結果
+func (h *Handler) AckDeviceCommands(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" || len(deviceID) > maxDeviceID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的設備ID"})
+		return
+	}
+
+	var req AckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的請求數據"})
+		return
+	}
+
+	results := make([]gin.H, 0, len(req.Results))
+	for _, r := range req.Results {
+		updated, err := h.ackDeviceCommand(deviceID, r)
+		result := gin.H{"kind": r.Kind, "id": r.ID, "updated": updated}
+		if err != nil {
+			result["error"] = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"device_id": deviceID,
+		"results":   results,
+	})
+}
+
+// ackDeviceCommand 更新單筆指令的確認狀態，指令不屬於該閘道器或狀態已變更時不更新
+func (h *Handler) ackDeviceCommand(deviceID string, r AckResult) (bool, error) {
+	switch r.Kind {
+	case kindDispatch:
+		switch r.Status {
+		case models.DispatchAcknowledged:
+			return h.DispatchModel.AcknowledgeFromDevice(r.ID, deviceID, models.DispatchAcknowledged, "")
+		case models.DispatchFailed:
+			return h.DispatchModel.AcknowledgeFromDevice(r.ID, deviceID, models.DispatchFailed, "閘道器回報: "+r.Message)
+		}
+	case kindDevice:
+		switch r.Status {
+		case models.DeviceCommandAcknowledged, models.DeviceCommandFailed:
+			return h.DeviceCommandModel.Acknowledge(r.ID, deviceID, r.Status, r.Message)
+		}
+	default:
+		return false, errors.New("無效的指令種類")
+	}
+	return false, errors.New("無效的狀態，須為 acknowledged 或 failed")
+}
+
+// EnqueueDeviceCommand 建立閘道器設定類指令，於閘道器下次輪詢時送出
+func (h *Handler) EnqueueDeviceCommand(c *gin.Context) {
+	deviceID := c.Param("device_id")
+	if deviceID == "" || len(deviceID) > maxDeviceID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的設備ID"})
+		return
+	}
+
+	var req EnqueueDeviceCommandRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的請求數據"})
+		return
+	}
+	if req.SiteID != "" && !config.IsValidSite(req.SiteID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
+		return
+	}
+	// 未指定場站的指令不限場站，須具有所有場站的管理權限
+	if req.SiteID != "" && !h.authorizeSite(c, auth.PermAdmin, req.SiteID) {
+		return
+	}
+	if req.SiteID == "" && !h.authorizeAll(c, auth.PermAdmin) {
+		return
+	}
+	if len(req.Payload) > 0 && !json.Valid(req.Payload) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的payload"})
+		return
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(h.Dispatcher.CommandTTL)
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(now) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "expires_at 須晚於現在"})
+			return
+		}
+		expiresAt = *req.ExpiresAt
+	}
+
+	cmd := &models.DeviceCommand{
+		DeviceID:  deviceID,
+		SiteID:    req.SiteID,
+		Type:      req.Type,
+		Payload:   req.Payload,
+		ExpiresAt: expiresAt,
+	}
+	if err := h.DeviceCommandModel.Insert(cmd); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.Dispatcher.Notify()
+
+	c.JSON(http.StatusCreated, cmd)
+}
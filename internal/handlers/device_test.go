@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"vpp-go/internal/dispatch"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEnqueueDeviceCommandExpiresAt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{Dispatcher: &dispatch.Dispatcher{CommandTTL: time.Hour}}
+	r := gin.New()
+	r.POST("/devices/:device_id/commands", h.EnqueueDeviceCommand)
+
+	// 已過期的到期時間在寫入前拒絕
+	past := time.Now().Add(-time.Minute).Format(time.RFC3339)
+	body := `{"site_id": "north", "type": "set_interval", "expires_at": "` + past + `"}`
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/devices/rpi-north-01/commands", strings.NewReader(body)))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "expires_at") {
+		t.Errorf("狀態 %d、%s, 預期 400", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/config"
+	"vpp-go/internal/dispatch"
+	"vpp-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CreateDispatch 建立派遣指令
+// 請求包含各場站的功率設定點排程，每個時段展開為一筆指令，須具有每個場站的派遣權限
+func (h *Handler) CreateDispatch(c *gin.Context) {
+	var req dispatch.Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的請求數據"})
+		return
+	}
+
+	// 先檢查派遣權限再驗證，避免無權限的使用者從驗證錯誤得知其他場站的狀態
+	// 缺少場站ID的排程由驗證拒絕
+	for _, schedule := range req.Schedules {
+		if schedule.SiteID != "" && !h.authorizeSite(c, auth.PermDispatch, schedule.SiteID) {
+			return
+		}
+	}
+
+	// 指定的閘道器須登記於排程的場站，避免派遣到其他場站的設備
+	var deviceIDs []string
+	for _, schedule := range req.Schedules {
+		if schedule.DeviceID != "" {
+			deviceIDs = append(deviceIDs, schedule.DeviceID)
+		}
+	}
+	var deviceSites map[string][]string
+	if len(deviceIDs) > 0 {
+		sites, err := h.APIKeyModel.DeviceSites(deviceIDs)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		deviceSites = sites
+	}
+
+	commands, err := dispatch.Plan(&req, time.Now(), deviceSites)
+	if err != nil {
+		var validationErr *dispatch.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "派遣請求驗證失敗", "details": validationErr.Errors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.Dispatcher.Submit(commands); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"dispatch_id": commands[0].DispatchID,
+		"count":       len(commands),
+		"commands":    commands,
+	})
+}
+
+// ListDispatches 查詢派遣指令，未指定場站時只返回使用者可查詢的場站
+func (h *Handler) ListDispatches(c *gin.Context) {
+	siteID := c.Query("site_id")
+	if siteID != "" && !config.IsValidSite(siteID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
+		return
+	}
+	if siteID != "" && !h.authorizeSite(c, auth.PermRead, siteID) {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的limit參數"})
+		return
+	}
+
+	dataList, err := h.DispatchModel.List(models.DispatchFilter{
+		DispatchID: c.Query("dispatch_id"),
+		SiteID:     siteID,
+		Sites:      h.scope(c, auth.PermRead).list(),
+		Status:     c.Query("status"),
+		Limit:      limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(dataList),
+		"data":  dataList,
+	})
+}
+
+// GetDispatch 獲取單筆派遣指令
+func (h *Handler) GetDispatch(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的指令ID"})
+		return
+	}
+
+	data, err := h.DispatchModel.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if data == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到指令"})
+		return
+	}
+	if !h.authorizeSite(c, auth.PermRead, data.SiteID) {
+		return
+	}
+
+	c.JSON(http.StatusOK, data)
+}
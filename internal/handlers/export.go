@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"vpp-go/internal/export"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportFunc 依序讀取要匯出的數據，每筆呼叫 write
+type exportFunc func(write func(interface{}) error) error
+
+// exportHistory 以 CSV、Excel 或 Parquet 串流匯出歷史數據，欄位名稱同 JSON
+// 第一筆數據讀出後才送出回應標頭，查詢失敗時仍可返回 JSON 錯誤；
+// 開始傳送後發生錯誤只能中斷，檔案會不完整
+func (h *Handler) exportHistory(c *gin.Context, format, name string, sample interface{}, location *time.Location, each exportFunc) {
+	var w *export.Writer
+	start := func() error {
+		c.Header("Content-Type", export.ContentType(format))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+		c.Status(http.StatusOK)
+
+		var err error
+		w, err = export.NewWriter(c.Writer, format, sample, location)
+		return err
+	}
+
+	err := each(func(v interface{}) error {
+		if w == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return w.Write(v)
+	})
+	if err != nil {
+		if w == nil && !c.Writer.Written() {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("匯出 %s 中斷: %v\n", name, err)
+		abortExport(c)
+		return
+	}
+
+	// 無數據時仍輸出只有標題的檔案
+	if w == nil {
+		if err := start(); err != nil {
+			log.Printf("匯出 %s 失敗: %v\n", name, err)
+			return
+		}
+	}
+	if err := w.Close(); err != nil {
+		log.Printf("匯出 %s 失敗: %v\n", name, err)
+		abortExport(c)
+	}
+}
+
+// abortExport 已開始傳送後失敗時直接關閉連線，不送出分塊傳輸的結尾
+// 用戶端因此收到讀取錯誤，而不是狀態 200 的截斷檔案
+func abortExport(c *gin.Context) {
+	conn, _, err := c.Writer.Hijack()
+	if err != nil {
+		log.Printf("無法中斷匯出連線: %v\n", err)
+		return
+	}
+	conn.Close()
+}
+
+// exportName 匯出檔名（不含副檔名）
+func exportName(kind, siteID string, startDate, endDate time.Time) string {
+	if siteID != "" {
+		kind += "_" + siteID
+	}
+	return kind + "_" + startDate.Format("20060102") + "_" + endDate.Format("20060102")
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportRow 測試用的匯出資料
+type exportRow struct {
+	ID   int64     `json:"id"`
+	Time time.Time `json:"time"`
+}
+
+// exportServer 匯出 rows 筆資料後返回 failure 的伺服器
+func exportServer(rows int, failure error) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := &Handler{}
+	r.GET("/export", func(c *gin.Context) {
+		h.exportHistory(c, "csv", "test", exportRow{}, nil, func(write func(interface{}) error) error {
+			for i := 0; i < rows; i++ {
+				if err := write(exportRow{ID: int64(i), Time: time.Unix(int64(i), 0)}); err != nil {
+					return err
+				}
+			}
+			return failure
+		})
+	})
+	return httptest.NewServer(r)
+}
+
+func TestExportHistory(t *testing.T) {
+	srv := exportServer(5000, nil)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/export")
+	if err != nil {
+		t.Fatalf("請求失敗: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Disposition") != `attachment; filename="test.csv"` {
+		t.Errorf("狀態 %d、Content-Disposition %q", resp.StatusCode, resp.Header.Get("Content-Disposition"))
+	}
+	rows, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil || len(rows) != 5001 {
+		t.Errorf("讀出 %d 列, %v, 預期含標題 5001 列", len(rows), err)
+	}
+}
+
+func TestExportHistoryAbort(t *testing.T) {
+	// 開始匯出後失敗時，用戶端須收到錯誤而非完整的 200 回應
+	// 資料仍在緩衝時連線關閉於回應標頭之前，已送出部分資料時則在讀取主體時中斷
+	tests := []struct {
+		rows    int
+		started bool
+	}{
+		{1, false},
+		{5000, true},
+	}
+
+	for _, tt := range tests {
+		srv := exportServer(tt.rows, errors.New("資料庫連線中斷"))
+
+		resp, err := http.Get(srv.URL + "/export")
+		if err == nil {
+			_, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK || !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("%d 筆後中斷: 狀態 %d、讀取錯誤 %v, 預期 unexpected EOF", tt.rows, resp.StatusCode, err)
+			}
+		}
+		srv.Close()
+
+		if err == nil {
+			t.Errorf("%d 筆後中斷: 用戶端未收到錯誤", tt.rows)
+		}
+		if started := resp != nil; started != tt.started {
+			t.Errorf("%d 筆後中斷: 收到回應標頭 = %v, 預期 %v（錯誤 %v）", tt.rows, started, tt.started, err)
+		}
+	}
+}
+
+func TestExportHistoryErrorBeforeStart(t *testing.T) {
+	// 尚未寫出任何資料時以 JSON 回應錯誤
+	srv := exportServer(0, errors.New("查詢失敗"))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/export")
+	if err != nil {
+		t.Fatalf("請求失敗: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("狀態 = %d, 預期 500", resp.StatusCode)
+	}
+}
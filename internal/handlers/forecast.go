@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/config"
+	"vpp-go/internal/forecast"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultMetricsDays = 7
+	maxMetricsDays     = 90
+	// metricsMinLead 評估準確度時只採用目標時間前至少 12 小時發布的預測（日前預測）
+	metricsMinLead = 12 * time.Hour
+)
+
+// GetSolarForecast 獲取場站最新的太陽能預測（未來 48 小時，每 15 分鐘）
+// 並以過去 metrics_days 天的實際值計算日前預測的 MAPE、RMSE
+func (h *Handler) GetSolarForecast(c *gin.Context) {
+	siteID := c.Query("site_id")
+	if siteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少場站ID參數"})
+		return
+	}
+	if !config.IsValidSite(siteID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
+		return
+	}
+	if !h.authorizeSite(c, auth.PermRead, siteID) {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("metrics_days", strconv.Itoa(defaultMetricsDays)))
+	if err != nil || days <= 0 || days > maxMetricsDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的metrics_days參數（1-90）"})
+		return
+	}
+
+	run, err := h.SolarForecastModel.GetLatestRun(siteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if run == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "該場站尚無太陽能預測"})
+		return
+	}
+
+	now := time.Now()
+	forecasts, err := h.SolarForecastModel.GetForecasts(run.ID, now.Truncate(forecast.Interval))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	end := now.Truncate(forecast.Interval)
+	start := end.AddDate(0, 0, -days)
+	pairs, err := h.SolarForecastModel.GetForecastActuals(siteID, start, end, metricsMinLead)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"site_id":   siteID,
+		"run":       run,
+		"count":     len(forecasts),
+		"forecasts": forecasts,
+		"accuracy":  forecast.ComputeAccuracy(pairs, start, end),
+	})
+}
+
+// GetLoadForecast 獲取場站最新的負載預測（未來 48 小時，每 15 分鐘）
+// 並以過去 metrics_days 天的實際值計算日前預測的 MAPE、RMSE
+func (h *Handler) GetLoadForecast(c *gin.Context) {
+	siteID := c.Query("site_id")
+	if siteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少場站ID參數"})
+		return
+	}
+	if !config.IsValidSite(siteID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
+		return
+	}
+	if !h.authorizeSite(c, auth.PermRead, siteID) {
+		return
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("metrics_days", strconv.Itoa(defaultMetricsDays)))
+	if err != nil || days <= 0 || days > maxMetricsDays {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的metrics_days參數（1-90）"})
+		return
+	}
+
+	run, err := h.LoadForecastModel.GetLatestRun(siteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if run == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "該場站尚無負載預測"})
+		return
+	}
+
+	now := time.Now()
+	forecasts, err := h.LoadForecastModel.GetForecasts(run.ID, now.Truncate(forecast.Interval))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	end := now.Truncate(forecast.Interval)
+	start := end.AddDate(0, 0, -days)
+	pairs, err := h.LoadForecastModel.GetForecastActuals(siteID, start, end, metricsMinLead)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"site_id":   siteID,
+		"run":       run,
+		"count":     len(forecasts),
+		"forecasts": forecasts,
+		"accuracy":  forecast.ComputeAccuracy(pairs, start, end),
+	})
+}
@@ -2,23 +2,77 @@ package handlers
 
 import (
 	"database/sql"
+	"time"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/collectors"
+	"vpp-go/internal/config"
+	"vpp-go/internal/dispatch"
+	"vpp-go/internal/events"
+	"vpp-go/internal/market"
 	"vpp-go/internal/models"
+	"vpp-go/internal/scheduler"
+	"vpp-go/internal/stream"
+	"vpp-go/internal/telemetry"
 )
 
 // Handler 處理器結構
 type Handler struct {
-	DB            *sql.DB
-	SolarModel    *models.SolarDataModel
-	LoadModel     *models.LoadDataModel
-	TaipowerModel *models.TaipowerReserveModel
+	DB                 *sql.DB
+	SolarModel         *models.SolarDataModel
+	LoadModel          *models.LoadDataModel
+	StorageModel       *models.StorageDataModel
+	TaipowerModel      *models.TaipowerReserveModel
+	IdempotencyModel   *models.IdempotencyModel
+	DispatchModel      *models.DispatchModel
+	DeviceCommandModel *models.DeviceCommandModel
+	BidPlanModel       *models.BidPlanModel
+	SolarForecastModel *models.SolarForecastModel
+	LoadForecastModel  *models.LoadForecastModel
+	APIKeyModel        *models.APIKeyModel
+	AuditModel         *models.AuditModel
+	Ingester           *telemetry.Ingester
+	Scheduler          *scheduler.Scheduler
+	Sites              []config.SiteConfig
+	TaipowerCollector  *collectors.TaipowerCollector
+	Dispatcher         *dispatch.Dispatcher
+	BidPlanner         *market.Planner
+	Events             *events.Bus
+	StreamHub          *stream.Hub
+	// StreamHeartbeat 即時推送無數據時的心跳間隔
+	StreamHeartbeat time.Duration
+	// AllowedOrigins 允許建立 WebSocket 連線的跨來源，* 表示全部，與 CORS 配置相同
+	AllowedOrigins []string
+	// AuthEnabled 為 false 時認證中介層不檢查，僅供本地開發
+	AuthEnabled bool
+	// Tokens 驗證使用者權杖，AuthEnabled 時必須設定
+	Tokens *auth.JWT
+	// StreamTickets 即時推送連線的一次性票證，AuthEnabled 時必須設定
+	StreamTickets *auth.Tickets
+	// Done 服務器關閉時關閉，用於結束長輪詢
+	Done <-chan struct{}
 }
 
 // NewHandler 創建新的處理器
 func NewHandler(db *sql.DB) *Handler {
 	return &Handler{
-		DB:            db,
-		SolarModel:    models.NewSolarDataModel(db),
-		LoadModel:     models.NewLoadDataModel(db),
-		TaipowerModel: models.NewTaipowerReserveModel(db),
+		DB:                 db,
+		SolarModel:         models.NewSolarDataModel(db),
+		LoadModel:          models.NewLoadDataModel(db),
+		StorageModel:       models.NewStorageDataModel(db),
+		TaipowerModel:      models.NewTaipowerReserveModel(db),
+		IdempotencyModel:   models.NewIdempotencyModel(db),
+		DispatchModel:      models.NewDispatchModel(db),
+		DeviceCommandModel: models.NewDeviceCommandModel(db),
+		BidPlanModel:       models.NewBidPlanModel(db),
+		SolarForecastModel: models.NewSolarForecastModel(db),
+		LoadForecastModel:  models.NewLoadForecastModel(db),
+		APIKeyModel:        models.NewAPIKeyModel(db),
+		AuditModel:         models.NewAuditModel(db),
+		Ingester: &telemetry.Ingester{
+			Solar:   models.NewSolarDataModel(db),
+			Load:    models.NewLoadDataModel(db),
+			Storage: models.NewStorageDataModel(db),
+			Raw:     models.NewTelemetryRawModel(db),
+		},
 	}
 }
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+	"vpp-go/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// readyTimeout 就緒檢查的資料庫查詢逾時
+const readyTimeout = 2 * time.Second
+
+// 檢查結果狀態
+const (
+	checkOK       = "ok"
+	checkFailed   = "failed"
+	checkStale    = "stale"
+	checkDisabled = "disabled"
+)
+
+// Healthz 存活檢查，程序可響應即返回 200
+func (h *Handler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": "ok",
+		"time":   time.Now().Format(time.RFC3339),
+	})
+}
+
+// Readyz 就緒檢查：資料庫連線、遷移版本、收集器是否過期
+// 資料庫或遷移版本異常返回 503；收集器過期只標記為 degraded，
+// 避免上游API故障時平台把整個服務移出流量
+func (h *Handler) Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), readyTimeout)
+	defer cancel()
+
+	ready, degraded := true, false
+	checks := gin.H{}
+
+	// 資料庫連線
+	start := time.Now()
+	if err := h.DB.PingContext(ctx); err != nil {
+		ready = false
+		checks["database"] = gin.H{"status": checkFailed, "error": err.Error()}
+	} else {
+		checks["database"] = gin.H{"status": checkOK, "latency": time.Since(start).String()}
+	}
+
+	// 遷移版本
+	current, expected, err := migrationVersions(ctx, h.DB)
+	switch {
+	case err != nil:
+		ready = false
+		checks["migrations"] = gin.H{"status": checkFailed, "error": err.Error()}
+	case current < expected:
+		ready = false
+		checks["migrations"] = gin.H{"status": checkFailed, "current": current, "expected": expected, "error": "資料庫遷移版本落後"}
+	default:
+		checks["migrations"] = gin.H{"status": checkOK, "current": current, "expected": expected}
+	}
+
+	// 收集器新鮮度
+	if h.Scheduler == nil {
+		checks["collectors"] = gin.H{"status": checkDisabled}
+	} else {
+		stale := []string{}
+		for _, job := range h.Scheduler.Status() {
+			if job.Stale {
+				stale = append(stale, job.Name)
+			}
+		}
+		if len(stale) > 0 {
+			degraded = true
+			checks["collectors"] = gin.H{"status": checkStale, "stale": stale}
+		} else {
+			checks["collectors"] = gin.H{"status": checkOK, "stale": stale}
+		}
+	}
+
+	status, code := "ready", http.StatusOK
+	switch {
+	case !ready:
+		status, code = "not_ready", http.StatusServiceUnavailable
+	case degraded:
+		status = "degraded"
+	}
+
+	c.JSON(code, gin.H{
+		"status": status,
+		"checks": checks,
+	})
+}
+
+// migrationVersions 獲取資料庫目前與程式內嵌的最新遷移版本
+func migrationVersions(ctx context.Context, db *sql.DB) (current, expected int, err error) {
+	expected, err = database.LatestMigrationVersion()
+	if err != nil {
+		return 0, 0, err
+	}
+	current, err = database.SchemaVersion(ctx, db)
+	return current, expected, err
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/config"
+	"vpp-go/internal/market"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CalculateRevenue 以台電結清價格試算即時備轉與補充備轉的收益
+// 請求包含各小時的登錄容量與效能級數，依 group_by 彙總為每小時、每日或每月明細
+func (h *Handler) CalculateRevenue(c *gin.Context) {
+	if !h.authorizeAny(c, auth.PermTrade) {
+		return
+	}
+
+	var req market.RevenueRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的請求數據"})
+		return
+	}
+
+	startDate, endDate, err := req.Validate()
+	if err != nil {
+		var validationErr *market.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "收益試算請求驗證失敗", "details": validationErr.Errors})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prices, err := h.TaipowerModel.GetRange(startDate, endDate)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, market.CalculateRevenue(&req, startDate, endDate, prices))
+}
+
+// CreateBidPlan 產生次日 24 小時各商品的投標計畫並保存，須具有每個參與場站的交易權限
+func (h *Handler) CreateBidPlan(c *gin.Context) {
+	var req market.BidPlanRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的請求數據"})
+			return
+		}
+	}
+
+	// 未指定場站時只納入使用者可交易的場站
+	scope := h.scope(c, auth.PermTrade)
+	if len(req.SiteIDs) == 0 && !scope.all {
+		req.SiteIDs = scope.list()
+		if len(req.SiteIDs) == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "權限不足", "permission": auth.PermTrade})
+			return
+		}
+	}
+	for _, siteID := range req.SiteIDs {
+		// 無效的場站ID由請求驗證回報
+		if config.IsValidSite(siteID) && !h.authorizeSite(c, auth.PermTrade, siteID) {
+			return
+		}
+	}
+
+	plan, err := h.BidPlanner.Create(&req, time.Now())
+	if err != nil {
+		var validationErr *market.ValidationError
+		if errors.As(err, &validationErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "投標計畫請求驗證失敗", "details": validationErr.Errors})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, plan)
+}
+
+// ListBidPlans 查詢投標計畫（不含各小時投標量），只返回參與場站皆可交易的計畫
+func (h *Handler) ListBidPlans(c *gin.Context) {
+	if !h.authorizeAny(c, auth.PermTrade) {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "30"))
+	if err != nil || limit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的limit參數"})
+		return
+	}
+
+	startDate := time.Now().AddDate(0, 0, -30)
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		startDate, err = time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的開始日期格式"})
+			return
+		}
+	}
+
+	endDate := time.Now().AddDate(0, 0, 1)
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		endDate, err = time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的結束日期格式"})
+			return
+		}
+	}
+
+	dataList, err := h.BidPlanModel.List(startDate, endDate, limit, h.scope(c, auth.PermTrade).list())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"start_date": startDate.Format("2006-01-02"),
+		"end_date":   endDate.Format("2006-01-02"),
+		"count":      len(dataList),
+		"data":       dataList,
+	})
+}
+
+// GetBidPlan 獲取投標計畫，並以計畫日的實際結清價格比較預期與實際收益
+func (h *Handler) GetBidPlan(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的計畫ID"})
+		return
+	}
+
+	plan, err := h.BidPlanModel.Get(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if plan == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "找不到投標計畫"})
+		return
+	}
+	for _, site := range plan.Sites {
+		if !h.authorizeSite(c, auth.PermTrade, site.SiteID) {
+			return
+		}
+	}
+
+	comparison, err := h.BidPlanner.Compare(plan)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"plan":   plan,
+		"actual": comparison,
+	})
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"vpp-go/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxPageSize 歷史查詢每頁筆數上限，超過時以上限計
+const maxPageSize = 5000
+
+// parsePage 解析歷史查詢的 limit 與 cursor 參數
+func parsePage(c *gin.Context, defaultLimit int) (int, *models.Cursor, error) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLimit)))
+	if err != nil || limit <= 0 {
+		return 0, nil, errors.New("無效的limit參數")
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	var after *models.Cursor
+	if cursor := c.Query("cursor"); cursor != "" {
+		after, err = models.DecodeCursor(cursor)
+		if err != nil {
+			return 0, nil, err
+		}
+	}
+	return limit, after, nil
+}
+
+// nextCursor 將下一頁游標編碼為字串，已是最後一頁時返回 nil
+func nextCursor(next *models.Cursor) *string {
+	if next == nil {
+		return nil
+	}
+	s := next.Encode()
+	return &s
+}
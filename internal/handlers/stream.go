@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/config"
+	"vpp-go/internal/stream"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// sseRetry 建議 SSE 客戶端斷線後重連的等待時間（毫秒）
+	sseRetry = 3000
+	// wsWriteTimeout WebSocket 單次寫入逾時
+	wsWriteTimeout = 10 * time.Second
+)
+
+// 推送的控制訊息種類
+const (
+	streamEventReset     = "reset"     // 無法補送斷線期間的數據，客戶端須重新載入
+	streamEventHeartbeat = "heartbeat" // 心跳
+	streamEventOverflow  = "overflow"  // 客戶端跟不上，連線將中斷
+	streamEventSnapshot  = "snapshot"  // 連線時各場站的最新數據
+)
+
+// streamControl 控制訊息
+type streamControl struct {
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data,omitempty"`
+}
+
+// StreamTelemetry 以 WebSocket 或 Server-Sent Events 推送新寫入的太陽能、負載及儲能數據
+// 參數: site_id、metric（逗號分隔，未帶時不限；site_id 未帶時限使用者可查詢的場站）、last_event_id（重連時補送其後的數據，SSE 亦可用 Last-Event-ID 標頭）
+// 帶 Upgrade: websocket 標頭時使用 WebSocket，否則使用 SSE
+func (h *Handler) StreamTelemetry(c *gin.Context) {
+	filter := stream.Filter{Sites: map[string]bool{}, Metrics: map[string]bool{}}
+	for _, siteID := range splitList(c.Query("site_id")) {
+		if !config.IsValidSite(siteID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID: " + siteID})
+			return
+		}
+		if !h.authorizeSite(c, auth.PermRead, siteID) {
+			return
+		}
+		filter.Sites[siteID] = true
+	}
+	// 未指定場站時只推送使用者可查詢的場站
+	if len(filter.Sites) == 0 {
+		scope := h.scope(c, auth.PermRead)
+		if !scope.all {
+			if len(scope.sites) == 0 {
+				c.JSON(http.StatusForbidden, gin.H{"error": "權限不足", "permission": auth.PermRead})
+				return
+			}
+			for _, siteID := range scope.list() {
+				filter.Sites[siteID] = true
+			}
+		}
+	}
+	for _, metric := range splitList(c.Query("metric")) {
+		if !stream.IsValidMetric(metric) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的metric參數（solar、load、storage）"})
+			return
+		}
+		filter.Metrics[metric] = true
+	}
+
+	lastIDStr := c.Query("last_event_id")
+	if lastIDStr == "" {
+		lastIDStr = c.GetHeader("Last-Event-ID")
+	}
+	var lastID uint64
+	if lastIDStr != "" {
+		id, err := strconv.ParseUint(lastIDStr, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的last_event_id參數"})
+			return
+		}
+		lastID = id
+	}
+
+	if strings.EqualFold(c.GetHeader("Upgrade"), "websocket") {
+		h.streamWebSocket(c, filter, lastID)
+		return
+	}
+	h.streamSSE(c, filter, lastID)
+}
+
+// IssueStreamTicket 發出即時推送連線用的一次性票證，以 ticket 參數代替網址中的權杖
+// 票證只能使用一次，斷線重連前須重新取得
+func (h *Handler) IssueStreamTicket(c *gin.Context) {
+	if !h.AuthEnabled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "認證已關閉，不需要票證"})
+		return
+	}
+
+	ticket, expires, err := h.StreamTickets.Issue(userClaims(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ticket": ticket, "expires_at": expires})
+}
+
+// streamSSE 以 Server-Sent Events 推送，事件ID即訊息ID，瀏覽器重連時自動帶 Last-Event-ID
+func (h *Handler) streamSSE(c *gin.Context, filter stream.Filter, lastID uint64) {
+	sub, complete := h.StreamHub.Subscribe(filter, lastID)
+	defer sub.Close()
+
+	w := c.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	send := func(id uint64, event string, data interface{}) error {
+		body, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if id > 0 {
+			fmt.Fprintf(w, "id: %d\n", id)
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body); err != nil {
+			return err
+		}
+		w.Flush()
+		return nil
+	}
+
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry)
+	if err := h.sendInitial(filter, lastID, complete, func(ctl streamControl) error {
+		return send(0, ctl.Type, ctl)
+	}); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.StreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case msg, ok := <-sub.C():
+			if !ok {
+				if sub.Overflowed() {
+					send(0, streamEventOverflow, streamControl{Type: streamEventOverflow, Time: time.Now()})
+				}
+				return
+			}
+			if err := send(msg.ID, msg.Metric, msg); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if err := send(0, streamEventHeartbeat, streamControl{Type: streamEventHeartbeat, Time: time.Now()}); err != nil {
+				return
+			}
+		case <-h.Done:
+			return
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
+
+// allowOrigin 同來源或在 AllowedOrigins 內的來源才可建立 WebSocket 連線
+func (h *Handler) allowOrigin(origin, host string) bool {
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, host) {
+		return true
+	}
+	for _, allowed := range h.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// streamWebSocket 以 WebSocket 推送 JSON 訊息，數據訊息帶 id，重連時以 last_event_id 參數補送
+func (h *Handler) streamWebSocket(c *gin.Context, filter stream.Filter, lastID uint64) {
+	server := websocket.Server{
+		// WebSocket 不受 CORS 限制，須自行檢查來源，返回錯誤時回應 403
+		// 沒有 Origin 標頭的非瀏覽器客戶端不檢查
+		Handshake: func(config *websocket.Config, req *http.Request) error {
+			origin, err := websocket.Origin(config, req)
+			if err != nil {
+				return err
+			}
+			if origin == nil || h.allowOrigin(origin.String(), req.Host) {
+				config.Origin = origin
+				return nil
+			}
+			return fmt.Errorf("來源 %s 不在允許清單內", origin)
+		},
+		Handler: func(ws *websocket.Conn) {
+			defer ws.Close()
+
+			sub, complete := h.StreamHub.Subscribe(filter, lastID)
+			defer sub.Close()
+
+			send := func(v interface{}) error {
+				ws.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+				return websocket.JSON.Send(ws, v)
+			}
+
+			// 客戶端不會送出數據，讀取只用於偵測連線關閉
+			closed := make(chan struct{})
+			go func() {
+				defer close(closed)
+				var discard []byte
+				for websocket.Message.Receive(ws, &discard) == nil {
+				}
+			}()
+
+			if err := h.sendInitial(filter, lastID, complete, func(ctl streamControl) error {
+				return send(ctl)
+			}); err != nil {
+				return
+			}
+
+			heartbeat := time.NewTicker(h.StreamHeartbeat)
+			defer heartbeat.Stop()
+
+			for {
+				select {
+				case msg, ok := <-sub.C():
+					if !ok {
+						if sub.Overflowed() {
+							send(streamControl{Type: streamEventOverflow, Time: time.Now()})
+						}
+						return
+					}
+					if err := send(msg); err != nil {
+						return
+					}
+				case <-heartbeat.C:
+					if err := send(streamControl{Type: streamEventHeartbeat, Time: time.Now()}); err != nil {
+						return
+					}
+				case <-closed:
+					return
+				case <-h.Done:
+					return
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// sendInitial 連線建立後的第一則訊息：新連線送出各場站最新數據，無法補送時通知客戶端重新載入
+func (h *Handler) sendInitial(filter stream.Filter, lastID uint64, complete bool, send func(streamControl) error) error {
+	if lastID > 0 {
+		if complete {
+			return nil
+		}
+		return send(streamControl{Type: streamEventReset, Time: time.Now()})
+	}
+
+	snapshot, err := h.streamSnapshot(filter)
+	if err != nil {
+		// 查詢失敗不影響後續推送
+		log.Printf("查詢即時推送的最新數據失敗: %v\n", err)
+		return nil
+	}
+	return send(streamControl{Type: streamEventSnapshot, Time: time.Now(), Data: snapshot})
+}
+
+// streamSnapshot 查詢符合訂閱條件的各場站最新數據
+func (h *Handler) streamSnapshot(filter stream.Filter) ([]stream.Message, error) {
+	var snapshot []stream.Message
+	add := func(metric, siteID string, t time.Time, data interface{}) {
+		msg := stream.Message{Metric: metric, SiteID: siteID, Time: t, Data: data}
+		if filter.Match(&msg) {
+			snapshot = append(snapshot, msg)
+		}
+	}
+
+	if len(filter.Metrics) == 0 || filter.Metrics[stream.MetricSolar] {
+		solar, err := h.SolarModel.GetAllLatest()
+		if err != nil {
+			return nil, err
+		}
+		for i := range solar {
+			add(stream.MetricSolar, solar[i].SiteID, solar[i].DateTime, &solar[i])
+		}
+	}
+	if len(filter.Metrics) == 0 || filter.Metrics[stream.MetricLoad] {
+		load, err := h.LoadModel.GetAllLatest()
+		if err != nil {
+			return nil, err
+		}
+		for i := range load {
+			add(stream.MetricLoad, load[i].SiteID, load[i].DateTime, &load[i])
+		}
+	}
+	if len(filter.Metrics) == 0 || filter.Metrics[stream.MetricStorage] {
+		storage, err := h.StorageModel.GetAllLatest()
+		if err != nil {
+			return nil, err
+		}
+		for i := range storage {
+			add(stream.MetricStorage, storage[i].SiteID, storage[i].DateTime, &storage[i])
+		}
+	}
+	return snapshot, nil
+}
+
+// splitList 解析逗號分隔的參數，略過空白項
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
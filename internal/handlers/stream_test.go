@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/stream"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+func TestAllowOrigin(t *testing.T) {
+	h := &Handler{AllowedOrigins: []string{"https://dashboard.example.com/"}}
+
+	tests := []struct {
+		origin string
+		host   string
+		want   bool
+	}{
+		{"https://dashboard.example.com", "api.example.com", true},
+		{"https://api.example.com", "api.example.com", true}, // 同來源
+		{"https://evil.example.com", "api.example.com", false},
+		{"http://dashboard.example.com", "api.example.com", false}, // 協定不符
+		{"null", "api.example.com", false},
+	}
+	for _, tt := range tests {
+		if got := h.allowOrigin(tt.origin, tt.host); got != tt.want {
+			t.Errorf("allowOrigin(%q, %q) = %v, 預期 %v", tt.origin, tt.host, got, tt.want)
+		}
+	}
+
+	if !(&Handler{AllowedOrigins: []string{"*"}}).allowOrigin("https://evil.example.com", "api.example.com") {
+		t.Error("* 應允許所有來源")
+	}
+	if (&Handler{}).allowOrigin("https://dashboard.example.com", "api.example.com") {
+		t.Error("未設定允許清單時應拒絕跨來源")
+	}
+}
+
+func TestStreamWebSocketRejectsOrigin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	h := &Handler{AllowedOrigins: []string{"https://dashboard.example.com"}}
+	r.GET("/stream", func(c *gin.Context) {
+		h.streamWebSocket(c, stream.Filter{}, 0)
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	config, err := websocket.NewConfig(strings.Replace(srv.URL, "http", "ws", 1)+"/stream", "https://evil.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := websocket.DialConfig(config); err == nil {
+		t.Fatal("不在允許清單內的來源應無法建立連線")
+	}
+
+	// 直接檢查握手回應狀態
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/stream", nil)
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Origin", "https://evil.example.com")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("請求失敗: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("狀態 = %d, 預期 403", resp.StatusCode)
+	}
+}
+
+func TestRequireUserStreamTicket(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{AuthEnabled: true, StreamTickets: auth.NewTickets(time.Minute)}
+	r := gin.New()
+	r.GET("/stream", h.RequireUser(), func(c *gin.Context) {
+		c.String(http.StatusOK, userClaims(c).Subject)
+	})
+
+	ticket, _, err := h.StreamTickets.Issue(&auth.Claims{Subject: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/stream?ticket="+ticket, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// 非即時推送請求不接受票證
+	if w := request("application/json"); w.Code != http.StatusUnauthorized {
+		t.Errorf("一般請求狀態 = %d, 預期 401", w.Code)
+	}
+	if w := request("text/event-stream"); w.Code != http.StatusOK || w.Body.String() != "alice" {
+		t.Errorf("首次使用狀態 %d、使用者 %q, 預期 200、alice", w.Code, w.Body.String())
+	}
+	if w := request("text/event-stream"); w.Code != http.StatusUnauthorized {
+		t.Errorf("重複使用狀態 = %d, 預期 401", w.Code)
+	}
+}
@@ -4,12 +4,19 @@ import (
 	"net/http"
 	"strconv"
 	"time"
+	"vpp-go/internal/auth"
+	"vpp-go/internal/export"
+	"vpp-go/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
 // GetLatestReserve 獲取最新一天的備轉資料
 func (h *Handler) GetLatestReserve(c *gin.Context) {
+	if !h.authorizeAny(c, auth.PermRead) {
+		return
+	}
+
 	dataList, err := h.TaipowerModel.GetLatest()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -30,6 +37,10 @@ func (h *Handler) GetLatestReserve(c *gin.Context) {
 
 // GetReserveByDate 獲取特定日期的備轉資料
 func (h *Handler) GetReserveByDate(c *gin.Context) {
+	if !h.authorizeAny(c, auth.PermRead) {
+		return
+	}
+
 	dateStr := c.Query("date")
 	if dateStr == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少日期參數"})
@@ -62,13 +73,15 @@ func (h *Handler) GetReserveByDate(c *gin.Context) {
 
 // GetReserveHistory 獲取歷史備轉資料
 func (h *Handler) GetReserveHistory(c *gin.Context) {
+	if !h.authorizeAny(c, auth.PermRead) {
+		return
+	}
+
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
-	limitStr := c.DefaultQuery("limit", "1000")
-
-	limit, err := strconv.Atoi(limitStr)
+	limit, after, err := parsePage(c, 1000)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的limit參數"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -94,22 +107,41 @@ func (h *Handler) GetReserveHistory(c *gin.Context) {
 		endDate = time.Now()
 	}
 
-	dataList, err := h.TaipowerModel.GetHistory(startDate, endDate, limit)
+	format, err := export.Negotiate(c.Query("format"), c.GetHeader("Accept"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if format != "" {
+		name := exportName("reserve", "", startDate, endDate)
+		h.exportHistory(c, format, name, models.TaipowerReserveData{}, nil, func(write func(interface{}) error) error {
+			return h.TaipowerModel.Each(startDate, endDate, func(data *models.TaipowerReserveData) error { return write(data) })
+		})
+		return
+	}
+
+	dataList, next, err := h.TaipowerModel.GetHistory(startDate, endDate, limit, after)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"start_date": startDate.Format("2006-01-02"),
-		"end_date":   endDate.Format("2006-01-02"),
-		"count":      len(dataList),
-		"data":       dataList,
+		"start_date":  startDate.Format("2006-01-02"),
+		"end_date":    endDate.Format("2006-01-02"),
+		"count":       len(dataList),
+		"limit":       limit,
+		"data":        dataList,
+		"next_cursor": nextCursor(next),
 	})
 }
 
 // GetReserveStatistics 獲取統計資訊
 func (h *Handler) GetReserveStatistics(c *gin.Context) {
+	if !h.authorizeAny(c, auth.PermRead) {
+		return
+	}
+
 	dateStr := c.DefaultQuery("date", time.Now().Format("2006-01-02"))
 
 	date, err := time.Parse("2006-01-02", dateStr)
@@ -132,6 +164,10 @@ func (h *Handler) GetReserveStatistics(c *gin.Context) {
 
 // GetReserveByHour 獲取特定時段的備轉資料
 func (h *Handler) GetReserveByHour(c *gin.Context) {
+	if !h.authorizeAny(c, auth.PermRead) {
+		return
+	}
+
 	dateStr := c.Query("date")
 	hourStr := c.Query("hour")
 
@@ -1,12 +1,18 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"time"
+	"vpp-go/internal/models"
+	"vpp-go/internal/telemetry"
 
 	"github.com/gin-gonic/gin"
 )
 
+// maxUploadRecords 單次上傳的記錄數上限
+const maxUploadRecords = 500
+
 // UploadRequest 上傳請求結構
 type UploadRequest struct {
 	SiteID    string  `json:"site_id" binding:"required"`
@@ -20,14 +26,93 @@ type RawData struct {
 }
 
 // UploadData 處理樹莓派數據上傳
+// 帶 schema_version 的請求依版本化格式寫入對應數據表，否則沿用舊格式
 func (h *Handler) UploadData(c *gin.Context) {
-	var req UploadRequest
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無法讀取請求內容"})
+		return
+	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	var probe struct {
+		SchemaVersion int `json:"schema_version"`
+	}
+	if err := json.Unmarshal(body, &probe); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的請求數據"})
 		return
 	}
 
+	switch {
+	case probe.SchemaVersion == 0:
+		h.uploadLegacy(c, body)
+	case probe.SchemaVersion == telemetry.SchemaVersion:
+		h.uploadTyped(c, body)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":              "不支援的上傳格式版本",
+			"supported_versions": []int{telemetry.SchemaVersion},
+		})
+	}
+}
+
+// uploadTyped 處理版本化上傳格式，返回每筆記錄的接受/拒絕結果
+func (h *Handler) uploadTyped(c *gin.Context, body []byte) {
+	var env telemetry.Envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的請求數據"})
+		return
+	}
+
+	if len(env.Records) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少記錄"})
+		return
+	}
+	if len(env.Records) > maxUploadRecords {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "記錄數超過上限，請使用批次上傳"})
+		return
+	}
+	if !authorizeEnvelope(c, &env) {
+		return
+	}
+
+	results := h.Ingester.Ingest(&env)
+
+	accepted := 0
+	for _, r := range results {
+		if r.Status == models.TelemetryAccepted {
+			accepted++
+		}
+	}
+
+	status := http.StatusOK
+	switch {
+	case accepted == 0:
+		status = http.StatusUnprocessableEntity
+	case accepted < len(results):
+		status = http.StatusMultiStatus
+	}
+
+	c.JSON(status, gin.H{
+		"schema_version": env.SchemaVersion,
+		"site_id":        env.SiteID,
+		"device_id":      env.DeviceID,
+		"accepted":       accepted,
+		"rejected":       len(results) - accepted,
+		"results":        results,
+	})
+}
+
+// uploadLegacy 處理舊版上傳格式（寫入 stu 表）
+func (h *Handler) uploadLegacy(c *gin.Context, body []byte) {
+	var req UploadRequest
+	if err := json.Unmarshal(body, &req); err != nil || req.SiteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的請求數據"})
+		return
+	}
+	if !authorizeDeviceSite(c, req.SiteID) {
+		return
+	}
+
 	// 解析時間戳
 	var timestamp time.Time
 	var err error
@@ -40,10 +125,6 @@ func (h *Handler) UploadData(c *gin.Context) {
 		timestamp = time.Now()
 	}
 
-	// 這裡可以根據需要將數據插入到 stu 表或其他表
-	// 目前保留原有的上傳功能，可以根據實際需求修改
-
-	// 假設插入到一個通用的上傳表
 	query := `
 		INSERT INTO stu (site_id, timestamp, data)
 		VALUES ($1, $2, $3)
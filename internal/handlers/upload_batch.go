@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+	"vpp-go/internal/database"
+	"vpp-go/internal/models"
+	"vpp-go/internal/telemetry"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxBatchBodySize    = 32 << 20  // 請求主體上限（壓縮後）
+	maxBatchDecodedSize = 256 << 20 // 解壓縮後上限
+	maxBatchRecords     = 50000     // 單次批次記錄數上限
+	maxIdempotencyKey   = 255       // Idempotency-Key 長度上限
+)
+
+// UploadBatch 批次上傳遙測數據
+// 支援 JSON lines（每行一筆記錄）或版本化 JSON 格式，可用 gzip 壓縮，所有寫入在同一事務中完成
+// 帶 Idempotency-Key 標頭的請求重送時返回首次處理的結果，不會重複寫入
+func (h *Handler) UploadBatch(c *gin.Context) {
+	key := strings.TrimSpace(c.GetHeader("Idempotency-Key"))
+	if len(key) > maxIdempotencyKey {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key 過長"})
+		return
+	}
+
+	body, err := readBatchBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	requestHash := hex.EncodeToString(sum[:])
+
+	env, err := parseBatchEnvelope(c, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(env.Records) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少記錄"})
+		return
+	}
+	if len(env.Records) > maxBatchRecords {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "記錄數超過上限"})
+		return
+	}
+	// 先檢查場站範圍再回放，避免以其他場站的重送取得結果
+	if !authorizeEnvelope(c, env) {
+		return
+	}
+	device := idempotencyDevice(c, env)
+
+	if key != "" {
+		if h.replayIdempotent(c, device, key, requestHash) {
+			return
+		}
+	}
+
+	results, statements, prepared := telemetry.BuildBatch(env, time.Now())
+
+	accepted := 0
+	rejected := []telemetry.RecordResult{}
+	for _, r := range results {
+		if r.Status == models.TelemetryAccepted {
+			accepted++
+		} else {
+			rejected = append(rejected, r)
+		}
+	}
+
+	status := http.StatusOK
+	switch {
+	case accepted == 0:
+		status = http.StatusUnprocessableEntity
+	case accepted < len(results):
+		status = http.StatusMultiStatus
+	}
+
+	response, err := json.Marshal(gin.H{
+		"site_id":          env.SiteID,
+		"device_id":        env.DeviceID,
+		"total":            len(results),
+		"accepted":         accepted,
+		"rejected":         len(rejected),
+		"rejected_records": rejected,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "響應編碼失敗"})
+		return
+	}
+
+	if key != "" {
+		record := &models.IdempotencyRecord{
+			DeviceID:    device,
+			Key:         key,
+			RequestHash: requestHash,
+			StatusCode:  status,
+			Response:    response,
+		}
+		statements = append(statements, database.BatchStatement{
+			Query:    models.IdempotencyInsertQuery,
+			DataList: [][]interface{}{record.InsertArgs()},
+		})
+	}
+
+	if err := database.ExecuteBatches(h.DB, statements...); err != nil {
+		// 同一個鍵的並行請求已先完成時回放其結果
+		if key != "" && h.replayIdempotent(c, device, key, requestHash) {
+			return
+		}
+		log.Printf("批次上傳寫入失敗: %v\n", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "數據保存失敗"})
+		return
+	}
+	h.Ingester.Publish(prepared...)
+
+	c.Data(status, "application/json; charset=utf-8", response)
+}
+
+// idempotencyDevice 冪等鍵所屬的設備，使用 API 金鑰時以金鑰的設備為準
+func idempotencyDevice(c *gin.Context, env *telemetry.Envelope) string {
+	if key := deviceKey(c); key != nil {
+		return key.DeviceID
+	}
+	return env.DeviceID
+}
+
+// replayIdempotent 該設備的鍵已處理過時回放結果，返回是否已回應
+func (h *Handler) replayIdempotent(c *gin.Context, device, key, requestHash string) bool {
+	record, err := h.IdempotencyModel.Get(device, key)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return true
+	}
+	if record == nil {
+		return false
+	}
+
+	if record.RequestHash != requestHash {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key 已用於不同的請求內容"})
+		return true
+	}
+
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(record.StatusCode, "application/json; charset=utf-8", record.Response)
+	return true
+}
+
+// readBatchBody 讀取請求主體，依 Content-Encoding 解壓縮
+func readBatchBody(c *gin.Context) ([]byte, error) {
+	reader := io.Reader(http.MaxBytesReader(c.Writer, c.Request.Body, maxBatchBodySize))
+
+	if strings.EqualFold(c.GetHeader("Content-Encoding"), "gzip") {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, errors.New("無效的 gzip 內容")
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, maxBatchDecodedSize+1))
+	if err != nil {
+		return nil, errors.New("無法讀取請求內容")
+	}
+	if len(body) > maxBatchDecodedSize {
+		return nil, errors.New("請求內容過大")
+	}
+	return body, nil
+}
+
+// parseBatchEnvelope 依 Content-Type 解析批次內容
+// application/json 為版本化格式，其餘視為 JSON lines，場站與設備ID由查詢參數提供
+func parseBatchEnvelope(c *gin.Context, body []byte) (*telemetry.Envelope, error) {
+	mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+	if mediaType == "application/json" {
+		var env telemetry.Envelope
+		if err := json.Unmarshal(body, &env); err != nil {
+			return nil, errors.New("無效的請求數據")
+		}
+		if env.SchemaVersion != telemetry.SchemaVersion {
+			return nil, errors.New("不支援的上傳格式版本")
+		}
+		return &env, nil
+	}
+
+	return telemetry.ParseNDJSON(bytes.NewReader(body), c.Query("site_id"), c.Query("device_id"))
+}
@@ -2,15 +2,41 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 	"time"
+	"vpp-go/internal/auth"
 	"vpp-go/internal/config"
+	"vpp-go/internal/export"
+	"vpp-go/internal/models"
 
 	"github.com/gin-gonic/gin"
 )
 
-// GetAllRealtimeData 獲取所有場站即時數據
+// GetSites 獲取已註冊的場站列表，只列出使用者可查詢的場站
+func (h *Handler) GetSites(c *gin.Context) {
+	scope := h.scope(c, auth.PermRead)
+	sites := make([]gin.H, 0, len(h.Sites))
+	for _, site := range h.Sites {
+		if !scope.allows(site.ID) {
+			continue
+		}
+		sites = append(sites, gin.H{
+			"site_id":       site.ID,
+			"name":          site.Name,
+			"poll_schedule": site.PollSchedule,
+			"timezone":      site.Timezone.String(),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(sites),
+		"sites": sites,
+	})
+}
+
+// GetAllRealtimeData 獲取所有場站即時數據，只包含使用者可查詢的場站
 func (h *Handler) GetAllRealtimeData(c *gin.Context) {
+	scope := h.scope(c, auth.PermRead)
+
 	solarData, err := h.SolarModel.GetAllLatest()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -23,9 +49,20 @@ func (h *Handler) GetAllRealtimeData(c *gin.Context) {
 		return
 	}
 
+	storageData, err := h.StorageModel.GetAllLatest()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	solarData = filterSolar(scope, solarData)
+	loadData = filterLoad(scope, loadData)
+	storageData = filterStorage(scope, storageData)
+
 	c.JSON(http.StatusOK, gin.H{
-		"solar": solarData,
-		"load":  loadData,
+		"solar":   solarData,
+		"load":    loadData,
+		"storage": storageData,
 	})
 }
 
@@ -37,6 +74,9 @@ func (h *Handler) GetSiteRealtimeData(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
 		return
 	}
+	if !h.authorizeSite(c, auth.PermRead, siteID) {
+		return
+	}
 
 	solarData, err := h.SolarModel.GetLatest(siteID)
 	if err != nil {
@@ -50,10 +90,17 @@ func (h *Handler) GetSiteRealtimeData(c *gin.Context) {
 		return
 	}
 
+	storageData, err := h.StorageModel.GetLatest(siteID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"site_id": siteID,
 		"solar":   solarData,
 		"load":    loadData,
+		"storage": storageData,
 	})
 }
 
@@ -67,6 +114,9 @@ func (h *Handler) GetLatestSolarData(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
 			return
 		}
+		if !h.authorizeSite(c, auth.PermRead, siteID) {
+			return
+		}
 
 		data, err := h.SolarModel.GetLatest(siteID)
 		if err != nil {
@@ -88,7 +138,7 @@ func (h *Handler) GetLatestSolarData(c *gin.Context) {
 			return
 		}
 
-		c.JSON(http.StatusOK, dataList)
+		c.JSON(http.StatusOK, filterSolar(h.scope(c, auth.PermRead), dataList))
 	}
 }
 
@@ -104,14 +154,21 @@ func (h *Handler) GetSolarHistory(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
 		return
 	}
+	if !h.authorizeSite(c, auth.PermRead, siteID) {
+		return
+	}
+
+	// 帶 interval 時改為依時間區間聚合
+	if c.Query("interval") != "" {
+		h.aggregateHistory(c, siteID, h.SolarModel.Aggregate)
+		return
+	}
 
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
-	limitStr := c.DefaultQuery("limit", "100")
-
-	limit, err := strconv.Atoi(limitStr)
+	limit, after, err := parsePage(c, 100)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的limit參數"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -137,18 +194,33 @@ func (h *Handler) GetSolarHistory(c *gin.Context) {
 		endDate = time.Now()
 	}
 
-	dataList, err := h.SolarModel.GetHistory(siteID, startDate, endDate, limit)
+	format, err := export.Negotiate(c.Query("format"), c.GetHeader("Accept"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if format != "" {
+		name := exportName("solar", siteID, startDate, endDate)
+		h.exportHistory(c, format, name, models.SolarData{}, h.siteLocation(siteID), func(write func(interface{}) error) error {
+			return h.SolarModel.Each(siteID, startDate, endDate, func(data *models.SolarData) error { return write(data) })
+		})
+		return
+	}
+
+	dataList, next, err := h.SolarModel.GetHistory(siteID, startDate, endDate, limit, after)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"site_id":    siteID,
-		"start_date": startDate.Format("2006-01-02"),
-		"end_date":   endDate.Format("2006-01-02"),
-		"count":      len(dataList),
-		"data":       dataList,
+		"site_id":     siteID,
+		"start_date":  startDate.Format("2006-01-02"),
+		"end_date":    endDate.Format("2006-01-02"),
+		"count":       len(dataList),
+		"limit":       limit,
+		"data":        dataList,
+		"next_cursor": nextCursor(next),
 	})
 }
 
@@ -162,6 +234,9 @@ func (h *Handler) GetLatestLoadData(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
 			return
 		}
+		if !h.authorizeSite(c, auth.PermRead, siteID) {
+			return
+		}
 
 		data, err := h.LoadModel.GetLatest(siteID)
 		if err != nil {
@@ -183,7 +258,7 @@ func (h *Handler) GetLatestLoadData(c *gin.Context) {
 			return
 		}
 
-		c.JSON(http.StatusOK, dataList)
+		c.JSON(http.StatusOK, filterLoad(h.scope(c, auth.PermRead), dataList))
 	}
 }
 
@@ -199,14 +274,135 @@ func (h *Handler) GetLoadHistory(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
 		return
 	}
+	if !h.authorizeSite(c, auth.PermRead, siteID) {
+		return
+	}
+
+	// 帶 interval 時改為依時間區間聚合
+	if c.Query("interval") != "" {
+		h.aggregateHistory(c, siteID, h.LoadModel.Aggregate)
+		return
+	}
 
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
-	limitStr := c.DefaultQuery("limit", "100")
+	limit, after, err := parsePage(c, 100)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 解析日期
+	var startDate, endDate time.Time
+	if startDateStr != "" {
+		startDate, err = time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的開始日期格式"})
+			return
+		}
+	} else {
+		startDate = time.Now().AddDate(0, 0, -30) // 預設30天前
+	}
+
+	if endDateStr != "" {
+		endDate, err = time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的結束日期格式"})
+			return
+		}
+	} else {
+		endDate = time.Now()
+	}
+
+	format, err := export.Negotiate(c.Query("format"), c.GetHeader("Accept"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if format != "" {
+		name := exportName("load", siteID, startDate, endDate)
+		h.exportHistory(c, format, name, models.LoadData{}, h.siteLocation(siteID), func(write func(interface{}) error) error {
+			return h.LoadModel.Each(siteID, startDate, endDate, func(data *models.LoadData) error { return write(data) })
+		})
+		return
+	}
+
+	dataList, next, err := h.LoadModel.GetHistory(siteID, startDate, endDate, limit, after)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"site_id":     siteID,
+		"start_date":  startDate.Format("2006-01-02"),
+		"end_date":    endDate.Format("2006-01-02"),
+		"count":       len(dataList),
+		"limit":       limit,
+		"data":        dataList,
+		"next_cursor": nextCursor(next),
+	})
+}
+
+// GetLatestStorageData 獲取最新儲能數據
+func (h *Handler) GetLatestStorageData(c *gin.Context) {
+	siteID := c.Query("site_id")
+
+	if siteID != "" {
+		// 獲取特定場站
+		if !config.IsValidSite(siteID) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
+			return
+		}
+		if !h.authorizeSite(c, auth.PermRead, siteID) {
+			return
+		}
+
+		data, err := h.StorageModel.GetLatest(siteID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		if data == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "找不到數據"})
+			return
+		}
+
+		c.JSON(http.StatusOK, data)
+	} else {
+		// 獲取所有場站
+		dataList, err := h.StorageModel.GetAllLatest()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, filterStorage(h.scope(c, auth.PermRead), dataList))
+	}
+}
+
+// GetStorageHistory 獲取儲能歷史數據
+func (h *Handler) GetStorageHistory(c *gin.Context) {
+	siteID := c.Query("site_id")
+	if siteID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少場站ID參數"})
+		return
+	}
 
-	limit, err := strconv.Atoi(limitStr)
+	if !config.IsValidSite(siteID) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的場站ID"})
+		return
+	}
+	if !h.authorizeSite(c, auth.PermRead, siteID) {
+		return
+	}
+
+	startDateStr := c.Query("start_date")
+	endDateStr := c.Query("end_date")
+	limit, after, err := parsePage(c, 100)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "無效的limit參數"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -232,24 +428,41 @@ func (h *Handler) GetLoadHistory(c *gin.Context) {
 		endDate = time.Now()
 	}
 
-	dataList, err := h.LoadModel.GetHistory(siteID, startDate, endDate, limit)
+	format, err := export.Negotiate(c.Query("format"), c.GetHeader("Accept"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if format != "" {
+		name := exportName("storage", siteID, startDate, endDate)
+		h.exportHistory(c, format, name, models.StorageData{}, h.siteLocation(siteID), func(write func(interface{}) error) error {
+			return h.StorageModel.Each(siteID, startDate, endDate, func(data *models.StorageData) error { return write(data) })
+		})
+		return
+	}
+
+	dataList, next, err := h.StorageModel.GetHistory(siteID, startDate, endDate, limit, after)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"site_id":    siteID,
-		"start_date": startDate.Format("2006-01-02"),
-		"end_date":   endDate.Format("2006-01-02"),
-		"count":      len(dataList),
-		"data":       dataList,
+		"site_id":     siteID,
+		"start_date":  startDate.Format("2006-01-02"),
+		"end_date":    endDate.Format("2006-01-02"),
+		"count":       len(dataList),
+		"limit":       limit,
+		"data":        dataList,
+		"next_cursor": nextCursor(next),
 	})
 }
 
-// GetSummary 獲取彙總統計
+// GetSummary 獲取彙總統計，只計入使用者可查詢的場站
 func (h *Handler) GetSummary(c *gin.Context) {
 	// 獲取所有場站最新數據
+	scope := h.scope(c, auth.PermRead)
+
 	solarData, err := h.SolarModel.GetAllLatest()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -262,6 +475,16 @@ func (h *Handler) GetSummary(c *gin.Context) {
 		return
 	}
 
+	storageData, err := h.StorageModel.GetAllLatest()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	solarData = filterSolar(scope, solarData)
+	loadData = filterLoad(scope, loadData)
+	storageData = filterStorage(scope, storageData)
+
 	// 計算總和
 	var totalGeneration, totalLoad, totalCO2 float64
 	for _, solar := range solarData {
@@ -273,11 +496,70 @@ func (h *Handler) GetSummary(c *gin.Context) {
 		totalLoad += load.LoadValue
 	}
 
+	// 儲能可用容量（正值放電、負值充電的淨功率）
+	var availableEnergy, netStoragePower, avgSoC float64
+	for _, storage := range storageData {
+		availableEnergy += storage.AvailableEnergy
+		netStoragePower += storage.DischargePower - storage.ChargePower
+		avgSoC += storage.SoC
+	}
+	if len(storageData) > 0 {
+		avgSoC /= float64(len(storageData))
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"total_generation":  totalGeneration,
 		"total_load":        totalLoad,
 		"total_co2_reduced": totalCO2,
 		"site_count":        len(solarData),
 		"timestamp":         time.Now(),
+		"storage": gin.H{
+			"available_energy": availableEnergy,
+			"net_power":        netStoragePower,
+			"average_soc":      avgSoC,
+			"site_count":       len(storageData),
+		},
 	})
 }
+
+// filterSolar 只保留範圍內場站的太陽能數據
+func filterSolar(scope siteScope, dataList []models.SolarData) []models.SolarData {
+	if scope.all {
+		return dataList
+	}
+	filtered := make([]models.SolarData, 0, len(dataList))
+	for _, data := range dataList {
+		if scope.allows(data.SiteID) {
+			filtered = append(filtered, data)
+		}
+	}
+	return filtered
+}
+
+// filterLoad 只保留範圍內場站的負載數據
+func filterLoad(scope siteScope, dataList []models.LoadData) []models.LoadData {
+	if scope.all {
+		return dataList
+	}
+	filtered := make([]models.LoadData, 0, len(dataList))
+	for _, data := range dataList {
+		if scope.allows(data.SiteID) {
+			filtered = append(filtered, data)
+		}
+	}
+	return filtered
+}
+
+// filterStorage 只保留範圍內場站的儲能數據
+func filterStorage(scope siteScope, dataList []models.StorageData) []models.StorageData {
+	if scope.all {
+		return dataList
+	}
+	filtered := make([]models.StorageData, 0, len(dataList))
+	for _, data := range dataList {
+		if scope.allows(data.SiteID) {
+			filtered = append(filtered, data)
+		}
+	}
+	return filtered
+}
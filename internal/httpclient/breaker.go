@@ -0,0 +1,113 @@
+package httpclient
+
+import (
+	"sync"
+	"time"
+)
+
+// state 熔斷器狀態
+type state int
+
+const (
+	stateClosed state = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// String 狀態名稱
+func (s state) String() string {
+	switch s {
+	case stateOpen:
+		return "open"
+	case stateHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// breaker 單一上游的熔斷器
+// 連續失敗達 threshold 次後熔斷，cooldown 過後允許一個試探請求，成功即恢復
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex
+	state    state
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow 判斷是否允許送出請求
+func (b *breaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = stateHalfOpen
+		b.probing = true
+		return true
+	case stateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// success 記錄成功
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = stateClosed
+	b.failures = 0
+	b.probing = false
+}
+
+// failure 記錄失敗
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == stateHalfOpen || (b.threshold > 0 && b.failures >= b.threshold) {
+		b.state = stateOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release 請求未產生結果（如呼叫方取消）時釋放試探名額
+func (b *breaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if b.state == stateHalfOpen {
+		b.state = stateOpen
+	}
+}
+
+// currentState 獲取目前狀態
+func (b *breaker) currentState() state {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == stateOpen && time.Since(b.openedAt) >= b.cooldown {
+		return stateHalfOpen
+	}
+	return b.state
+}
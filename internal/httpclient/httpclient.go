@@ -0,0 +1,241 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 上游熔斷中，請求未送出
+var ErrCircuitOpen = errors.New("上游服務熔斷中")
+
+// Config 對外HTTP請求配置
+type Config struct {
+	Timeout          time.Duration // 單次請求逾時
+	MaxRetries       int           // 最大重試次數（不含首次請求）
+	BaseDelay        time.Duration // 退避基準間隔
+	MaxDelay         time.Duration // 退避間隔上限（含 Retry-After）
+	BreakerThreshold int           // 連續失敗幾次後熔斷
+	BreakerCooldown  time.Duration // 熔斷後多久允許試探請求
+}
+
+// DefaultConfig 預設配置
+func DefaultConfig() Config {
+	return Config{
+		Timeout:          30 * time.Second,
+		MaxRetries:       3,
+		BaseDelay:        500 * time.Millisecond,
+		MaxDelay:         30 * time.Second,
+		BreakerThreshold: 5,
+		BreakerCooldown:  time.Minute,
+	}
+}
+
+// Client 帶重試、退避與熔斷的HTTP客戶端，熔斷狀態依上游主機分開計算
+type Client struct {
+	http *http.Client
+	cfg  Config
+
+	mu       sync.Mutex
+	breakers map[string]*breaker
+}
+
+var (
+	defaultMu     sync.RWMutex
+	defaultClient = New(DefaultConfig())
+)
+
+// New 創建HTTP客戶端
+func New(cfg Config) *Client {
+	return &Client{
+		http:     &http.Client{Timeout: cfg.Timeout},
+		cfg:      cfg,
+		breakers: make(map[string]*breaker),
+	}
+}
+
+// Default 獲取共用的HTTP客戶端
+func Default() *Client {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultClient
+}
+
+// SetDefault 替換共用的HTTP客戶端，應於創建收集器前呼叫
+func SetDefault(c *Client) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultClient = c
+}
+
+// Do 發送請求，遇到5xx或逾時時以指數退避加隨機抖動重試
+// 重試用盡時返回最後一次的響應，由呼叫方檢查狀態碼
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Host
+	b := c.breaker(host)
+
+	for attempt := 0; ; attempt++ {
+		if !b.allow() {
+			return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, host)
+		}
+
+		attemptReq, err := rewind(req, attempt)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.http.Do(attemptReq)
+		if ctx.Err() != nil {
+			// 呼叫方取消，不計入熔斷
+			b.release()
+			return resp, err
+		}
+		if !isRetryable(resp, err) {
+			if err != nil {
+				b.failure()
+			} else {
+				b.success()
+			}
+			return resp, err
+		}
+		b.failure()
+
+		if attempt >= c.cfg.MaxRetries {
+			return resp, err
+		}
+
+		delay := c.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp); ok {
+				if after > c.cfg.MaxDelay {
+					// 上游要求等待過久，直接返回讓下一次排程處理
+					return resp, nil
+				}
+				delay = after
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		log.Printf("對外請求失敗，%s 後重試 (%d/%d) - %s %s: %s\n",
+			delay, attempt+1, c.cfg.MaxRetries, req.Method, req.URL.Redacted(), describe(resp, err))
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Get 發送GET請求
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("創建請求失敗: %w", err)
+	}
+	return c.Do(req)
+}
+
+// BreakerStates 獲取各上游的熔斷狀態
+func (c *Client) BreakerStates() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	states := make(map[string]string, len(c.breakers))
+	for host, b := range c.breakers {
+		states[host] = b.currentState().String()
+	}
+	return states
+}
+
+// breaker 獲取指定上游的熔斷器
+func (c *Client) breaker(host string) *breaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.breakers[host]
+	if !ok {
+		b = &breaker{threshold: c.cfg.BreakerThreshold, cooldown: c.cfg.BreakerCooldown}
+		c.breakers[host] = b
+	}
+	return b
+}
+
+// backoff 計算第 attempt 次重試的等待時間（full jitter）
+func (c *Client) backoff(attempt int) time.Duration {
+	ceiling := c.cfg.BaseDelay << attempt
+	if ceiling <= 0 || ceiling > c.cfg.MaxDelay {
+		ceiling = c.cfg.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// rewind 重試時重建請求主體
+func rewind(req *http.Request, attempt int) (*http.Request, error) {
+	if attempt == 0 || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if req.GetBody == nil {
+		return nil, fmt.Errorf("請求主體無法重送")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("重建請求主體失敗: %w", err)
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone, nil
+}
+
+// isRetryable 判斷是否應重試：5xx 或單次請求逾時
+// 429 表示呼叫頻率過高，重試只會加重限流，直接返回由下一次排程處理
+func isRetryable(resp *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return true
+		}
+		return errors.Is(err, context.DeadlineExceeded)
+	}
+	return resp.StatusCode >= 500
+}
+
+// retryAfter 解析 Retry-After 標頭（秒數或HTTP日期）
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// describe 描述失敗原因
+func describe(resp *http.Response, err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return fmt.Sprintf("狀態碼 %d", resp.StatusCode)
+}
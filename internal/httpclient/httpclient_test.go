@@ -0,0 +1,252 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testConfig 測試用的短間隔配置
+func testConfig() Config {
+	return Config{
+		Timeout:          time.Second,
+		MaxRetries:       3,
+		BaseDelay:        time.Millisecond,
+		MaxDelay:         5 * time.Millisecond,
+		BreakerThreshold: 0,
+		BreakerCooldown:  time.Minute,
+	}
+}
+
+// statusServer 依序回應 statuses 中的狀態碼，用完後重複最後一個
+func statusServer(t *testing.T, statuses []int, header http.Header) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1)) - 1
+		if n >= len(statuses) {
+			n = len(statuses) - 1
+		}
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(statuses[n])
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestDoRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		statuses   []int
+		maxRetries int
+		wantCalls  int32
+		wantStatus int
+	}{
+		{"成功不重試", []int{200}, 3, 1, 200},
+		{"5xx 重試至成功", []int{500, 502, 200}, 3, 3, 200},
+		{"5xx 重試用盡返回最後響應", []int{503}, 3, 4, 503},
+		{"不重試時只送一次", []int{500}, 0, 1, 500},
+		{"4xx 不重試", []int{404}, 3, 1, 404},
+		{"429 不重試", []int{429}, 3, 1, 429},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := statusServer(t, tt.statuses, nil)
+			cfg := testConfig()
+			cfg.MaxRetries = tt.maxRetries
+			c := New(cfg)
+
+			resp, err := c.Get(context.Background(), srv.URL)
+			if err != nil {
+				t.Fatalf("Get: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("狀態碼 = %d, 預期 %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("請求次數 = %d, 預期 %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestBackoffCap(t *testing.T) {
+	c := New(Config{BaseDelay: 10 * time.Millisecond, MaxDelay: 100 * time.Millisecond})
+
+	for attempt := 0; attempt < 70; attempt++ {
+		ceiling := 10 * time.Millisecond << attempt
+		if ceiling <= 0 || ceiling > 100*time.Millisecond {
+			ceiling = 100 * time.Millisecond
+		}
+		for i := 0; i < 50; i++ {
+			d := c.backoff(attempt)
+			if d <= 0 || d > ceiling {
+				t.Fatalf("backoff(%d) = %s, 預期在 (0, %s]", attempt, d, ceiling)
+			}
+		}
+	}
+
+	if d := New(Config{}).backoff(3); d != 0 {
+		t.Errorf("未設定間隔時 backoff = %s, 預期 0", d)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	t.Run("依 Retry-After 等待", func(t *testing.T) {
+		srv, calls := statusServer(t, []int{503, 200}, http.Header{"Retry-After": {"1"}})
+		cfg := testConfig()
+		cfg.MaxDelay = 2 * time.Second
+		c := New(cfg)
+
+		start := time.Now()
+		resp, err := c.Get(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		resp.Body.Close()
+
+		if elapsed := time.Since(start); elapsed < time.Second {
+			t.Errorf("等待 %s, 預期至少 1s", elapsed)
+		}
+		if resp.StatusCode != 200 || atomic.LoadInt32(calls) != 2 {
+			t.Errorf("狀態碼 %d、請求次數 %d, 預期 200、2", resp.StatusCode, atomic.LoadInt32(calls))
+		}
+	})
+
+	t.Run("超過上限時不重試", func(t *testing.T) {
+		srv, calls := statusServer(t, []int{503, 200}, http.Header{"Retry-After": {"120"}})
+		c := New(testConfig())
+
+		start := time.Now()
+		resp, err := c.Get(context.Background(), srv.URL)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != 503 || atomic.LoadInt32(calls) != 1 {
+			t.Errorf("狀態碼 %d、請求次數 %d, 預期 503、1", resp.StatusCode, atomic.LoadInt32(calls))
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("等待 %s, 預期直接返回", elapsed)
+		}
+	})
+
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"3", 3 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.value != "" {
+			resp.Header.Set("Retry-After", tt.value)
+		}
+		got, ok := retryAfter(resp)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %v, 預期 %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestBreakerTransitions(t *testing.T) {
+	var healthy atomic.Bool
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if healthy.Load() {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.MaxRetries = 0
+	cfg.BreakerThreshold = 2
+	cfg.BreakerCooldown = 50 * time.Millisecond
+	c := New(cfg)
+	host := srv.Listener.Addr().String()
+
+	get := func() error {
+		resp, err := c.Get(context.Background(), srv.URL)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	assertState := func(want string) {
+		t.Helper()
+		if got := c.BreakerStates()[host]; got != want {
+			t.Fatalf("熔斷狀態 = %s, 預期 %s", got, want)
+		}
+	}
+
+	// closed：未達門檻前照常送出
+	get()
+	assertState("closed")
+
+	// closed -> open：連續失敗達門檻
+	get()
+	assertState("open")
+	before := atomic.LoadInt32(&calls)
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("熔斷中 err = %v, 預期 ErrCircuitOpen", err)
+	}
+	if atomic.LoadInt32(&calls) != before {
+		t.Fatal("熔斷中不應送出請求")
+	}
+
+	// open -> half_open：冷卻後試探失敗，重新熔斷
+	time.Sleep(60 * time.Millisecond)
+	assertState("half_open")
+	get()
+	assertState("open")
+
+	// half_open -> closed：冷卻後試探成功
+	time.Sleep(60 * time.Millisecond)
+	assertState("half_open")
+	healthy.Store(true)
+	if err := get(); err != nil {
+		t.Fatalf("試探請求失敗: %v", err)
+	}
+	assertState("closed")
+}
+
+func TestBreakerSingleProbe(t *testing.T) {
+	b := &breaker{threshold: 1, cooldown: 10 * time.Millisecond}
+	b.failure()
+	if b.allow() {
+		t.Fatal("熔斷中不應放行")
+	}
+
+	time.Sleep(15 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("冷卻後應放行試探請求")
+	}
+	if b.allow() {
+		t.Fatal("試探中只放行一個請求")
+	}
+
+	// 試探請求被取消時釋放名額，下一個請求可再試探
+	b.release()
+	if !b.allow() {
+		t.Fatal("釋放試探名額後應可再試探")
+	}
+}
@@ -0,0 +1,313 @@
+package market
+
+import (
+	"math"
+	"time"
+	"vpp-go/internal/models"
+)
+
+const (
+	// bidStepMW 投標量的最小單位
+	bidStepMW = 0.1
+	// energyLevels 動態規劃的電量離散等級數
+	energyLevels = 200
+	// minPriceDays 同類型日（平日/週末）的歷史天數少於此值時改用全部歷史
+	minPriceDays = 3
+)
+
+// HourlyPrice 單一小時各商品的預期價格（元/MWh）
+type HourlyPrice struct {
+	SR   float64 `json:"sr"`  // 容量價格加效能價格
+	SUP  float64 `json:"sup"` // 容量價格
+	Days int     `json:"days"`
+}
+
+// ForecastPrices 以歷史結清價格的同小時平均估計目標日的預期價格
+// 目標日為週末時只取週末的歷史（平日亦同），天數不足時改用全部歷史
+func ForecastPrices(history []models.TaipowerReserveData, date time.Time, grade int) [24]HourlyPrice {
+	weekend := isWeekend(date)
+	sameType := make([]models.TaipowerReserveData, 0, len(history))
+	days := make(map[string]bool)
+	for _, p := range history {
+		if isWeekend(p.TranDate) == weekend {
+			sameType = append(sameType, p)
+			days[p.TranDate.Format("2006-01-02")] = true
+		}
+	}
+	if len(days) >= minPriceDays {
+		history = sameType
+	}
+
+	var prices [24]HourlyPrice
+	for _, p := range history {
+		if p.TranHour < 0 || p.TranHour > 23 {
+			continue
+		}
+		prices[p.TranHour].SR += p.SRPrice + performancePrice(p, grade)
+		prices[p.TranHour].SUP += p.SUPPrice
+		prices[p.TranHour].Days++
+	}
+	for h := range prices {
+		if n := float64(prices[h].Days); n > 0 {
+			prices[h].SR = round2(prices[h].SR / n)
+			prices[h].SUP = round2(prices[h].SUP / n)
+		}
+	}
+	return prices
+}
+
+// isWeekend 是否為週六或週日
+func isWeekend(date time.Time) bool {
+	return date.Weekday() == time.Saturday || date.Weekday() == time.Sunday
+}
+
+// SiteState 場站儲能條件
+type SiteState struct {
+	SiteID      string
+	PowerKW     float64
+	CapacityKWh float64
+	SoC         float64     // 計畫開始時的電量狀態 %
+	SolarKW     [24]float64 // 各小時預期太陽能出力
+}
+
+// BidParams 投標限制
+type BidParams struct {
+	MinSoC           float64 // %
+	MaxSoC           float64 // %
+	ActivationRate   float64
+	ChargeEfficiency float64
+	MinBidMW         float64 // 低於此值不投標
+	MaxSRMW          float64 // 0 表示不限
+	MaxSUPMW         float64 // 0 表示不限
+}
+
+// Optimize 依預期價格與各場站儲能條件產生 24 小時各商品的投標量，最大化預期收益
+// 每小時各場站可投標的容量為額定功率與可持續放電一小時的能量（保留 MinSoC）之較小者。
+// 投標被調度時依 ActivationRate 消耗電量、太陽能可充電（不超過 MaxSoC），因此先將全部場站視為
+// 一個儲能系統，以動態規劃求出各小時的最佳投標量（低價時段保留電量給高價時段），
+// 再依各場站可投標容量的比例分配
+func Optimize(prices [24]HourlyPrice, sites []SiteState, params BidParams) []models.BidPlanHour {
+	var totalPowerKW, totalMinKWh, totalMaxKWh float64
+	var totalSolarKW [24]float64
+	energy := make([]float64, len(sites))
+	for i, site := range sites {
+		minKWh, maxKWh := site.CapacityKWh*params.MinSoC/100, site.CapacityKWh*params.MaxSoC/100
+		energy[i] = clamp(site.CapacityKWh*site.SoC/100, minKWh, maxKWh)
+		totalPowerKW += site.PowerKW
+		totalMinKWh += minKWh
+		totalMaxKWh += maxKWh
+		for h := range totalSolarKW {
+			totalSolarKW[h] += site.SolarKW[h]
+		}
+	}
+
+	// value[h][level] 第 h 小時開始時電量為 level 之後可得的最大預期收益
+	step := math.Max(totalMaxKWh/energyLevels, 1e-9)
+	var value [25][energyLevels + 1]float64
+	for h := 23; h >= 0; h-- {
+		for level := 0; level <= energyLevels; level++ {
+			e := float64(level) * step
+			best := 0.0
+			for _, bid := range bidOptions(math.Min(totalPowerKW, e-totalMinKWh)) {
+				next := clamp(e-params.ActivationRate*bid*1000+totalSolarKW[h]*params.ChargeEfficiency, 0, totalMaxKWh)
+				v := hourRevenue(bid, prices[h], params) + interpolate(value[h+1][:], next/step)
+				if v > best+1e-9 {
+					best = v
+				}
+			}
+			value[h][level] = best
+		}
+	}
+
+	hours := make([]models.BidPlanHour, 0, 48)
+	offers := make([]float64, len(sites))
+	for h := 0; h < 24; h++ {
+		// 各場站本小時可投標的容量 (kW)
+		var totalOfferKW, totalEnergy float64
+		for i, site := range sites {
+			headroom := energy[i] - site.CapacityKWh*params.MinSoC/100
+			offers[i] = math.Max(0, math.Min(site.PowerKW, headroom))
+			totalOfferKW += offers[i]
+			totalEnergy += energy[i]
+		}
+
+		// 依動態規劃的結果選擇本小時的投標量
+		bid, best := 0.0, -1.0
+		for _, option := range bidOptions(totalOfferKW) {
+			next := clamp(totalEnergy-params.ActivationRate*option*1000+totalSolarKW[h]*params.ChargeEfficiency, 0, totalMaxKWh)
+			v := hourRevenue(option, prices[h], params) + interpolate(value[h+1][:], next/step)
+			if v > best+1e-9 {
+				bid, best = option, v
+			}
+		}
+
+		srBid, supBid := splitBid(bid, prices[h], params)
+		used := make([]float64, len(sites))
+		for _, product := range []struct {
+			name     string
+			capacity float64
+			price    float64
+		}{
+			{models.ProductSR, srBid, prices[h].SR},
+			{models.ProductSUP, supBid, prices[h].SUP},
+		} {
+			capacity, price := product.capacity, product.price
+
+			// 依各場站可投標容量的比例分配
+			allocation := make(map[string]float64, len(sites))
+			for i, site := range sites {
+				share := 0.0
+				if totalOfferKW > 0 {
+					share = capacity * offers[i] / totalOfferKW
+				}
+				used[i] += share * 1000
+				allocation[site.SiteID] = math.Round(share*1000) / 1000
+			}
+
+			hours = append(hours, models.BidPlanHour{
+				Hour:            h,
+				Product:         product.name,
+				CapacityMW:      capacity,
+				ExpectedPrice:   price,
+				ExpectedRevenue: round2(capacity * price),
+				Sites:           allocation,
+			})
+		}
+
+		// 推算下一小時的電量
+		for i, site := range sites {
+			energy[i] -= params.ActivationRate * used[i]
+			energy[i] += site.SolarKW[h] * params.ChargeEfficiency
+			energy[i] = clamp(energy[i], 0, site.CapacityKWh*params.MaxSoC/100)
+		}
+	}
+
+	return hours
+}
+
+// bidOptions 返回可投標的總容量選項 (MW)，以 bidStepMW 為單位
+func bidOptions(maxKW float64) []float64 {
+	n := int(math.Floor(math.Max(0, maxKW)/1000/bidStepMW + 1e-9))
+	options := make([]float64, n+1)
+	for i := range options {
+		options[i] = math.Round(float64(i)*bidStepMW*10) / 10
+	}
+	return options
+}
+
+// splitBid 將總投標量分配給各商品：先填滿預期價格較高的商品（受商品上限限制），餘量給另一個商品。
+// 預期價格不為正或低於 MinBidMW 的部分不投標
+func splitBid(total float64, price HourlyPrice, params BidParams) (sr, sup float64) {
+	products := [2]struct {
+		bid   *float64
+		price float64
+		max   float64
+	}{
+		{&sr, price.SR, params.MaxSRMW},
+		{&sup, price.SUP, params.MaxSUPMW},
+	}
+	if products[1].price > products[0].price {
+		products[0], products[1] = products[1], products[0]
+	}
+
+	remaining := total
+	for _, product := range products {
+		bid := 0.0
+		if product.price > 0 {
+			bid = remaining
+			if product.max > 0 {
+				bid = math.Min(bid, product.max)
+			}
+			bid = math.Floor(bid/bidStepMW+1e-9) * bidStepMW
+			if bid < params.MinBidMW {
+				bid = 0
+			}
+		}
+		bid = math.Round(bid*10) / 10
+		*product.bid = bid
+		remaining -= bid
+	}
+	return sr, sup
+}
+
+// hourRevenue 單一小時投標總量的預期收益
+func hourRevenue(total float64, price HourlyPrice, params BidParams) float64 {
+	sr, sup := splitBid(total, price, params)
+	return sr*price.SR + sup*price.SUP
+}
+
+// interpolate 以線性內插取得非整數電量等級的收益
+func interpolate(values []float64, level float64) float64 {
+	if level <= 0 {
+		return values[0]
+	}
+	last := len(values) - 1
+	if level >= float64(last) {
+		return values[last]
+	}
+	i := int(level)
+	frac := level - float64(i)
+	return values[i]*(1-frac) + values[i+1]*frac
+}
+
+// clamp 將數值限制在區間內
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
+
+// HourComparison 單一小時單一商品的計畫與實際比較
+type HourComparison struct {
+	Hour            int      `json:"hour"`
+	Product         string   `json:"product"`
+	CapacityMW      float64  `json:"capacity_mw"`
+	ExpectedPrice   float64  `json:"expected_price"`
+	ActualPrice     *float64 `json:"actual_price"`
+	ExpectedRevenue float64  `json:"expected_revenue"`
+	ActualRevenue   *float64 `json:"actual_revenue"`
+}
+
+// Comparison 投標計畫與實際結清價格的比較，缺少價格的時段不計入實際收益
+type Comparison struct {
+	PricedHours     int              `json:"priced_hours"`
+	ExpectedRevenue float64          `json:"expected_revenue"` // 僅計有實際價格的時段
+	ActualRevenue   float64          `json:"actual_revenue"`
+	Difference      float64          `json:"difference"`
+	Hours           []HourComparison `json:"hours"`
+}
+
+// Compare 以計畫日的實際結清價格計算投標計畫的實際收益
+func Compare(plan *models.BidPlan, prices []models.TaipowerReserveData) *Comparison {
+	byHour := make(map[int]models.TaipowerReserveData, len(prices))
+	for _, p := range prices {
+		byHour[p.TranHour] = p
+	}
+
+	result := &Comparison{Hours: make([]HourComparison, 0, len(plan.Hours))}
+	for _, hour := range plan.Hours {
+		item := HourComparison{
+			Hour:            hour.Hour,
+			Product:         hour.Product,
+			CapacityMW:      hour.CapacityMW,
+			ExpectedPrice:   hour.ExpectedPrice,
+			ExpectedRevenue: hour.ExpectedRevenue,
+		}
+		if p, ok := byHour[hour.Hour]; ok {
+			price := p.SUPPrice
+			if hour.Product == models.ProductSR {
+				price = p.SRPrice + performancePrice(p, plan.PerformanceGrade)
+			}
+			revenue := round2(hour.CapacityMW * price)
+			item.ActualPrice = &price
+			item.ActualRevenue = &revenue
+			result.ExpectedRevenue += hour.ExpectedRevenue
+			result.ActualRevenue += revenue
+		}
+		result.Hours = append(result.Hours, item)
+	}
+
+	result.PricedHours = len(byHour)
+	result.ExpectedRevenue = round2(result.ExpectedRevenue)
+	result.ActualRevenue = round2(result.ActualRevenue)
+	result.Difference = round2(result.ActualRevenue - result.ExpectedRevenue)
+	return result
+}
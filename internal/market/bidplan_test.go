@@ -0,0 +1,188 @@
+package market
+
+import (
+	"math"
+	"testing"
+	"vpp-go/internal/models"
+)
+
+// flatPrices 24 小時相同的預期價格
+func flatPrices(sr, sup float64) [24]HourlyPrice {
+	var prices [24]HourlyPrice
+	for h := range prices {
+		prices[h] = HourlyPrice{SR: sr, SUP: sup, Days: 7}
+	}
+	return prices
+}
+
+// bids 依小時整理各商品的投標量 (MW)
+func bids(hours []models.BidPlanHour) (sr, sup [24]float64) {
+	for _, hour := range hours {
+		if hour.Product == models.ProductSR {
+			sr[hour.Hour] = hour.CapacityMW
+		} else {
+			sup[hour.Hour] = hour.CapacityMW
+		}
+	}
+	return sr, sup
+}
+
+// total 全日投標總量 (MW)
+func total(sr, sup [24]float64) float64 {
+	sum := 0.0
+	for h := range sr {
+		sum += sr[h] + sup[h]
+	}
+	return math.Round(sum*10) / 10
+}
+
+func TestOptimize(t *testing.T) {
+	params := BidParams{MinSoC: 10, MaxSoC: 90, ActivationRate: 1, ChargeEfficiency: 1}
+
+	// 18 時價格遠高於其他時段
+	peak := flatPrices(10, 0)
+	peak[18].SR = 1000
+
+	// 0 時有大量太陽能可充電
+	var morningSolar [24]float64
+	morningSolar[0] = 2000
+
+	tests := []struct {
+		name   string
+		prices [24]HourlyPrice
+		site   SiteState
+		params BidParams
+		check  func(t *testing.T, sr, sup [24]float64)
+	}{
+		{
+			name:   "電量在下限時不投標",
+			prices: flatPrices(300, 100),
+			site:   SiteState{SiteID: "north", PowerKW: 1000, CapacityKWh: 1000, SoC: 10},
+			params: params,
+			check: func(t *testing.T, sr, sup [24]float64) {
+				if got := total(sr, sup); got != 0 {
+					t.Errorf("投標總量 = %g, 預期 0", got)
+				}
+			},
+		},
+		{
+			name:   "可放電量以下限為底",
+			prices: flatPrices(300, 100),
+			site:   SiteState{SiteID: "north", PowerKW: 1000, CapacityKWh: 1000, SoC: 40},
+			params: params,
+			check: func(t *testing.T, sr, sup [24]float64) {
+				// 40% 至 10% 之間只有 300 kWh，每 MW 調度消耗 1000 kWh
+				if got := total(sr, sup); got != 0.3 {
+					t.Errorf("投標總量 = %g, 預期 0.3", got)
+				}
+			},
+		},
+		{
+			name:   "太陽能充電不超過上限",
+			prices: flatPrices(300, 0),
+			site:   SiteState{SiteID: "north", PowerKW: 1000, CapacityKWh: 1000, SoC: 20, SolarKW: morningSolar},
+			params: params,
+			check: func(t *testing.T, sr, sup [24]float64) {
+				// 起始 100 kWh 可放電，充電後最多到 90%，再放到 10% 為 800 kWh
+				if got := total(sr, sup); got != 0.9 {
+					t.Errorf("投標總量 = %g, 預期 0.9", got)
+				}
+			},
+		},
+		{
+			name:   "保留電量給後面的高價時段",
+			prices: peak,
+			site:   SiteState{SiteID: "north", PowerKW: 1000, CapacityKWh: 1000, SoC: 60},
+			params: params,
+			check: func(t *testing.T, sr, sup [24]float64) {
+				for h := 0; h < 18; h++ {
+					if sr[h] != 0 {
+						t.Errorf("%d 時投標 %g MW, 預期保留電量", h, sr[h])
+					}
+				}
+				if sr[18] != 0.5 {
+					t.Errorf("18 時投標 %g MW, 預期 0.5", sr[18])
+				}
+			},
+		},
+		{
+			name:   "即時備轉價格較高時先填滿即時備轉",
+			prices: flatPrices(300, 100),
+			site:   SiteState{SiteID: "north", PowerKW: 1000, CapacityKWh: 1000, SoC: 90},
+			params: BidParams{MinSoC: 10, MaxSoC: 90, MaxSRMW: 0.3},
+			check: func(t *testing.T, sr, sup [24]float64) {
+				for h := range sr {
+					if sr[h] != 0.3 || sup[h] != 0.5 {
+						t.Fatalf("%d 時 SR %g、SUP %g, 預期 0.3、0.5", h, sr[h], sup[h])
+					}
+				}
+			},
+		},
+		{
+			name:   "補充備轉價格較高時先填滿補充備轉",
+			prices: flatPrices(100, 300),
+			site:   SiteState{SiteID: "north", PowerKW: 1000, CapacityKWh: 1000, SoC: 90},
+			params: BidParams{MinSoC: 10, MaxSoC: 90, MaxSUPMW: 0.2},
+			check: func(t *testing.T, sr, sup [24]float64) {
+				for h := range sr {
+					if sup[h] != 0.2 || sr[h] != 0.6 {
+						t.Fatalf("%d 時 SR %g、SUP %g, 預期 0.6、0.2", h, sr[h], sup[h])
+					}
+				}
+			},
+		},
+		{
+			name:   "低於最小投標量的商品不投標",
+			prices: flatPrices(300, 100),
+			site:   SiteState{SiteID: "north", PowerKW: 1000, CapacityKWh: 1000, SoC: 90},
+			params: BidParams{MinSoC: 10, MaxSoC: 90, MaxSRMW: 0.3, MinBidMW: 0.4},
+			check: func(t *testing.T, sr, sup [24]float64) {
+				if sr[0] != 0 || sup[0] != 0.8 {
+					t.Errorf("SR %g、SUP %g, 預期 0、0.8", sr[0], sup[0])
+				}
+			},
+		},
+		{
+			name:   "價格不為正的商品不投標",
+			prices: flatPrices(300, 0),
+			site:   SiteState{SiteID: "north", PowerKW: 1000, CapacityKWh: 1000, SoC: 90},
+			params: BidParams{MinSoC: 10, MaxSoC: 90, MaxSRMW: 0.3},
+			check: func(t *testing.T, sr, sup [24]float64) {
+				if sr[0] != 0.3 || sup[0] != 0 {
+					t.Errorf("SR %g、SUP %g, 預期 0.3、0", sr[0], sup[0])
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hours := Optimize(tt.prices, []SiteState{tt.site}, tt.params)
+			if len(hours) != 48 {
+				t.Fatalf("返回 %d 筆, 預期 24 小時各兩個商品", len(hours))
+			}
+			sr, sup := bids(hours)
+			tt.check(t, sr, sup)
+		})
+	}
+}
+
+func TestOptimizeAllocation(t *testing.T) {
+	sites := []SiteState{
+		{SiteID: "north", PowerKW: 600, CapacityKWh: 2000, SoC: 90},
+		{SiteID: "south", PowerKW: 200, CapacityKWh: 2000, SoC: 90},
+	}
+	hours := Optimize(flatPrices(300, 0), sites, BidParams{MinSoC: 10, MaxSoC: 90})
+
+	first := hours[0]
+	if first.Product != models.ProductSR || first.CapacityMW != 0.8 {
+		t.Fatalf("首筆 = %+v, 預期即時備轉 0.8 MW", first)
+	}
+	// 依各場站可投標容量 600:200 分配
+	if first.Sites["north"] != 0.6 || first.Sites["south"] != 0.2 {
+		t.Errorf("分配 = %v, 預期 north 0.6、south 0.2", first.Sites)
+	}
+	if first.ExpectedRevenue != 240 {
+		t.Errorf("預期收益 = %g, 預期 240", first.ExpectedRevenue)
+	}
+}
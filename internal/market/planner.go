@@ -0,0 +1,264 @@
+package market
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/models"
+)
+
+// maxLookbackDays 歷史天數上限
+const maxLookbackDays = 365
+
+// BidPlanRequest 投標計畫請求
+type BidPlanRequest struct {
+	Date             string   `json:"date"`     // 計畫日期 YYYY-MM-DD，預設為明天
+	SiteIDs          []string `json:"site_ids"` // 參與投標的場站，預設為全部
+	LookbackDays     int      `json:"lookback_days"`
+	PerformanceGrade int      `json:"performance_grade"`
+	MinBidMW         float64  `json:"min_bid_mw"`
+	MaxSRMW          float64  `json:"max_sr_mw"`
+	MaxSUPMW         float64  `json:"max_sup_mw"`
+}
+
+// Planner 產生並保存次日投標計畫
+type Planner struct {
+	Taipower  *models.TaipowerReserveModel
+	Solar     *models.SolarDataModel
+	Forecasts *models.SolarForecastModel
+	Storage   *models.StorageDataModel
+	Plans     *models.BidPlanModel
+
+	Sites    []config.SiteConfig
+	Config   config.MarketConfig
+	Location *time.Location
+}
+
+// NewPlanner 創建投標計畫產生器
+func NewPlanner(db *sql.DB, cfg config.MarketConfig, sites []config.SiteConfig, location *time.Location) *Planner {
+	return &Planner{
+		Taipower:  models.NewTaipowerReserveModel(db),
+		Solar:     models.NewSolarDataModel(db),
+		Forecasts: models.NewSolarForecastModel(db),
+		Storage:   models.NewStorageDataModel(db),
+		Plans:     models.NewBidPlanModel(db),
+		Sites:     sites,
+		Config:    cfg,
+		Location:  location,
+	}
+}
+
+// validate 驗證請求並補上預設值，返回計畫日期與參與的場站
+func (p *Planner) validate(req *BidPlanRequest, now time.Time) (time.Time, []config.SiteConfig, error) {
+	var errs []string
+
+	today := now.In(p.Location)
+	date := time.Date(today.Year(), today.Month(), today.Day()+1, 0, 0, 0, 0, p.Location)
+	if req.Date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.Date, p.Location)
+		if err != nil {
+			errs = append(errs, "date: 無效的日期格式，請使用 YYYY-MM-DD")
+		}
+		date = parsed
+	}
+
+	if req.LookbackDays == 0 {
+		req.LookbackDays = p.Config.LookbackDays
+	}
+	if req.LookbackDays < 1 || req.LookbackDays > maxLookbackDays {
+		errs = append(errs, fmt.Sprintf("lookback_days: 須介於 1 ~ %d", maxLookbackDays))
+	}
+
+	if req.PerformanceGrade == 0 {
+		req.PerformanceGrade = 1
+	}
+	if req.PerformanceGrade < 1 || req.PerformanceGrade > 3 {
+		errs = append(errs, "performance_grade: 效能級數須為 1、2 或 3")
+	}
+
+	if req.MinBidMW < 0 || req.MaxSRMW < 0 || req.MaxSUPMW < 0 {
+		errs = append(errs, "min_bid_mw、max_sr_mw、max_sup_mw 不可為負值")
+	}
+
+	sites := p.Sites
+	if len(req.SiteIDs) > 0 {
+		sites = make([]config.SiteConfig, 0, len(req.SiteIDs))
+		for _, id := range req.SiteIDs {
+			site, ok := p.site(id)
+			if !ok {
+				errs = append(errs, fmt.Sprintf("site_ids: 無效的場站ID %q", id))
+				continue
+			}
+			sites = append(sites, site)
+		}
+	}
+
+	if len(errs) > 0 {
+		return time.Time{}, nil, &ValidationError{Errors: errs}
+	}
+	return date, sites, nil
+}
+
+// site 依ID獲取場站配置
+func (p *Planner) site(id string) (config.SiteConfig, bool) {
+	for _, site := range p.Sites {
+		if site.ID == id {
+			return site, true
+		}
+	}
+	return config.SiteConfig{}, false
+}
+
+// Create 產生投標計畫並保存
+func (p *Planner) Create(req *BidPlanRequest, now time.Time) (*models.BidPlan, error) {
+	date, sites, err := p.validate(req, now)
+	if err != nil {
+		return nil, err
+	}
+
+	from := date.AddDate(0, 0, -req.LookbackDays)
+	history, err := p.Taipower.GetRange(from, date.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, fmt.Errorf("查詢歷史備轉價格失敗: %w", err)
+	}
+	prices := ForecastPrices(history, date, req.PerformanceGrade)
+
+	plan := &models.BidPlan{
+		PlanDate:         date,
+		PerformanceGrade: req.PerformanceGrade,
+		LookbackDays:     req.LookbackDays,
+		Sites:            make([]models.BidPlanSite, 0, len(sites)),
+	}
+
+	var states []SiteState
+	for _, site := range sites {
+		state, info, err := p.siteState(site, from, date)
+		if err != nil {
+			return nil, fmt.Errorf("查詢場站 %s 儲能條件失敗: %w", site.ID, err)
+		}
+		plan.Sites = append(plan.Sites, info)
+		if state != nil {
+			states = append(states, *state)
+		}
+	}
+
+	plan.Hours = Optimize(prices, states, BidParams{
+		MinSoC:           p.Config.MinSoC,
+		MaxSoC:           p.Config.MaxSoC,
+		ActivationRate:   p.Config.ActivationRate,
+		ChargeEfficiency: p.Config.ChargeEfficiency,
+		MinBidMW:         req.MinBidMW,
+		MaxSRMW:          req.MaxSRMW,
+		MaxSUPMW:         req.MaxSUPMW,
+	})
+	for _, hour := range plan.Hours {
+		plan.ExpectedRevenue += hour.ExpectedRevenue
+	}
+	plan.ExpectedRevenue = round2(plan.ExpectedRevenue)
+
+	if err := p.Plans.Insert(plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// siteState 取得場站的儲能條件與太陽能出力，不參與投標時 state 為 nil 並記錄原因
+func (p *Planner) siteState(site config.SiteConfig, from, date time.Time) (*SiteState, models.BidPlanSite, error) {
+	info := models.BidPlanSite{SiteID: site.ID, PowerKW: site.StoragePowerKW, CapacityKWh: site.StorageCapacityKWh}
+	if site.StoragePowerKW <= 0 {
+		info.Skipped = "未配置儲能額定功率"
+		return nil, info, nil
+	}
+
+	latest, err := p.Storage.GetLatest(site.ID)
+	if err != nil {
+		return nil, info, err
+	}
+	if latest == nil {
+		info.Skipped = "無儲能遙測數據"
+		return nil, info, nil
+	}
+	info.SoC = latest.SoC
+
+	// 未配置容量時由可用能量與電量狀態推算
+	if info.CapacityKWh <= 0 && latest.SoC > 0 {
+		info.CapacityKWh = round2(latest.AvailableEnergy / (latest.SoC / 100))
+	}
+	if info.CapacityKWh <= 0 {
+		info.Skipped = "無法推算儲能容量"
+		return nil, info, nil
+	}
+
+	solar, source, err := p.solarProfile(site.ID, from, date)
+	if err != nil {
+		return nil, info, err
+	}
+	info.SolarSource = source
+	for _, kw := range solar {
+		info.SolarKWh += kw
+	}
+	info.SolarKWh = round2(info.SolarKWh)
+
+	return &SiteState{
+		SiteID:      site.ID,
+		PowerKW:     site.StoragePowerKW,
+		CapacityKWh: info.CapacityKWh,
+		SoC:         latest.SoC,
+		SolarKW:     solar,
+	}, info, nil
+}
+
+// solarProfile 取得計畫日各小時的預期太陽能出力 (kW)
+// 優先使用最新一次太陽能預測中計畫日的預測值，預測未涵蓋全部 24 小時時改用歷史同小時平均
+func (p *Planner) solarProfile(siteID string, from, date time.Time) ([24]float64, string, error) {
+	run, err := p.Forecasts.GetLatestRun(siteID)
+	if err != nil {
+		return [24]float64{}, "", err
+	}
+	if run != nil {
+		forecasts, err := p.Forecasts.GetForecasts(run.ID, date)
+		if err != nil {
+			return [24]float64{}, "", err
+		}
+		if profile, ok := hourlyForecast(forecasts, date, p.Location); ok {
+			return profile, models.SolarSourceForecast, nil
+		}
+	}
+
+	profile, _, err := p.Solar.GetHourlyProfile(siteID, from, date, p.Location)
+	return profile, models.SolarSourceHistory, err
+}
+
+// hourlyForecast 將計畫日的預測值依小時平均，任一小時沒有預測值時 ok 為 false
+func hourlyForecast(forecasts []models.SolarForecast, date time.Time, location *time.Location) ([24]float64, bool) {
+	var sum [24]float64
+	var count [24]int
+	end := date.AddDate(0, 0, 1)
+	for _, f := range forecasts {
+		if f.TargetTime.Before(date) || !f.TargetTime.Before(end) {
+			continue
+		}
+		h := f.TargetTime.In(location).Hour()
+		sum[h] += f.PowerKW
+		count[h]++
+	}
+
+	var profile [24]float64
+	for h := range profile {
+		if count[h] == 0 {
+			return profile, false
+		}
+		profile[h] = sum[h] / float64(count[h])
+	}
+	return profile, true
+}
+
+// Compare 以計畫日的實際結清價格比較投標計畫的預期與實際收益
+func (p *Planner) Compare(plan *models.BidPlan) (*Comparison, error) {
+	prices, err := p.Taipower.GetByDate(plan.PlanDate)
+	if err != nil {
+		return nil, err
+	}
+	return Compare(plan, prices), nil
+}
@@ -0,0 +1,42 @@
+package market
+
+import (
+	"testing"
+	"time"
+	"vpp-go/internal/models"
+)
+
+func TestHourlyForecast(t *testing.T) {
+	location := time.FixedZone("Asia/Taipei", 8*3600)
+	date := time.Date(2024, 6, 2, 0, 0, 0, 0, location)
+
+	// 前一天及計畫日 15 分鐘一筆的預測值，功率等於當地小時數
+	var forecasts []models.SolarForecast
+	for ts := date.Add(-2 * time.Hour); ts.Before(date.AddDate(0, 0, 1).Add(2 * time.Hour)); ts = ts.Add(15 * time.Minute) {
+		forecasts = append(forecasts, models.SolarForecast{TargetTime: ts.UTC(), PowerKW: float64(ts.Hour())})
+	}
+
+	profile, ok := hourlyForecast(forecasts, date, location)
+	if !ok {
+		t.Fatal("預測涵蓋全天時應使用預測值")
+	}
+	for h, kw := range profile {
+		if kw != float64(h) {
+			t.Errorf("%d 時 = %g, 預期 %d", h, kw, h)
+		}
+	}
+
+	// 缺少 13 時的預測值時改用歷史
+	var partial []models.SolarForecast
+	for _, f := range forecasts {
+		if f.TargetTime.In(location).Hour() != 13 {
+			partial = append(partial, f)
+		}
+	}
+	if _, ok := hourlyForecast(partial, date, location); ok {
+		t.Error("預測未涵蓋全天時不應使用")
+	}
+	if _, ok := hourlyForecast(nil, date, location); ok {
+		t.Error("沒有預測值時不應使用")
+	}
+}
@@ -0,0 +1,260 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"vpp-go/internal/models"
+)
+
+const (
+	// maxRevenueDays 單次試算的日期區間上限
+	maxRevenueDays = 366
+	// maxCapacityMW 單一時段的登錄容量上限
+	maxCapacityMW = 1000
+)
+
+// 彙總週期
+const (
+	GroupByHour  = "hour"
+	GroupByDay   = "day"
+	GroupByMonth = "month"
+)
+
+// ValidationError 請求驗證錯誤
+type ValidationError struct {
+	Errors []string
+}
+
+// Error 實作 error 介面
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Errors, "; ")
+}
+
+// CapacityProfile 各小時的登錄容量（MW）
+// HourlyMW 有 24 個值時依小時取值，否則每小時皆為 CapacityMW
+type CapacityProfile struct {
+	CapacityMW float64   `json:"capacity_mw"`
+	HourlyMW   []float64 `json:"hourly_mw"`
+}
+
+// At 返回指定小時的登錄容量
+func (p *CapacityProfile) At(hour int) float64 {
+	if p == nil {
+		return 0
+	}
+	if len(p.HourlyMW) == 24 {
+		return p.HourlyMW[hour]
+	}
+	return p.CapacityMW
+}
+
+// validate 驗證容量設定
+func (p *CapacityProfile) validate(field string) []string {
+	if p == nil {
+		return nil
+	}
+	var errs []string
+	if len(p.HourlyMW) > 0 && len(p.HourlyMW) != 24 {
+		errs = append(errs, field+".hourly_mw: 須為 24 個小時的容量")
+	}
+	values := append([]float64{p.CapacityMW}, p.HourlyMW...)
+	for _, v := range values {
+		if math.IsNaN(v) || v < 0 || v > maxCapacityMW {
+			errs = append(errs, fmt.Sprintf("%s: 容量須介於 0 ~ %g MW", field, float64(maxCapacityMW)))
+			break
+		}
+	}
+	return errs
+}
+
+// RevenueRequest 輔助服務收益試算請求
+type RevenueRequest struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	// PerformanceGrade 即時備轉的效能級數（1-3），對應 sr_perf_price_1..3，預設 1
+	PerformanceGrade int              `json:"performance_grade"`
+	GroupBy          string           `json:"group_by"` // hour、day（預設）或 month
+	SR               *CapacityProfile `json:"sr"`
+	SUP              *CapacityProfile `json:"sup"`
+}
+
+// Validate 驗證請求並補上預設值，返回解析後的日期區間
+func (r *RevenueRequest) Validate() (time.Time, time.Time, error) {
+	var errs []string
+
+	startDate, err := time.Parse("2006-01-02", r.StartDate)
+	if err != nil {
+		errs = append(errs, "start_date: 無效的日期格式，請使用 YYYY-MM-DD")
+	}
+	endDate, err := time.Parse("2006-01-02", r.EndDate)
+	if err != nil {
+		errs = append(errs, "end_date: 無效的日期格式，請使用 YYYY-MM-DD")
+	}
+	if len(errs) == 0 {
+		switch days := int(endDate.Sub(startDate).Hours()/24) + 1; {
+		case days <= 0:
+			errs = append(errs, "end_date: 結束日期須不早於開始日期")
+		case days > maxRevenueDays:
+			errs = append(errs, fmt.Sprintf("end_date: 日期區間不可超過 %d 天", maxRevenueDays))
+		}
+	}
+
+	if r.PerformanceGrade == 0 {
+		r.PerformanceGrade = 1
+	}
+	if r.PerformanceGrade < 1 || r.PerformanceGrade > 3 {
+		errs = append(errs, "performance_grade: 效能級數須為 1、2 或 3")
+	}
+
+	if r.GroupBy == "" {
+		r.GroupBy = GroupByDay
+	}
+	switch r.GroupBy {
+	case GroupByHour, GroupByDay, GroupByMonth:
+	default:
+		errs = append(errs, "group_by: 須為 hour、day 或 month")
+	}
+
+	if r.SR == nil && r.SUP == nil {
+		errs = append(errs, "缺少 sr 或 sup 的登錄容量")
+	}
+	errs = append(errs, r.SR.validate("sr")...)
+	errs = append(errs, r.SUP.validate("sup")...)
+
+	if len(errs) > 0 {
+		return time.Time{}, time.Time{}, &ValidationError{Errors: errs}
+	}
+	return startDate, endDate, nil
+}
+
+// ServiceRevenue 單一服務的收益（元）
+type ServiceRevenue struct {
+	CapacityMWh    float64 `json:"capacity_mwh"`
+	CapacityFee    float64 `json:"capacity_fee"`
+	PerformanceFee float64 `json:"performance_fee"`
+	Total          float64 `json:"total"`
+}
+
+// add 累加一個小時的收益
+func (s *ServiceRevenue) add(capacityMW, capacityPrice, performancePrice float64) {
+	capacityFee := capacityMW * capacityPrice
+	performanceFee := capacityMW * performancePrice
+	s.CapacityMWh += capacityMW
+	s.CapacityFee += capacityFee
+	s.PerformanceFee += performanceFee
+	s.Total += capacityFee + performanceFee
+}
+
+// round 金額四捨五入至小數第二位
+func (s *ServiceRevenue) round() {
+	s.CapacityMWh = round2(s.CapacityMWh)
+	s.CapacityFee = round2(s.CapacityFee)
+	s.PerformanceFee = round2(s.PerformanceFee)
+	s.Total = round2(s.Total)
+}
+
+// RevenuePeriod 單一彙總週期的收益明細
+type RevenuePeriod struct {
+	Period string         `json:"period"`
+	Hours  int            `json:"hours"`
+	SR     ServiceRevenue `json:"sr"`
+	SUP    ServiceRevenue `json:"sup"`
+	Total  float64        `json:"total"`
+}
+
+// Revenue 輔助服務收益試算結果
+type Revenue struct {
+	StartDate        string          `json:"start_date"`
+	EndDate          string          `json:"end_date"`
+	PerformanceGrade int             `json:"performance_grade"`
+	GroupBy          string          `json:"group_by"`
+	PricedHours      int             `json:"priced_hours"`
+	MissingHours     int             `json:"missing_hours"` // 區間內缺少台電價格的時段數，不計收益
+	SR               ServiceRevenue  `json:"sr"`
+	SUP              ServiceRevenue  `json:"sup"`
+	Total            float64         `json:"total"`
+	Periods          []RevenuePeriod `json:"periods"`
+}
+
+// CalculateRevenue 依台電結清價格計算各時段的容量費與效能費
+// 容量費 = 登錄容量 × 結清價格；效能費 = 登錄容量 × 對應效能級數的效能價格。
+// 補充備轉未公告效能價格，只計容量費。prices 須依日期與小時排序
+func CalculateRevenue(req *RevenueRequest, startDate, endDate time.Time, prices []models.TaipowerReserveData) *Revenue {
+	result := &Revenue{
+		StartDate:        startDate.Format("2006-01-02"),
+		EndDate:          endDate.Format("2006-01-02"),
+		PerformanceGrade: req.PerformanceGrade,
+		GroupBy:          req.GroupBy,
+		Periods:          []RevenuePeriod{},
+	}
+
+	for _, p := range prices {
+		if p.TranHour < 0 || p.TranHour > 23 {
+			continue
+		}
+
+		key := periodKey(p.TranDate, p.TranHour, req.GroupBy)
+		if n := len(result.Periods); n == 0 || result.Periods[n-1].Period != key {
+			result.Periods = append(result.Periods, RevenuePeriod{Period: key})
+		}
+		period := &result.Periods[len(result.Periods)-1]
+
+		srCapacity := req.SR.At(p.TranHour)
+		supCapacity := req.SUP.At(p.TranHour)
+		srPerfPrice := performancePrice(p, req.PerformanceGrade)
+
+		period.Hours++
+		period.SR.add(srCapacity, p.SRPrice, srPerfPrice)
+		period.SUP.add(supCapacity, p.SUPPrice, 0)
+		result.SR.add(srCapacity, p.SRPrice, srPerfPrice)
+		result.SUP.add(supCapacity, p.SUPPrice, 0)
+		result.PricedHours++
+	}
+
+	for i := range result.Periods {
+		period := &result.Periods[i]
+		period.Total = round2(period.SR.Total + period.SUP.Total)
+		period.SR.round()
+		period.SUP.round()
+	}
+	result.Total = round2(result.SR.Total + result.SUP.Total)
+	result.SR.round()
+	result.SUP.round()
+
+	days := int(endDate.Sub(startDate).Hours()/24) + 1
+	if missing := days*24 - result.PricedHours; missing > 0 {
+		result.MissingHours = missing
+	}
+	return result
+}
+
+// performancePrice 返回效能級數對應的即時備轉效能價格
+func performancePrice(p models.TaipowerReserveData, grade int) float64 {
+	switch grade {
+	case 1:
+		return p.SRPerfPrice1
+	case 2:
+		return p.SRPerfPrice2
+	case 3:
+		return p.SRPerfPrice3
+	}
+	return 0
+}
+
+// periodKey 返回時段所屬的彙總週期
+func periodKey(date time.Time, hour int, groupBy string) string {
+	switch groupBy {
+	case GroupByHour:
+		return fmt.Sprintf("%s %02d:00", date.Format("2006-01-02"), hour)
+	case GroupByMonth:
+		return date.Format("2006-01")
+	}
+	return date.Format("2006-01-02")
+}
+
+// round2 四捨五入至小數第二位
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,204 @@
+package market
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+	"vpp-go/internal/models"
+)
+
+// price 單一時段的台電結清價格，效能價格依級數為 perf、2×perf、3×perf
+func price(date string, hour int, sr, sup, perf float64) models.TaipowerReserveData {
+	d, _ := time.Parse("2006-01-02", date)
+	return models.TaipowerReserveData{
+		TranDate: d, TranHour: hour,
+		SRPrice: sr, SUPPrice: sup,
+		SRPerfPrice1: perf, SRPerfPrice2: 2 * perf, SRPerfPrice3: 3 * perf,
+	}
+}
+
+// revenue 驗證請求後試算收益
+func revenue(t *testing.T, req *RevenueRequest, prices []models.TaipowerReserveData) *Revenue {
+	t.Helper()
+	start, end, err := req.Validate()
+	if err != nil {
+		t.Fatalf("Validate 錯誤: %v", err)
+	}
+	return CalculateRevenue(req, start, end, prices)
+}
+
+func TestCalculateRevenue(t *testing.T) {
+	day := []models.TaipowerReserveData{
+		price("2024-06-01", 0, 100, 50, 20),
+		price("2024-06-01", 1, 200, 60, 40),
+	}
+
+	tests := []struct {
+		name   string
+		req    RevenueRequest
+		prices []models.TaipowerReserveData
+		sr     ServiceRevenue
+		sup    ServiceRevenue
+	}{
+		{
+			name:   "即時備轉計容量費及效能費",
+			req:    RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", PerformanceGrade: 2, SR: &CapacityProfile{CapacityMW: 2}},
+			prices: day,
+			// 容量費 2×100 + 2×200；效能費 2×40 + 2×80
+			sr: ServiceRevenue{CapacityMWh: 4, CapacityFee: 600, PerformanceFee: 240, Total: 840},
+		},
+		{
+			name:   "效能級數預設為 1",
+			req:    RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", SR: &CapacityProfile{CapacityMW: 1}},
+			prices: day,
+			sr:     ServiceRevenue{CapacityMWh: 2, CapacityFee: 300, PerformanceFee: 60, Total: 360},
+		},
+		{
+			name:   "補充備轉只計容量費",
+			req:    RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", PerformanceGrade: 3, SUP: &CapacityProfile{CapacityMW: 1.5}},
+			prices: day,
+			sup:    ServiceRevenue{CapacityMWh: 3, CapacityFee: 165, Total: 165},
+		},
+		{
+			name: "逐時容量優先於固定容量",
+			req: RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", SR: &CapacityProfile{
+				CapacityMW: 10,
+				HourlyMW:   []float64{1, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+			}},
+			prices: day,
+			// 容量費 1×100 + 3×200；效能費 1×20 + 3×40
+			sr: ServiceRevenue{CapacityMWh: 4, CapacityFee: 700, PerformanceFee: 140, Total: 840},
+		},
+		{
+			name: "金額在累加後才四捨五入",
+			req:  RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", SUP: &CapacityProfile{CapacityMW: 1}},
+			prices: []models.TaipowerReserveData{
+				price("2024-06-01", 0, 0, 0.004, 0),
+				price("2024-06-01", 1, 0, 0.004, 0),
+				price("2024-06-01", 2, 0, 0.004, 0),
+			},
+			// 逐時四捨五入會得到 0
+			sup: ServiceRevenue{CapacityMWh: 3, CapacityFee: 0.01, Total: 0.01},
+		},
+		{
+			name: "時段超出 0~23 時不計",
+			req:  RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", SUP: &CapacityProfile{CapacityMW: 1}},
+			prices: []models.TaipowerReserveData{
+				price("2024-06-01", 23, 0, 10, 0),
+				price("2024-06-01", 24, 0, 10, 0),
+			},
+			sup: ServiceRevenue{CapacityMWh: 1, CapacityFee: 10, Total: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := revenue(t, &tt.req, tt.prices)
+			if result.SR != tt.sr {
+				t.Errorf("SR = %+v, 預期 %+v", result.SR, tt.sr)
+			}
+			if result.SUP != tt.sup {
+				t.Errorf("SUP = %+v, 預期 %+v", result.SUP, tt.sup)
+			}
+			if want := round2(tt.sr.Total + tt.sup.Total); result.Total != want {
+				t.Errorf("Total = %g, 預期 %g", result.Total, want)
+			}
+		})
+	}
+}
+
+func TestCalculateRevenueGroupBy(t *testing.T) {
+	prices := []models.TaipowerReserveData{
+		price("2024-01-31", 23, 100, 0, 0),
+		price("2024-02-01", 0, 100, 0, 0),
+		price("2024-02-01", 1, 200, 0, 0),
+	}
+
+	tests := []struct {
+		groupBy string
+		periods []string
+		hours   []int
+		totals  []float64
+	}{
+		{GroupByHour, []string{"2024-01-31 23:00", "2024-02-01 00:00", "2024-02-01 01:00"}, []int{1, 1, 1}, []float64{100, 100, 200}},
+		{GroupByDay, []string{"2024-01-31", "2024-02-01"}, []int{1, 2}, []float64{100, 300}},
+		{"", []string{"2024-01-31", "2024-02-01"}, []int{1, 2}, []float64{100, 300}},
+		{GroupByMonth, []string{"2024-01", "2024-02"}, []int{1, 2}, []float64{100, 300}},
+	}
+
+	for _, tt := range tests {
+		req := &RevenueRequest{StartDate: "2024-01-31", EndDate: "2024-02-01", GroupBy: tt.groupBy, SR: &CapacityProfile{CapacityMW: 1}}
+		result := revenue(t, req, prices)
+		if len(result.Periods) != len(tt.periods) {
+			t.Errorf("group_by %q: %d 個週期, 預期 %d", tt.groupBy, len(result.Periods), len(tt.periods))
+			continue
+		}
+		for i, period := range result.Periods {
+			if period.Period != tt.periods[i] || period.Hours != tt.hours[i] || period.Total != tt.totals[i] {
+				t.Errorf("group_by %q 週期 %d = %s (%d 小時, %g), 預期 %s (%d 小時, %g)",
+					tt.groupBy, i, period.Period, period.Hours, period.Total, tt.periods[i], tt.hours[i], tt.totals[i])
+			}
+		}
+	}
+}
+
+func TestCalculateRevenueMissingHours(t *testing.T) {
+	var prices []models.TaipowerReserveData
+	for h := 0; h < 24; h++ {
+		prices = append(prices, price("2024-06-01", h, 100, 0, 0))
+	}
+	prices = append(prices, price("2024-06-02", 0, 100, 0, 0))
+
+	req := &RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-03", SR: &CapacityProfile{CapacityMW: 1}}
+	result := revenue(t, req, prices)
+	if result.PricedHours != 25 || result.MissingHours != 47 {
+		t.Errorf("計價 %d 小時、缺少 %d 小時, 預期 25、47", result.PricedHours, result.MissingHours)
+	}
+	if result.Total != 2500 {
+		t.Errorf("Total = %g, 預期 2500", result.Total)
+	}
+}
+
+func TestRevenueRequestValidate(t *testing.T) {
+	sr := &CapacityProfile{CapacityMW: 1}
+
+	tests := []struct {
+		name string
+		req  RevenueRequest
+		want string
+	}{
+		{"開始日期格式", RevenueRequest{StartDate: "2024/06/01", EndDate: "2024-06-01", SR: sr}, "start_date: 無效的日期格式"},
+		{"結束日期格式", RevenueRequest{StartDate: "2024-06-01", EndDate: "", SR: sr}, "end_date: 無效的日期格式"},
+		{"結束早於開始", RevenueRequest{StartDate: "2024-06-02", EndDate: "2024-06-01", SR: sr}, "end_date: 結束日期須不早於開始日期"},
+		{"區間超過上限", RevenueRequest{StartDate: "2024-01-01", EndDate: "2025-01-01", SR: sr}, "end_date: 日期區間不可超過 366 天"},
+		{"效能級數", RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", PerformanceGrade: 4, SR: sr}, "performance_grade"},
+		{"彙總週期", RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", GroupBy: "week", SR: sr}, "group_by"},
+		{"缺少容量", RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01"}, "缺少 sr 或 sup 的登錄容量"},
+		{"逐時容量數量", RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", SR: &CapacityProfile{HourlyMW: make([]float64, 23)}}, "sr.hourly_mw: 須為 24 個小時的容量"},
+		{"負容量", RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", SUP: &CapacityProfile{CapacityMW: -1}}, "sup: 容量須介於"},
+		{"容量非數值", RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", SR: &CapacityProfile{CapacityMW: math.NaN()}}, "sr: 容量須介於"},
+		{"容量超過上限", RevenueRequest{StartDate: "2024-06-01", EndDate: "2024-06-01", SR: &CapacityProfile{CapacityMW: maxCapacityMW + 1}}, "sr: 容量須介於"},
+	}
+
+	for _, tt := range tests {
+		_, _, err := tt.req.Validate()
+		verr, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("%s: 錯誤 %v, 預期 *ValidationError", tt.name, err)
+			continue
+		}
+		if !strings.Contains(verr.Error(), tt.want) {
+			t.Errorf("%s: 錯誤 %q 未包含 %q", tt.name, verr.Error(), tt.want)
+		}
+	}
+
+	// 閏年整年 366 天可接受，並補上預設值
+	req := RevenueRequest{StartDate: "2024-01-01", EndDate: "2024-12-31", SR: sr}
+	if _, _, err := req.Validate(); err != nil {
+		t.Fatalf("366 天區間應可接受: %v", err)
+	}
+	if req.PerformanceGrade != 1 || req.GroupBy != GroupByDay {
+		t.Errorf("預設值 = 級數 %d、%q, 預期 1、day", req.PerformanceGrade, req.GroupBy)
+	}
+}
@@ -0,0 +1,222 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// 聚合時距
+const (
+	Interval15m = "15m"
+	Interval1h  = "1h"
+	Interval1d  = "1d"
+	Interval1M  = "1M"
+)
+
+// 聚合函數
+const (
+	AggAvg  = "avg"
+	AggMin  = "min"
+	AggMax  = "max"
+	AggSum  = "sum"
+	AggLast = "last"
+)
+
+// 缺漏區間的填補方式
+const (
+	FillNull     = "null"
+	FillZero     = "zero"
+	FillPrevious = "previous"
+)
+
+// intervalSteps 聚合時距對應的 PostgreSQL interval
+var intervalSteps = map[string]string{
+	Interval15m: "15 minutes",
+	Interval1h:  "1 hour",
+	Interval1d:  "1 day",
+	Interval1M:  "1 month",
+}
+
+// intervalDurations 聚合時距的近似長度，用於估算區間數
+var intervalDurations = map[string]time.Duration{
+	Interval15m: 15 * time.Minute,
+	Interval1h:  time.Hour,
+	Interval1d:  24 * time.Hour,
+	Interval1M:  30 * 24 * time.Hour,
+}
+
+// IsValidInterval 檢查聚合時距
+func IsValidInterval(interval string) bool {
+	_, ok := intervalSteps[interval]
+	return ok
+}
+
+// IsValidAgg 檢查聚合函數
+func IsValidAgg(agg string) bool {
+	switch agg {
+	case AggAvg, AggMin, AggMax, AggSum, AggLast:
+		return true
+	}
+	return false
+}
+
+// IsValidFill 檢查填補方式
+func IsValidFill(fill string) bool {
+	switch fill {
+	case FillNull, FillZero, FillPrevious:
+		return true
+	}
+	return false
+}
+
+// AggregateQuery 時間分組聚合查詢條件
+type AggregateQuery struct {
+	Start    time.Time
+	End      time.Time // 不含
+	Interval string
+	Agg      string
+	Fill     string
+	Location *time.Location // 分組依據的時區（日、月以當地零時為界）
+}
+
+// AlignStart 將開始時間對齊到所屬區間的開頭（依查詢時區）
+func (q *AggregateQuery) AlignStart() time.Time {
+	t := q.Start.In(q.Location)
+	switch q.Interval {
+	case Interval15m:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()/15*15, 0, 0, q.Location)
+	case Interval1h:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, q.Location)
+	case Interval1d:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, q.Location)
+	default:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, q.Location)
+	}
+}
+
+// BucketCount 估算查詢返回的區間數
+func (q *AggregateQuery) BucketCount() int {
+	return int(q.End.Sub(q.AlignStart())/intervalDurations[q.Interval]) + 1
+}
+
+// AggregateBucket 單一時間區間的聚合結果，無數據的區間 Samples 為 0
+type AggregateBucket struct {
+	Time    time.Time           `json:"time"` // 區間開始時間
+	Samples int                 `json:"samples"`
+	Values  map[string]*float64 `json:"values"`
+}
+
+// aggregateExpr 欄位的聚合運算式
+func aggregateExpr(agg, column string) string {
+	if agg == AggLast {
+		return fmt.Sprintf("(array_agg(%s ORDER BY datetime DESC))[1]", column)
+	}
+	return fmt.Sprintf("%s(%s)", strings.ToUpper(agg), column)
+}
+
+// bucketExpr 數據所屬區間開頭的當地時間運算式
+func bucketExpr(interval string) string {
+	local := "(datetime AT TIME ZONE $4)"
+	switch interval {
+	case Interval15m:
+		return fmt.Sprintf("date_trunc('hour', %s) + floor(extract(minute FROM %s) / 15)::int * interval '15 minutes'", local, local)
+	case Interval1h:
+		return fmt.Sprintf("date_trunc('hour', %s)", local)
+	case Interval1d:
+		return fmt.Sprintf("date_trunc('day', %s)", local)
+	default:
+		return fmt.Sprintf("date_trunc('month', %s)", local)
+	}
+}
+
+// aggregate 依時間區間聚合資料表欄位，以 generate_series 補齊無數據的區間
+// 欄位名稱只可來自程式內的常數，不可由請求傳入
+func aggregate(db *sql.DB, table string, columns []string, siteID string, q AggregateQuery) ([]AggregateBucket, error) {
+	exprs := make([]string, len(columns))
+	selects := make([]string, len(columns))
+	for i, column := range columns {
+		exprs[i] = aggregateExpr(q.Agg, column) + " AS " + column
+		selects[i] = "a." + column
+	}
+
+	query := `
+		WITH buckets AS (
+			SELECT generate_series($2::timestamptz AT TIME ZONE $4, $3::timestamptz AT TIME ZONE $4, $5::interval) AS bucket
+		), agg AS (
+			SELECT ` + bucketExpr(q.Interval) + ` AS bucket, COUNT(*) AS samples, ` + strings.Join(exprs, ", ") + `
+			FROM ` + table + `
+			WHERE site_id = $1 AND datetime >= $2 AND datetime < $3
+			GROUP BY 1
+		)
+		SELECT b.bucket AT TIME ZONE $4, COALESCE(a.samples, 0), ` + strings.Join(selects, ", ") + `
+		FROM buckets b
+		LEFT JOIN agg a ON a.bucket = b.bucket
+		WHERE b.bucket < $3::timestamptz AT TIME ZONE $4
+		ORDER BY b.bucket
+	`
+
+	rows, err := db.Query(query, siteID, q.AlignStart(), q.End, q.Location.String(), intervalSteps[q.Interval])
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var buckets []AggregateBucket
+	values := make([]sql.NullFloat64, len(columns))
+	dest := make([]interface{}, len(columns)+2)
+	for i := range values {
+		dest[i+2] = &values[i]
+	}
+
+	for rows.Next() {
+		var bucket AggregateBucket
+		dest[0], dest[1] = &bucket.Time, &bucket.Samples
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		bucket.Time = bucket.Time.In(q.Location)
+		bucket.Values = make(map[string]*float64, len(columns))
+		for i, column := range columns {
+			if values[i].Valid {
+				v := values[i].Float64
+				bucket.Values[column] = &v
+			} else {
+				bucket.Values[column] = nil
+			}
+		}
+		buckets = append(buckets, bucket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	fillGaps(buckets, columns, q.Fill)
+	return buckets, nil
+}
+
+// fillGaps 依填補方式補上無數據區間的值，previous 在第一筆數據之前的區間仍為 null
+func fillGaps(buckets []AggregateBucket, columns []string, fill string) {
+	if fill != FillZero && fill != FillPrevious {
+		return
+	}
+
+	last := make(map[string]*float64, len(columns))
+	for i := range buckets {
+		for _, column := range columns {
+			v := buckets[i].Values[column]
+			if v != nil {
+				last[column] = v
+				continue
+			}
+			if fill == FillZero {
+				zero := 0.0
+				buckets[i].Values[column] = &zero
+			} else if prev := last[column]; prev != nil {
+				filled := *prev
+				buckets[i].Values[column] = &filled
+			}
+		}
+	}
+}
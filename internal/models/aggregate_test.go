@@ -0,0 +1,105 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+// taipei 測試用的場站時區（UTC+8，不依賴系統時區資料庫）
+var taipei = time.FixedZone("CST", 8*60*60)
+
+func TestAlignStart(t *testing.T) {
+	tests := []struct {
+		start    time.Time
+		interval string
+		want     time.Time
+	}{
+		// UTC 17:40 為當地隔日 01:40
+		{time.Date(2024, 3, 15, 17, 40, 0, 0, time.UTC), Interval15m, time.Date(2024, 3, 16, 1, 30, 0, 0, taipei)},
+		{time.Date(2024, 3, 15, 17, 40, 0, 0, time.UTC), Interval1h, time.Date(2024, 3, 16, 1, 0, 0, 0, taipei)},
+		{time.Date(2024, 3, 15, 17, 40, 0, 0, time.UTC), Interval1d, time.Date(2024, 3, 16, 0, 0, 0, 0, taipei)},
+		{time.Date(2024, 3, 15, 17, 40, 0, 0, time.UTC), Interval1M, time.Date(2024, 3, 1, 0, 0, 0, 0, taipei)},
+		// UTC 仍在三月底，當地已進入四月
+		{time.Date(2024, 3, 31, 16, 30, 0, 0, time.UTC), Interval1M, time.Date(2024, 4, 1, 0, 0, 0, 0, taipei)},
+		{time.Date(2024, 3, 16, 0, 0, 0, 0, taipei), Interval1d, time.Date(2024, 3, 16, 0, 0, 0, 0, taipei)},
+	}
+
+	for _, tt := range tests {
+		q := AggregateQuery{Start: tt.start, Interval: tt.interval, Location: taipei}
+		if got := q.AlignStart(); !got.Equal(tt.want) || got.Location() != taipei {
+			t.Errorf("%s %s AlignStart = %v, 預期 %v", tt.start, tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestBucketCount(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, taipei) }
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      time.Time
+		interval string
+		want     int
+	}{
+		{"15m 一天", day(2024, 6, 1), day(2024, 6, 2), Interval15m, 97},
+		{"15m 開始時間先對齊", time.Date(2024, 6, 1, 0, 10, 0, 0, taipei), time.Date(2024, 6, 1, 1, 0, 0, 0, taipei), Interval15m, 5},
+		{"1h 一週", day(2024, 6, 1), day(2024, 6, 8), Interval1h, 169},
+		{"1d 三十天", day(2024, 6, 1), day(2024, 7, 1), Interval1d, 31},
+		// 一個月以 30 天估算，閏年整年為 366/30 取整再加 1
+		{"1M 一年", day(2024, 1, 1), day(2025, 1, 1), Interval1M, 13},
+		{"1M 開始時間對齊到月初", day(2024, 1, 20), day(2024, 2, 1), Interval1M, 2},
+	}
+
+	for _, tt := range tests {
+		q := AggregateQuery{Start: tt.start, End: tt.end, Interval: tt.interval, Location: taipei}
+		if got := q.BucketCount(); got != tt.want {
+			t.Errorf("%s: BucketCount = %d, 預期 %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFillGaps(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	columns := []string{"a", "b"}
+
+	tests := []struct {
+		fill string
+		a    []*float64
+		b    []*float64
+	}{
+		{FillNull, []*float64{nil, f(1), nil, f(3), nil}, []*float64{nil, nil, nil, nil, nil}},
+		{FillZero, []*float64{f(0), f(1), f(0), f(3), f(0)}, []*float64{f(0), f(0), f(0), f(0), f(0)}},
+		// 第一筆數據之前的區間仍為 null
+		{FillPrevious, []*float64{nil, f(1), f(1), f(3), f(3)}, []*float64{nil, nil, nil, nil, nil}},
+	}
+
+	for _, tt := range tests {
+		buckets := make([]AggregateBucket, 5)
+		for i, v := range []*float64{nil, f(1), nil, f(3), nil} {
+			buckets[i].Values = map[string]*float64{"a": v, "b": nil}
+		}
+		fillGaps(buckets, columns, tt.fill)
+
+		for i, bucket := range buckets {
+			for column, want := range map[string][]*float64{"a": tt.a, "b": tt.b} {
+				got := bucket.Values[column]
+				if (got == nil) != (want[i] == nil) || (got != nil && *got != *want[i]) {
+					t.Errorf("fill %s 區間 %d 欄位 %s = %v, 預期 %v", tt.fill, i, column, fmtPtr(got), fmtPtr(want[i]))
+				}
+			}
+		}
+
+		// 補上的值不可與來源區間共用指標
+		if tt.fill == FillPrevious && buckets[2].Values["a"] == buckets[1].Values["a"] {
+			t.Errorf("fill previous 與前一區間共用指標")
+		}
+	}
+}
+
+func fmtPtr(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
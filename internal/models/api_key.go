@@ -0,0 +1,169 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// DeviceAPIKey 閘道器上傳用的 API 金鑰，只能寫入 SiteID 場站的數據
+type DeviceAPIKey struct {
+	ID         int64      `json:"id"`
+	DeviceID   string     `json:"device_id"`
+	SiteID     string     `json:"site_id"`
+	Name       string     `json:"name"`
+	KeyPrefix  string     `json:"key_prefix"`
+	KeyHash    string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// APIKeyModel API 金鑰模型操作
+type APIKeyModel struct {
+	DB *sql.DB
+}
+
+// NewAPIKeyModel 創建 API 金鑰模型
+func NewAPIKeyModel(db *sql.DB) *APIKeyModel {
+	return &APIKeyModel{DB: db}
+}
+
+// apiKeyColumns API 金鑰查詢欄位
+const apiKeyColumns = `
+	id, device_id, site_id, name, key_prefix, key_hash, created_at, last_used_at, revoked_at
+`
+
+// scanAPIKey 掃描單筆 API 金鑰
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*DeviceAPIKey, error) {
+	data := &DeviceAPIKey{}
+	err := row.Scan(
+		&data.ID, &data.DeviceID, &data.SiteID, &data.Name, &data.KeyPrefix, &data.KeyHash,
+		&data.CreatedAt, &data.LastUsedAt, &data.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Insert 新增 API 金鑰，回填 ID 與建立時間
+func (m *APIKeyModel) Insert(data *DeviceAPIKey) error {
+	query := `
+		INSERT INTO device_api_keys (device_id, site_id, name, key_prefix, key_hash)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`
+	return m.DB.QueryRow(query, data.DeviceID, data.SiteID, data.Name, data.KeyPrefix, data.KeyHash).
+		Scan(&data.ID, &data.CreatedAt)
+}
+
+// Authenticate 依雜湊查詢未撤銷的金鑰並更新最後使用時間，找不到時返回 nil
+func (m *APIKeyModel) Authenticate(keyHash string) (*DeviceAPIKey, error) {
+	// 一分鐘內重複使用不更新，避免每次上傳都寫入
+	query := `
+		WITH touched AS (
+			UPDATE device_api_keys SET last_used_at = NOW()
+			WHERE key_hash = $1 AND revoked_at IS NULL
+				AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')
+		)
+		SELECT ` + apiKeyColumns + `
+		FROM device_api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`
+
+	data, err := scanAPIKey(m.DB.QueryRow(query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Get 依ID獲取 API 金鑰（含已撤銷），不存在時返回 nil
+func (m *APIKeyModel) Get(id int64) (*DeviceAPIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM device_api_keys WHERE id = $1`
+
+	data, err := scanAPIKey(m.DB.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// List 查詢 API 金鑰，deviceID、siteID 為空時不限
+func (m *APIKeyModel) List(deviceID, siteID string, includeRevoked bool) ([]DeviceAPIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM device_api_keys
+		WHERE ($1 = '' OR device_id = $1)
+			AND ($2 = '' OR site_id = $2)
+			AND ($3 OR revoked_at IS NULL)
+		ORDER BY id
+	`
+
+	rows, err := m.DB.Query(query, deviceID, siteID, includeRevoked)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dataList := []DeviceAPIKey{}
+	for rows.Next() {
+		data, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		dataList = append(dataList, *data)
+	}
+	return dataList, rows.Err()
+}
+
+// DeviceSites 查詢閘道器以有效金鑰登記的場站，返回設備ID對應的場站列表，未登記的設備不在結果中
+func (m *APIKeyModel) DeviceSites(deviceIDs []string) (map[string][]string, error) {
+	query := `
+		SELECT DISTINCT device_id, site_id
+		FROM device_api_keys
+		WHERE device_id = ANY($1) AND revoked_at IS NULL
+		ORDER BY device_id, site_id
+	`
+
+	rows, err := m.DB.Query(query, pq.Array(deviceIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sites := make(map[string][]string)
+	for rows.Next() {
+		var deviceID, siteID string
+		if err := rows.Scan(&deviceID, &siteID); err != nil {
+			return nil, err
+		}
+		sites[deviceID] = append(sites[deviceID], siteID)
+	}
+	return sites, rows.Err()
+}
+
+// Revoke 撤銷 API 金鑰，返回撤銷後的金鑰；不存在時返回 nil，已撤銷時保留原撤銷時間
+func (m *APIKeyModel) Revoke(id int64) (*DeviceAPIKey, error) {
+	query := `
+		UPDATE device_api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1
+		RETURNING ` + apiKeyColumns
+
+	data, err := scanAPIKey(m.DB.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,159 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// 稽核記錄的操作者種類
+const (
+	ActorUser      = "user"      // 使用者權杖，Actor 為 sub
+	ActorDevice    = "device"    // 閘道器 API 金鑰，Actor 為設備ID
+	ActorSystem    = "system"    // 排程任務，Actor 為 scheduler
+	ActorAnonymous = "anonymous" // 未認證或認證關閉
+)
+
+// 稽核記錄的結果
+const (
+	AuditSuccess = "success"
+	AuditDenied  = "denied" // 認證失敗或權限不足
+	AuditFailure = "failure"
+)
+
+// AuditEntry 稽核記錄
+type AuditEntry struct {
+	ID          int64     `json:"id"`
+	OccurredAt  time.Time `json:"occurred_at"`
+	ActorType   string    `json:"actor_type"`
+	Actor       string    `json:"actor"`
+	Action      string    `json:"action"` // 請求為「方法 路由」，排程任務為 job.run
+	Target      string    `json:"target"` // 請求路徑或任務名稱
+	Sites       []string  `json:"sites"`
+	PayloadHash *string   `json:"payload_hash"` // 請求主體的 SHA-256，無主體時為 null
+	Result      string    `json:"result"`
+	Status      *int      `json:"status"` // HTTP 狀態碼，排程任務為 null
+	Detail      string    `json:"detail"`
+	ClientIP    string    `json:"client_ip"`
+	DurationMs  int64     `json:"duration_ms"`
+}
+
+// AuditFilter 稽核記錄查詢條件，空值不限
+type AuditFilter struct {
+	ActorType string
+	Actor     string
+	Action    string
+	SiteID    string
+	Result    string
+	Start     time.Time
+	End       time.Time
+	// Sites 不為 nil 時只返回所有場站皆在其中的記錄（不含未涉及場站的記錄）
+	Sites []string
+}
+
+// AuditModel 稽核記錄模型操作，只可新增與查詢
+type AuditModel struct {
+	DB *sql.DB
+}
+
+// NewAuditModel 創建稽核記錄模型
+func NewAuditModel(db *sql.DB) *AuditModel {
+	return &AuditModel{DB: db}
+}
+
+// Insert 新增稽核記錄，回填ID
+func (m *AuditModel) Insert(data *AuditEntry) error {
+	if data.OccurredAt.IsZero() {
+		data.OccurredAt = time.Now()
+	}
+	if data.Sites == nil {
+		data.Sites = []string{}
+	}
+
+	query := `
+		INSERT INTO audit_log (
+			occurred_at, actor_type, actor, action, target, sites,
+			payload_hash, result, status, detail, client_ip, duration_ms
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`
+	return m.DB.QueryRow(query,
+		data.OccurredAt, data.ActorType, data.Actor, data.Action, data.Target, pq.Array(data.Sites),
+		data.PayloadHash, data.Result, data.Status, data.Detail, data.ClientIP, data.DurationMs,
+	).Scan(&data.ID)
+}
+
+// List 查詢稽核記錄，依 (occurred_at, id) 遞減排序
+// after 為上一頁返回的游標，還有下一頁時返回下一頁的游標
+func (m *AuditModel) List(filter AuditFilter, limit int, after *Cursor) ([]AuditEntry, *Cursor, error) {
+	conditions := []string{"TRUE"}
+	var args []interface{}
+	add := func(column, value string) {
+		if value != "" {
+			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+	}
+	add("actor_type", filter.ActorType)
+	add("actor", filter.Actor)
+	add("action", filter.Action)
+	add("result", filter.Result)
+	if filter.SiteID != "" {
+		args = append(args, filter.SiteID)
+		conditions = append(conditions, fmt.Sprintf("$%d = ANY(sites)", len(args)))
+	}
+	if filter.Sites != nil {
+		args = append(args, pq.Array(filter.Sites))
+		conditions = append(conditions, fmt.Sprintf("cardinality(sites) > 0 AND sites <@ $%d", len(args)))
+	}
+	if !filter.Start.IsZero() {
+		args = append(args, filter.Start)
+		conditions = append(conditions, fmt.Sprintf("occurred_at >= $%d", len(args)))
+	}
+	if !filter.End.IsZero() {
+		args = append(args, filter.End)
+		conditions = append(conditions, fmt.Sprintf("occurred_at < $%d", len(args)))
+	}
+
+	keyset, args := keysetClause(after, "occurred_at", "id", args)
+	query := `
+		SELECT id, occurred_at, actor_type, actor, action, target, sites,
+		       payload_hash, result, status, detail, client_ip, duration_ms
+		FROM audit_log
+		WHERE ` + strings.Join(conditions, " AND ") + keyset + `
+		ORDER BY occurred_at DESC, id DESC
+		LIMIT ` + fmt.Sprint(limit+1)
+
+	rows, err := m.DB.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	dataList := []AuditEntry{}
+	for rows.Next() {
+		var data AuditEntry
+		err := rows.Scan(
+			&data.ID, &data.OccurredAt, &data.ActorType, &data.Actor, &data.Action, &data.Target,
+			pq.Array(&data.Sites), &data.PayloadHash, &data.Result, &data.Status, &data.Detail,
+			&data.ClientIP, &data.DurationMs,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		dataList = append(dataList, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(dataList) <= limit {
+		return dataList, nil, nil
+	}
+	dataList = dataList[:limit]
+	last := dataList[limit-1]
+	return dataList, &Cursor{Time: last.OccurredAt, Key: last.ID}, nil
+}
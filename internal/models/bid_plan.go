@@ -0,0 +1,209 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// 備轉市場商品
+const (
+	ProductSR  = "sr"  // 即時備轉
+	ProductSUP = "sup" // 補充備轉
+)
+
+// BidPlan 次日投標計畫
+type BidPlan struct {
+	ID               int64         `json:"id"`
+	PlanDate         time.Time     `json:"plan_date"`
+	PerformanceGrade int           `json:"performance_grade"`
+	LookbackDays     int           `json:"lookback_days"`
+	ExpectedRevenue  float64       `json:"expected_revenue"`
+	Sites            []BidPlanSite `json:"sites"`
+	CreatedAt        time.Time     `json:"created_at"`
+	Hours            []BidPlanHour `json:"hours,omitempty"`
+}
+
+// BidPlanSite 產生計畫時各場站的儲能條件
+type BidPlanSite struct {
+	SiteID      string  `json:"site_id"`
+	PowerKW     float64 `json:"power_kw"`
+	CapacityKWh float64 `json:"capacity_kwh"`
+	SoC         float64 `json:"soc"`                    // 計畫開始時的電量狀態 %
+	SolarKWh    float64 `json:"solar_kwh"`              // 預期太陽能發電量
+	SolarSource string  `json:"solar_source,omitempty"` // 太陽能出力來源：forecast 或 history
+	Skipped     string  `json:"skipped,omitempty"`      // 不參與投標的原因
+}
+
+// 投標計畫的太陽能出力來源
+const (
+	SolarSourceForecast = "forecast" // 最新一次太陽能預測
+	SolarSourceHistory  = "history"  // 歷史同小時平均
+)
+
+// BidPlanHour 單一小時單一商品的投標量
+type BidPlanHour struct {
+	Hour            int                `json:"hour"`
+	Product         string             `json:"product"`
+	CapacityMW      float64            `json:"capacity_mw"`
+	ExpectedPrice   float64            `json:"expected_price"` // 容量價格加效能價格，元/MWh
+	ExpectedRevenue float64            `json:"expected_revenue"`
+	Sites           map[string]float64 `json:"sites"` // 場站ID -> 分配容量 (MW)
+}
+
+// BidPlanModel 投標計畫模型操作
+type BidPlanModel struct {
+	DB *sql.DB
+}
+
+// NewBidPlanModel 創建投標計畫模型
+func NewBidPlanModel(db *sql.DB) *BidPlanModel {
+	return &BidPlanModel{DB: db}
+}
+
+// Insert 在同一個事務中插入計畫及各小時投標量，回填ID與建立時間
+func (m *BidPlanModel) Insert(plan *BidPlan) error {
+	sites, err := json.Marshal(plan.Sites)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("無法開始事務: %w", err)
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO bid_plans (plan_date, performance_grade, lookback_days, expected_revenue, sites)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, plan.PlanDate, plan.PerformanceGrade, plan.LookbackDays, plan.ExpectedRevenue, sites,
+	).Scan(&plan.ID, &plan.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("插入投標計畫失敗: %w", err)
+	}
+
+	query := `
+		INSERT INTO bid_plan_hours (
+			plan_id, hour, product, capacity_mw, expected_price, expected_revenue, sites
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	for _, hour := range plan.Hours {
+		allocation, err := json.Marshal(hour.Sites)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		_, err = tx.Exec(query,
+			plan.ID, hour.Hour, hour.Product, hour.CapacityMW,
+			hour.ExpectedPrice, hour.ExpectedRevenue, allocation,
+		)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("插入投標量失敗: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// bidPlanColumns 投標計畫查詢欄位
+const bidPlanColumns = `
+	id, plan_date, performance_grade, lookback_days, expected_revenue, sites, created_at
+`
+
+// scanBidPlan 掃描單筆投標計畫（不含各小時投標量）
+func scanBidPlan(row interface{ Scan(...interface{}) error }) (*BidPlan, error) {
+	data := &BidPlan{}
+	var sites []byte
+	err := row.Scan(
+		&data.ID, &data.PlanDate, &data.PerformanceGrade, &data.LookbackDays,
+		&data.ExpectedRevenue, &sites, &data.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(sites, &data.Sites); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Get 依ID獲取投標計畫及各小時投標量，不存在時返回 nil
+func (m *BidPlanModel) Get(id int64) (*BidPlan, error) {
+	query := `SELECT ` + bidPlanColumns + ` FROM bid_plans WHERE id = $1`
+
+	plan, err := scanBidPlan(m.DB.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := m.DB.Query(`
+		SELECT hour, product, capacity_mw, expected_price, expected_revenue, sites
+		FROM bid_plan_hours
+		WHERE plan_id = $1
+		ORDER BY hour, product
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var hour BidPlanHour
+		var allocation []byte
+		err := rows.Scan(
+			&hour.Hour, &hour.Product, &hour.CapacityMW,
+			&hour.ExpectedPrice, &hour.ExpectedRevenue, &allocation,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(allocation, &hour.Sites); err != nil {
+			return nil, err
+		}
+		plan.Hours = append(plan.Hours, hour)
+	}
+
+	return plan, rows.Err()
+}
+
+// List 獲取日期區間內的投標計畫（不含各小時投標量），依計畫日期與建立時間倒序
+// sites 不為 nil 時只返回所有參與場站皆在 sites 內的計畫
+func (m *BidPlanModel) List(startDate, endDate time.Time, limit int, sites []string) ([]BidPlan, error) {
+	query := `
+		SELECT ` + bidPlanColumns + `
+		FROM bid_plans
+		WHERE plan_date BETWEEN $1 AND $2
+			AND ($4::TEXT[] IS NULL OR NOT EXISTS (
+				SELECT 1 FROM jsonb_array_elements(sites) s
+				WHERE NOT (s->>'site_id' = ANY($4))
+			))
+		ORDER BY plan_date DESC, created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := m.DB.Query(query, startDate, endDate, limit, pq.Array(sites))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataList []BidPlan
+	for rows.Next() {
+		data, err := scanBidPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		dataList = append(dataList, *data)
+	}
+
+	return dataList, rows.Err()
+}
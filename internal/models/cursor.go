@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidCursor 無法解析的分頁游標
+var ErrInvalidCursor = errors.New("無效的分頁游標")
+
+// Cursor 歷史查詢的分頁位置，指向上一頁最後一筆的排序鍵
+// 場站數據以 (datetime, id) 排序；台電備轉資料以 (tran_date, tran_hour) 排序，Key 為小時
+type Cursor struct {
+	Time time.Time `json:"t"`
+	Key  int64     `json:"k"`
+}
+
+// Encode 將游標編碼為不透明字串
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeCursor 解析游標字串
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.Time.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
+
+// keysetClause 依游標產生遞減排序下一頁的條件及參數，游標為 nil 時不加條件
+// timeColumn、keyColumn 只可來自程式內的常數
+func keysetClause(after *Cursor, timeColumn, keyColumn string, args []interface{}) (string, []interface{}) {
+	if after == nil {
+		return "", args
+	}
+	n := len(args)
+	clause := fmt.Sprintf(" AND (%s, %s) < ($%d, $%d)", timeColumn, keyColumn, n+1, n+2)
+	return clause, append(args, after.Time, after.Key)
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// 閘道器指令狀態
+const (
+	DeviceCommandPending      = "pending"
+	DeviceCommandSent         = "sent"
+	DeviceCommandAcknowledged = "acknowledged"
+	DeviceCommandFailed       = "failed"
+	DeviceCommandExpired      = "expired" // 逾期未確認，不再送出
+)
+
+// DeviceCommand 閘道器設定類指令
+type DeviceCommand struct {
+	ID             int64           `json:"id"`
+	DeviceID       string          `json:"device_id"`
+	SiteID         string          `json:"site_id"`
+	Type           string          `json:"type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Result         string          `json:"result"`
+	CreatedAt      time.Time       `json:"created_at"`
+	SentAt         *time.Time      `json:"sent_at"`
+	AcknowledgedAt *time.Time      `json:"acknowledged_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	ExpiresAt      time.Time       `json:"expires_at"`
+}
+
+// DeviceCommandModel 閘道器指令模型操作
+type DeviceCommandModel struct {
+	DB *sql.DB
+}
+
+// NewDeviceCommandModel 創建閘道器指令模型
+func NewDeviceCommandModel(db *sql.DB) *DeviceCommandModel {
+	return &DeviceCommandModel{DB: db}
+}
+
+// deviceCommandColumns 閘道器指令查詢欄位
+const deviceCommandColumns = `
+	id, device_id, site_id, type, payload, status, result,
+	created_at, sent_at, acknowledged_at, updated_at, expires_at
+`
+
+// queryDeviceCommands 查詢並掃描多筆閘道器指令
+func (m *DeviceCommandModel) queryDeviceCommands(query string, args ...interface{}) ([]DeviceCommand, error) {
+	rows, err := m.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataList []DeviceCommand
+	for rows.Next() {
+		var data DeviceCommand
+		var payload []byte
+		err := rows.Scan(
+			&data.ID, &data.DeviceID, &data.SiteID, &data.Type, &payload,
+			&data.Status, &data.Result, &data.CreatedAt, &data.SentAt,
+			&data.AcknowledgedAt, &data.UpdatedAt, &data.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		data.Payload = payload
+		dataList = append(dataList, data)
+	}
+
+	return dataList, rows.Err()
+}
+
+// Insert 插入閘道器指令，須設定到期時間
+func (m *DeviceCommandModel) Insert(data *DeviceCommand) error {
+	query := `
+		INSERT INTO device_commands (device_id, site_id, type, payload, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, created_at, updated_at
+	`
+
+	payload := []byte(data.Payload)
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+	return m.DB.QueryRow(query, data.DeviceID, data.SiteID, data.Type, payload, data.ExpiresAt).Scan(
+		&data.ID, &data.Status, &data.CreatedAt, &data.UpdatedAt,
+	)
+}
+
+// Claim 領取閘道器尚未到期的待送指令並標記為已送出
+// 已送出但未確認的指令在到期前會再次返回，閘道器需依指令ID去重
+func (m *DeviceCommandModel) Claim(deviceID string, now time.Time, limit int) ([]DeviceCommand, error) {
+	query := `
+		UPDATE device_commands
+		SET status = $1, sent_at = COALESCE(sent_at, NOW()), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM device_commands
+			WHERE device_id = $2 AND status IN ($3, $1) AND expires_at > $4
+			ORDER BY created_at, id
+			LIMIT $5
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deviceCommandColumns
+
+	return m.queryDeviceCommands(query, DeviceCommandSent, deviceID, DeviceCommandPending, now, limit)
+}
+
+// ExpirePending 將已到期仍未確認（待送或已送出）的指令標記為逾期
+func (m *DeviceCommandModel) ExpirePending(now time.Time) (int64, error) {
+	query := `
+		UPDATE device_commands
+		SET status = $1, result = $2, updated_at = NOW()
+		WHERE status IN ($3, $4) AND expires_at <= $5
+	`
+
+	result, err := m.DB.Exec(query, DeviceCommandExpired, "指令逾期未確認", DeviceCommandPending, DeviceCommandSent, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Acknowledge 記錄閘道器回報的執行結果，只更新屬於該閘道器且已送出的指令
+func (m *DeviceCommandModel) Acknowledge(id int64, deviceID, status, result string) (bool, error) {
+	query := `
+		UPDATE device_commands
+		SET status = $1, result = $2, acknowledged_at = NOW(), updated_at = NOW()
+		WHERE id = $3 AND device_id = $4 AND status = $5
+	`
+
+	res, err := m.DB.Exec(query, status, result, id, deviceID, DeviceCommandSent)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	return affected > 0, err
+}
@@ -0,0 +1,326 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// 派遣指令狀態
+const (
+	DispatchPending      = "pending"
+	DispatchSent         = "sent"
+	DispatchAcknowledged = "acknowledged"
+	DispatchExecuted     = "executed"
+	DispatchFailed       = "failed"
+)
+
+// DispatchCommand 派遣指令（單一時段的功率設定點）
+type DispatchCommand struct {
+	ID                int64      `json:"id"`
+	DispatchID        string     `json:"dispatch_id"`
+	SiteID            string     `json:"site_id"`
+	DeviceID          string     `json:"device_id"`
+	StartTime         time.Time  `json:"start_time"`
+	EndTime           time.Time  `json:"end_time"`
+	PowerKW           float64    `json:"power_kw"` // 正值放電，負值充電
+	Reason            string     `json:"reason"`
+	Status            string     `json:"status"`
+	Transport         string     `json:"transport"`
+	Attempts          int        `json:"attempts"`
+	Error             string     `json:"error"`
+	MeasuredKW        *float64   `json:"measured_kw"`
+	MeasurementSource string     `json:"measurement_source"`
+	Deviation         *float64   `json:"deviation"` // 實測與設定點的相對偏差
+	CreatedAt         time.Time  `json:"created_at"`
+	SentAt            *time.Time `json:"sent_at"`
+	AcknowledgedAt    *time.Time `json:"acknowledged_at"`
+	ExecutedAt        *time.Time `json:"executed_at"`
+	FailedAt          *time.Time `json:"failed_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// DispatchFilter 派遣指令查詢條件
+type DispatchFilter struct {
+	DispatchID string
+	SiteID     string
+	Sites      []string // 不為 nil 時只查詢這些場站
+	DeviceID   string
+	Status     string
+	Limit      int
+}
+
+// DispatchModel 派遣指令模型操作
+type DispatchModel struct {
+	DB *sql.DB
+}
+
+// NewDispatchModel 創建派遣指令模型
+func NewDispatchModel(db *sql.DB) *DispatchModel {
+	return &DispatchModel{DB: db}
+}
+
+// dispatchColumns 派遣指令查詢欄位
+const dispatchColumns = `
+	id, dispatch_id, site_id, device_id, start_time, end_time, power_kw, reason,
+	status, transport, attempts, error, measured_kw, measurement_source, deviation,
+	created_at, sent_at, acknowledged_at, executed_at, failed_at, updated_at
+`
+
+// dispatchTimeColumns 各狀態對應的時間欄位
+var dispatchTimeColumns = map[string]string{
+	DispatchSent:         "sent_at",
+	DispatchAcknowledged: "acknowledged_at",
+	DispatchExecuted:     "executed_at",
+	DispatchFailed:       "failed_at",
+}
+
+// scanDispatch 掃描單筆派遣指令
+func scanDispatch(row interface{ Scan(...interface{}) error }) (*DispatchCommand, error) {
+	data := &DispatchCommand{}
+	err := row.Scan(
+		&data.ID, &data.DispatchID, &data.SiteID, &data.DeviceID,
+		&data.StartTime, &data.EndTime, &data.PowerKW, &data.Reason,
+		&data.Status, &data.Transport, &data.Attempts, &data.Error,
+		&data.MeasuredKW, &data.MeasurementSource, &data.Deviation,
+		&data.CreatedAt, &data.SentAt, &data.AcknowledgedAt, &data.ExecutedAt,
+		&data.FailedAt, &data.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// queryDispatches 查詢並掃描多筆派遣指令
+func (m *DispatchModel) queryDispatches(query string, args ...interface{}) ([]DispatchCommand, error) {
+	rows, err := m.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataList []DispatchCommand
+	for rows.Next() {
+		data, err := scanDispatch(rows)
+		if err != nil {
+			return nil, err
+		}
+		dataList = append(dataList, *data)
+	}
+
+	return dataList, rows.Err()
+}
+
+// Insert 在同一個事務中插入多筆派遣指令，回填ID與時間欄位
+func (m *DispatchModel) Insert(commands []DispatchCommand) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("無法開始事務: %w", err)
+	}
+
+	query := `
+		INSERT INTO dispatch_commands (
+			dispatch_id, site_id, device_id, start_time, end_time, power_kw, reason, transport
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, status, created_at, updated_at
+	`
+	for i := range commands {
+		cmd := &commands[i]
+		err := tx.QueryRow(query,
+			cmd.DispatchID, cmd.SiteID, cmd.DeviceID, cmd.StartTime, cmd.EndTime,
+			cmd.PowerKW, cmd.Reason, cmd.Transport,
+		).Scan(&cmd.ID, &cmd.Status, &cmd.CreatedAt, &cmd.UpdatedAt)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("插入派遣指令失敗: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Get 依ID獲取派遣指令
+func (m *DispatchModel) Get(id int64) (*DispatchCommand, error) {
+	query := `SELECT ` + dispatchColumns + ` FROM dispatch_commands WHERE id = $1`
+
+	data, err := scanDispatch(m.DB.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
+
+// List 依條件查詢派遣指令，依開始時間倒序
+func (m *DispatchModel) List(filter DispatchFilter) ([]DispatchCommand, error) {
+	var conditions []string
+	var args []interface{}
+	add := func(column, value string) {
+		if value != "" {
+			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+	}
+	add("dispatch_id", filter.DispatchID)
+	add("site_id", filter.SiteID)
+	add("device_id", filter.DeviceID)
+	add("status", filter.Status)
+	if filter.Sites != nil {
+		args = append(args, pq.Array(filter.Sites))
+		conditions = append(conditions, fmt.Sprintf("site_id = ANY($%d)", len(args)))
+	}
+
+	query := `SELECT ` + dispatchColumns + ` FROM dispatch_commands`
+	if len(conditions) > 0 {
+		query += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(` ORDER BY start_time DESC, id DESC LIMIT $%d`, len(args))
+
+	return m.queryDispatches(query, args...)
+}
+
+// ListDue 獲取開始時間早於 before 且尚未結束的待送出指令
+func (m *DispatchModel) ListDue(before, now time.Time, limit int) ([]DispatchCommand, error) {
+	query := `SELECT ` + dispatchColumns + `
+		FROM dispatch_commands
+		WHERE status = $1 AND start_time <= $2 AND end_time > $3
+		ORDER BY start_time, id
+		LIMIT $4
+	`
+	return m.queryDispatches(query, DispatchPending, before, now, limit)
+}
+
+// ListUnverified 獲取已於 endedBefore 前結束、尚待驗證的指令
+func (m *DispatchModel) ListUnverified(endedBefore time.Time, limit int) ([]DispatchCommand, error) {
+	query := `SELECT ` + dispatchColumns + `
+		FROM dispatch_commands
+		WHERE status IN ($1, $2) AND end_time <= $3
+		ORDER BY end_time, id
+		LIMIT $4
+	`
+	return m.queryDispatches(query, DispatchSent, DispatchAcknowledged, endedBefore, limit)
+}
+
+// Transition 將指令由 from 中任一狀態轉為 to，返回是否實際更新
+// 狀態不符（已被其他流程更新）時不更新
+func (m *DispatchModel) Transition(id int64, to string, errMsg string, from ...string) (bool, error) {
+	column, ok := dispatchTimeColumns[to]
+	if !ok {
+		return false, fmt.Errorf("無效的目標狀態: %s", to)
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE dispatch_commands
+		SET status = $1, %s = NOW(), error = $2, updated_at = NOW()
+		WHERE id = $3 AND status = ANY($4)
+	`, column)
+
+	result, err := m.DB.Exec(query, to, errMsg, id, pq.Array(from))
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ClaimForDevice 領取閘道器的輪詢指令並標記為已送出
+// 只領取金鑰所屬場站的指令：指定給該閘道器或未指定閘道器、開始時間早於 before 且尚未結束的待送指令，
+// 以及已送給該閘道器但尚未確認的指令（閘道器需依指令ID去重）
+func (m *DispatchModel) ClaimForDevice(deviceID, siteID, transport string, before, now time.Time, limit int) ([]DispatchCommand, error) {
+	query := `
+		UPDATE dispatch_commands
+		SET status = $1, device_id = $2, sent_at = COALESCE(sent_at, NOW()), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM dispatch_commands
+			WHERE transport = $3 AND start_time <= $4 AND end_time > $5 AND site_id = $7
+			  AND ((status = $6 AND (device_id = $2 OR device_id = ''))
+			    OR (status = $1 AND device_id = $2))
+			ORDER BY start_time, id
+			LIMIT $8
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + dispatchColumns
+
+	return m.queryDispatches(query, DispatchSent, deviceID, transport, before, now, DispatchPending, siteID, limit)
+}
+
+// AcknowledgeFromDevice 記錄閘道器對已送出指令的確認或拒絕
+func (m *DispatchModel) AcknowledgeFromDevice(id int64, deviceID, to, errMsg string) (bool, error) {
+	column, ok := dispatchTimeColumns[to]
+	if !ok || (to != DispatchAcknowledged && to != DispatchFailed) {
+		return false, fmt.Errorf("無效的確認狀態: %s", to)
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE dispatch_commands
+		SET status = $1, %s = NOW(), error = $2, updated_at = NOW()
+		WHERE id = $3 AND device_id = $4 AND status = $5
+	`, column)
+
+	result, err := m.DB.Exec(query, to, errMsg, id, deviceID, DispatchSent)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// RecordAttempt 記錄一次送出失敗，返回累計嘗試次數
+func (m *DispatchModel) RecordAttempt(id int64, errMsg string) (int, error) {
+	query := `
+		UPDATE dispatch_commands
+		SET attempts = attempts + 1, error = $1, updated_at = NOW()
+		WHERE id = $2
+		RETURNING attempts
+	`
+
+	var attempts int
+	err := m.DB.QueryRow(query, errMsg, id).Scan(&attempts)
+	return attempts, err
+}
+
+// RecordMeasurement 記錄實測響應並完成驗證
+func (m *DispatchModel) RecordMeasurement(id int64, status string, measured *float64, source string, deviation *float64, errMsg string) (bool, error) {
+	column, ok := dispatchTimeColumns[status]
+	if !ok || (status != DispatchExecuted && status != DispatchFailed) {
+		return false, fmt.Errorf("無效的驗證結果狀態: %s", status)
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE dispatch_commands
+		SET status = $1, %s = NOW(), measured_kw = $2, measurement_source = $3,
+		    deviation = $4, error = $5, updated_at = NOW()
+		WHERE id = $6 AND status IN ($7, $8)
+	`, column)
+
+	result, err := m.DB.Exec(query, status, measured, source, deviation, errMsg, id, DispatchSent, DispatchAcknowledged)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ExpirePending 將已結束仍未送出的指令標記為失敗
+func (m *DispatchModel) ExpirePending(now time.Time) (int64, error) {
+	query := `
+		UPDATE dispatch_commands
+		SET status = $1, failed_at = NOW(), error = $2, updated_at = NOW()
+		WHERE status = $3 AND end_time <= $4
+	`
+
+	result, err := m.DB.Exec(query, DispatchFailed, "指令逾期未送達", DispatchPending, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+)
+
+// IdempotencyRecord 冪等請求記錄，保存首次處理的響應供重送時回放
+// 鍵以設備區分，不同設備可使用相同的鍵
+type IdempotencyRecord struct {
+	DeviceID    string    `json:"device_id"`
+	Key         string    `json:"key"`
+	RequestHash string    `json:"request_hash"`
+	StatusCode  int       `json:"status_code"`
+	Response    []byte    `json:"response"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// IdempotencyModel 冪等請求記錄模型操作
+type IdempotencyModel struct {
+	DB *sql.DB
+}
+
+// NewIdempotencyModel 創建冪等請求記錄模型
+func NewIdempotencyModel(db *sql.DB) *IdempotencyModel {
+	return &IdempotencyModel{DB: db}
+}
+
+// IdempotencyInsertQuery 冪等請求記錄插入語句，同一設備重複的鍵會違反主鍵約束使事務回滾
+const IdempotencyInsertQuery = `
+	INSERT INTO idempotency_keys (device_id, key, request_hash, status_code, response)
+	VALUES ($1, $2, $3, $4, $5)
+`
+
+// InsertArgs 獲取 IdempotencyInsertQuery 的參數
+func (data *IdempotencyRecord) InsertArgs() []interface{} {
+	return []interface{}{data.DeviceID, data.Key, data.RequestHash, data.StatusCode, data.Response}
+}
+
+// Get 依設備及鍵獲取冪等請求記錄
+func (m *IdempotencyModel) Get(deviceID, key string) (*IdempotencyRecord, error) {
+	query := `
+		SELECT device_id, key, request_hash, status_code, response, created_at
+		FROM idempotency_keys
+		WHERE device_id = $1 AND key = $2
+	`
+
+	data := &IdempotencyRecord{}
+	err := m.DB.QueryRow(query, deviceID, key).Scan(
+		&data.DeviceID, &data.Key, &data.RequestHash, &data.StatusCode, &data.Response, &data.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// DeleteBefore 刪除早於指定時間的記錄
+func (m *IdempotencyModel) DeleteBefore(t time.Time) (int64, error) {
+	result, err := m.DB.Exec(`DELETE FROM idempotency_keys WHERE created_at < $1`, t)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -76,19 +77,20 @@ func (m *LoadDataModel) GetAllLatest() ([]LoadData, error) {
 	return dataList, nil
 }
 
-// GetHistory 獲取歷史數據
-func (m *LoadDataModel) GetHistory(siteID string, startDate, endDate time.Time, limit int) ([]LoadData, error) {
+// GetHistory 獲取歷史數據，依 (datetime, id) 遞減排序
+// after 為上一頁返回的游標，還有下一頁時返回下一頁的游標
+func (m *LoadDataModel) GetHistory(siteID string, startDate, endDate time.Time, limit int, after *Cursor) ([]LoadData, *Cursor, error) {
+	keyset, args := keysetClause(after, "datetime", "id", []interface{}{siteID, startDate, endDate})
 	query := `
 		SELECT id, site_id, datetime, load_value
 		FROM load_data
-		WHERE site_id = $1 AND datetime BETWEEN $2 AND $3
-		ORDER BY datetime DESC
-		LIMIT $4
-	`
+		WHERE site_id = $1 AND datetime BETWEEN $2 AND $3` + keyset + `
+		ORDER BY datetime DESC, id DESC
+		LIMIT ` + fmt.Sprint(limit+1)
 
-	rows, err := m.DB.Query(query, siteID, startDate, endDate, limit)
+	rows, err := m.DB.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -97,23 +99,120 @@ func (m *LoadDataModel) GetHistory(siteID string, startDate, endDate time.Time,
 		var data LoadData
 		err := rows.Scan(&data.ID, &data.SiteID, &data.DateTime, &data.LoadValue)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		dataList = append(dataList, data)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
 
-	return dataList, nil
+	if len(dataList) <= limit {
+		return dataList, nil, nil
+	}
+	dataList = dataList[:limit]
+	last := dataList[limit-1]
+	return dataList, &Cursor{Time: last.DateTime, Key: int64(last.ID)}, nil
+}
+
+// Each 依時間遞增逐筆讀取時段內的數據，不將結果保留在記憶體，fn 返回錯誤時中止
+func (m *LoadDataModel) Each(siteID string, startDate, endDate time.Time, fn func(*LoadData) error) error {
+	query := `
+		SELECT id, site_id, datetime, load_value
+		FROM load_data
+		WHERE site_id = $1 AND datetime BETWEEN $2 AND $3
+		ORDER BY datetime, id
+	`
+
+	rows, err := m.DB.Query(query, siteID, startDate, endDate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var data LoadData
+	for rows.Next() {
+		if err := rows.Scan(&data.ID, &data.SiteID, &data.DateTime, &data.LoadValue); err != nil {
+			return err
+		}
+		if err := fn(&data); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Aggregate 依時間區間聚合負載數據，依時間排序並補齊無數據的區間
+func (m *LoadDataModel) Aggregate(siteID string, q AggregateQuery) ([]AggregateBucket, error) {
+	return aggregate(m.DB, "load_data", []string{"load_value"}, siteID, q)
+}
+
+// GetAverageLoad 獲取時段內的平均負載及樣本數
+func (m *LoadDataModel) GetAverageLoad(siteID string, start, end time.Time) (float64, int, error) {
+	query := `
+		SELECT COALESCE(AVG(load_value), 0), COUNT(*)
+		FROM load_data
+		WHERE site_id = $1 AND datetime >= $2 AND datetime < $3
+	`
+
+	var avg float64
+	var samples int
+	err := m.DB.QueryRow(query, siteID, start, end).Scan(&avg, &samples)
+	return avg, samples, err
+}
+
+// LoadInsertQuery 負載數據插入語句（同場站同時間則更新）
+const LoadInsertQuery = `
+	INSERT INTO load_data (site_id, datetime, load_value)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (site_id, datetime) DO UPDATE SET
+		load_value = EXCLUDED.load_value
+`
+
+// InsertArgs 獲取 LoadInsertQuery 的參數
+func (data *LoadData) InsertArgs() []interface{} {
+	return []interface{}{data.SiteID, data.DateTime, data.LoadValue}
 }
 
 // Insert 插入負載數據
 func (m *LoadDataModel) Insert(data *LoadData) error {
+	_, err := m.DB.Exec(LoadInsertQuery, data.InsertArgs()...)
+	return err
+}
+
+// LoadInterval 固定區間的平均負載
+type LoadInterval struct {
+	Time      time.Time `json:"time"` // 區間開始時間
+	LoadValue float64   `json:"load_value"`
+	Samples   int       `json:"samples"`
+}
+
+// GetIntervals 獲取時段內以 interval 分組的平均負載，依時間排序，無數據的區間不返回
+func (m *LoadDataModel) GetIntervals(siteID string, start, end time.Time, interval time.Duration) ([]LoadInterval, error) {
 	query := `
-		INSERT INTO load_data (site_id, datetime, load_value)
-		VALUES ($1, $2, $3)
-		ON CONFLICT (site_id, datetime) DO UPDATE SET
-			load_value = EXCLUDED.load_value
+		SELECT to_timestamp(floor(extract(epoch FROM datetime) / $4) * $4) AS bucket,
+		       AVG(load_value), COUNT(*)
+		FROM load_data
+		WHERE site_id = $1 AND datetime >= $2 AND datetime < $3
+		GROUP BY bucket
+		ORDER BY bucket
 	`
 
-	_, err := m.DB.Exec(query, data.SiteID, data.DateTime, data.LoadValue)
-	return err
+	rows, err := m.DB.Query(query, siteID, start, end, interval.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataList []LoadInterval
+	for rows.Next() {
+		var data LoadInterval
+		if err := rows.Scan(&data.Time, &data.LoadValue, &data.Samples); err != nil {
+			return nil, err
+		}
+		dataList = append(dataList, data)
+	}
+
+	return dataList, rows.Err()
 }
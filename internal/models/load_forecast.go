@@ -0,0 +1,170 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// LoadForecastRun 一次負載預測的訓練結果
+type LoadForecastRun struct {
+	ID            int64     `json:"id"`
+	SiteID        string    `json:"site_id"`
+	IssuedAt      time.Time `json:"issued_at"`
+	TrainingStart time.Time `json:"training_start"`
+	TrainingEnd   time.Time `json:"training_end"`
+	Samples       int       `json:"samples"`
+	Level         float64   `json:"level"` // 近期負載相對典型日的比例
+	FitRMSE       float64   `json:"fit_rmse"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// LoadForecast 單一 15 分鐘區間的負載預測
+type LoadForecast struct {
+	TargetTime time.Time `json:"target_time"`
+	LoadKW     float64   `json:"load_kw"`
+	Holiday    bool      `json:"holiday"`
+}
+
+// LoadForecastModel 負載預測模型操作
+type LoadForecastModel struct {
+	DB *sql.DB
+}
+
+// NewLoadForecastModel 創建負載預測模型
+func NewLoadForecastModel(db *sql.DB) *LoadForecastModel {
+	return &LoadForecastModel{DB: db}
+}
+
+// Insert 在同一個事務中插入訓練結果及預測值，回填ID與建立時間
+func (m *LoadForecastModel) Insert(run *LoadForecastRun, forecasts []LoadForecast) error {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("無法開始事務: %w", err)
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO load_forecast_runs (
+			site_id, issued_at, training_start, training_end, samples, level, fit_rmse
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, run.SiteID, run.IssuedAt, run.TrainingStart, run.TrainingEnd, run.Samples, run.Level, run.FitRMSE,
+	).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("插入預測記錄失敗: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO load_forecasts (run_id, site_id, target_time, issued_at, load_kw, holiday)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, f := range forecasts {
+		if _, err := stmt.Exec(run.ID, run.SiteID, f.TargetTime, run.IssuedAt, f.LoadKW, f.Holiday); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("插入預測值失敗: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetLatestRun 獲取場站最新的訓練結果，不存在時返回 nil
+func (m *LoadForecastModel) GetLatestRun(siteID string) (*LoadForecastRun, error) {
+	query := `
+		SELECT id, site_id, issued_at, training_start, training_end, samples, level, fit_rmse, created_at
+		FROM load_forecast_runs
+		WHERE site_id = $1
+		ORDER BY issued_at DESC
+		LIMIT 1
+	`
+
+	run := &LoadForecastRun{}
+	err := m.DB.QueryRow(query, siteID).Scan(
+		&run.ID, &run.SiteID, &run.IssuedAt, &run.TrainingStart, &run.TrainingEnd,
+		&run.Samples, &run.Level, &run.FitRMSE, &run.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// GetForecasts 獲取訓練結果中目標時間在 from 之後的預測值
+func (m *LoadForecastModel) GetForecasts(runID int64, from time.Time) ([]LoadForecast, error) {
+	query := `
+		SELECT target_time, load_kw, holiday
+		FROM load_forecasts
+		WHERE run_id = $1 AND target_time >= $2
+		ORDER BY target_time
+	`
+
+	rows, err := m.DB.Query(query, runID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataList []LoadForecast
+	for rows.Next() {
+		var data LoadForecast
+		if err := rows.Scan(&data.TargetTime, &data.LoadKW, &data.Holiday); err != nil {
+			return nil, err
+		}
+		dataList = append(dataList, data)
+	}
+
+	return dataList, rows.Err()
+}
+
+// GetForecastActuals 獲取時段內各區間的預測值與實際平均負載
+// 預測值取目標時間前至少 minLead 發布的最新一次預測，無實際數據的區間不返回
+func (m *LoadForecastModel) GetForecastActuals(siteID string, start, end time.Time, minLead time.Duration) ([]ForecastActual, error) {
+	query := `
+		WITH forecast AS (
+			SELECT DISTINCT ON (target_time) target_time, load_kw
+			FROM load_forecasts
+			WHERE site_id = $1 AND target_time >= $2 AND target_time < $3
+			  AND issued_at <= target_time - make_interval(secs => $4)
+			ORDER BY target_time, issued_at DESC
+		),
+		actual AS (
+			SELECT to_timestamp(floor(extract(epoch FROM datetime) / 900) * 900) AS bucket,
+			       AVG(load_value) AS load
+			FROM load_data
+			WHERE site_id = $1 AND datetime >= $2 AND datetime < $3
+			GROUP BY bucket
+		)
+		SELECT f.target_time, f.load_kw, a.load
+		FROM forecast f
+		JOIN actual a ON a.bucket = f.target_time
+		ORDER BY f.target_time
+	`
+
+	rows, err := m.DB.Query(query, siteID, start, end, minLead.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataList []ForecastActual
+	for rows.Next() {
+		var data ForecastActual
+		if err := rows.Scan(&data.Time, &data.Forecast, &data.Actual); err != nil {
+			return nil, err
+		}
+		dataList = append(dataList, data)
+	}
+
+	return dataList, rows.Err()
+}
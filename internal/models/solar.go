@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 )
 
@@ -102,22 +103,23 @@ func (m *SolarDataModel) GetAllLatest() ([]SolarData, error) {
 	return dataList, nil
 }
 
-// GetHistory 獲取歷史數據
-func (m *SolarDataModel) GetHistory(siteID string, startDate, endDate time.Time, limit int) ([]SolarData, error) {
+// GetHistory 獲取歷史數據，依 (datetime, id) 遞減排序
+// after 為上一頁返回的游標，還有下一頁時返回下一頁的游標
+func (m *SolarDataModel) GetHistory(siteID string, startDate, endDate time.Time, limit int, after *Cursor) ([]SolarData, *Cursor, error) {
+	keyset, args := keysetClause(after, "datetime", "id", []interface{}{siteID, startDate, endDate})
 	query := `
 		SELECT id, site_id, datetime, daily_generation, solar_radiation,
 		       ac_avg_voltage, ac_total_power, ac_total_current,
 		       dc_avg_voltage, dc_total_power, dc_total_current,
 		       module_temperature, total_accumulated_generation, co2_reduction
 		FROM solar_data
-		WHERE site_id = $1 AND datetime BETWEEN $2 AND $3
-		ORDER BY datetime DESC
-		LIMIT $4
-	`
+		WHERE site_id = $1 AND datetime BETWEEN $2 AND $3` + keyset + `
+		ORDER BY datetime DESC, id DESC
+		LIMIT ` + fmt.Sprint(limit+1)
 
-	rows, err := m.DB.Query(query, siteID, startDate, endDate, limit)
+	rows, err := m.DB.Query(query, args...)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -132,43 +134,182 @@ func (m *SolarDataModel) GetHistory(siteID string, startDate, endDate time.Time,
 			&data.TotalAccumulatedGeneration, &data.CO2Reduction,
 		)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		dataList = append(dataList, data)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
 
-	return dataList, nil
+	if len(dataList) <= limit {
+		return dataList, nil, nil
+	}
+	dataList = dataList[:limit]
+	last := dataList[limit-1]
+	return dataList, &Cursor{Time: last.DateTime, Key: int64(last.ID)}, nil
 }
 
-// Insert 插入太陽能數據
-func (m *SolarDataModel) Insert(data *SolarData) error {
+// Each 依時間遞增逐筆讀取時段內的數據，不將結果保留在記憶體，fn 返回錯誤時中止
+func (m *SolarDataModel) Each(siteID string, startDate, endDate time.Time, fn func(*SolarData) error) error {
 	query := `
-		INSERT INTO solar_data (
-			site_id, datetime, daily_generation, solar_radiation,
-			ac_avg_voltage, ac_total_power, ac_total_current,
-			dc_avg_voltage, dc_total_power, dc_total_current,
-			module_temperature, total_accumulated_generation, co2_reduction
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
-		ON CONFLICT (site_id, datetime) DO UPDATE SET
-			daily_generation = EXCLUDED.daily_generation,
-			solar_radiation = EXCLUDED.solar_radiation,
-			ac_avg_voltage = EXCLUDED.ac_avg_voltage,
-			ac_total_power = EXCLUDED.ac_total_power,
-			ac_total_current = EXCLUDED.ac_total_current,
-			dc_avg_voltage = EXCLUDED.dc_avg_voltage,
-			dc_total_power = EXCLUDED.dc_total_power,
-			dc_total_current = EXCLUDED.dc_total_current,
-			module_temperature = EXCLUDED.module_temperature,
-			total_accumulated_generation = EXCLUDED.total_accumulated_generation,
-			co2_reduction = EXCLUDED.co2_reduction
+		SELECT id, site_id, datetime, daily_generation, solar_radiation,
+		       ac_avg_voltage, ac_total_power, ac_total_current,
+		       dc_avg_voltage, dc_total_power, dc_total_current,
+		       module_temperature, total_accumulated_generation, co2_reduction
+		FROM solar_data
+		WHERE site_id = $1 AND datetime BETWEEN $2 AND $3
+		ORDER BY datetime, id
 	`
 
-	_, err := m.DB.Exec(query,
+	rows, err := m.DB.Query(query, siteID, startDate, endDate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var data SolarData
+	for rows.Next() {
+		err := rows.Scan(
+			&data.ID, &data.SiteID, &data.DateTime, &data.DailyGeneration,
+			&data.SolarRadiation, &data.ACAverageVoltage, &data.ACTotalPower,
+			&data.ACTotalCurrent, &data.DCAverageVoltage, &data.DCTotalPower,
+			&data.DCTotalCurrent, &data.ModuleTemperature,
+			&data.TotalAccumulatedGeneration, &data.CO2Reduction,
+		)
+		if err != nil {
+			return err
+		}
+		if err := fn(&data); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// solarAggregateColumns 可聚合的太陽能數據欄位
+var solarAggregateColumns = []string{
+	"daily_generation", "solar_radiation",
+	"ac_avg_voltage", "ac_total_power", "ac_total_current",
+	"dc_avg_voltage", "dc_total_power", "dc_total_current",
+	"module_temperature", "total_accumulated_generation", "co2_reduction",
+}
+
+// Aggregate 依時間區間聚合太陽能數據，依時間排序並補齊無數據的區間
+func (m *SolarDataModel) Aggregate(siteID string, q AggregateQuery) ([]AggregateBucket, error) {
+	return aggregate(m.DB, "solar_data", solarAggregateColumns, siteID, q)
+}
+
+// SolarInsertQuery 太陽能數據插入語句（同場站同時間則更新）
+const SolarInsertQuery = `
+	INSERT INTO solar_data (
+		site_id, datetime, daily_generation, solar_radiation,
+		ac_avg_voltage, ac_total_power, ac_total_current,
+		dc_avg_voltage, dc_total_power, dc_total_current,
+		module_temperature, total_accumulated_generation, co2_reduction
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+	ON CONFLICT (site_id, datetime) DO UPDATE SET
+		daily_generation = EXCLUDED.daily_generation,
+		solar_radiation = EXCLUDED.solar_radiation,
+		ac_avg_voltage = EXCLUDED.ac_avg_voltage,
+		ac_total_power = EXCLUDED.ac_total_power,
+		ac_total_current = EXCLUDED.ac_total_current,
+		dc_avg_voltage = EXCLUDED.dc_avg_voltage,
+		dc_total_power = EXCLUDED.dc_total_power,
+		dc_total_current = EXCLUDED.dc_total_current,
+		module_temperature = EXCLUDED.module_temperature,
+		total_accumulated_generation = EXCLUDED.total_accumulated_generation,
+		co2_reduction = EXCLUDED.co2_reduction
+`
+
+// InsertArgs 獲取 SolarInsertQuery 的參數
+func (data *SolarData) InsertArgs() []interface{} {
+	return []interface{}{
 		data.SiteID, data.DateTime, data.DailyGeneration, data.SolarRadiation,
 		data.ACAverageVoltage, data.ACTotalPower, data.ACTotalCurrent,
 		data.DCAverageVoltage, data.DCTotalPower, data.DCTotalCurrent,
 		data.ModuleTemperature, data.TotalAccumulatedGeneration, data.CO2Reduction,
-	)
+	}
+}
 
+// Insert 插入太陽能數據
+func (m *SolarDataModel) Insert(data *SolarData) error {
+	_, err := m.DB.Exec(SolarInsertQuery, data.InsertArgs()...)
 	return err
 }
+
+// GetHourlyProfile 獲取時段內各小時（依指定時區）的平均交流輸出功率 (kW) 及有數據的天數
+func (m *SolarDataModel) GetHourlyProfile(siteID string, start, end time.Time, location *time.Location) ([24]float64, int, error) {
+	query := `
+		SELECT EXTRACT(HOUR FROM datetime AT TIME ZONE $4)::int AS hour,
+		       AVG(ac_total_power),
+		       COUNT(DISTINCT (datetime AT TIME ZONE $4)::date)
+		FROM solar_data
+		WHERE site_id = $1 AND datetime >= $2 AND datetime < $3
+		GROUP BY hour
+	`
+
+	var profile [24]float64
+	rows, err := m.DB.Query(query, siteID, start, end, location.String())
+	if err != nil {
+		return profile, 0, err
+	}
+	defer rows.Close()
+
+	days := 0
+	for rows.Next() {
+		var hour, count int
+		var avg float64
+		if err := rows.Scan(&hour, &avg, &count); err != nil {
+			return profile, 0, err
+		}
+		if hour >= 0 && hour < 24 {
+			profile[hour] = avg
+		}
+		if count > days {
+			days = count
+		}
+	}
+
+	return profile, days, rows.Err()
+}
+
+// SolarInterval 固定區間的太陽能平均值
+type SolarInterval struct {
+	Time              time.Time `json:"time"` // 區間開始時間
+	ACTotalPower      float64   `json:"ac_total_power"`
+	SolarRadiation    float64   `json:"solar_radiation"`
+	ModuleTemperature float64   `json:"module_temperature"`
+	Samples           int       `json:"samples"`
+}
+
+// GetIntervals 獲取時段內以 interval 分組的平均值，依時間排序，無數據的區間不返回
+func (m *SolarDataModel) GetIntervals(siteID string, start, end time.Time, interval time.Duration) ([]SolarInterval, error) {
+	query := `
+		SELECT to_timestamp(floor(extract(epoch FROM datetime) / $4) * $4) AS bucket,
+		       AVG(ac_total_power), AVG(solar_radiation), AVG(module_temperature), COUNT(*)
+		FROM solar_data
+		WHERE site_id = $1 AND datetime >= $2 AND datetime < $3
+		GROUP BY bucket
+		ORDER BY bucket
+	`
+
+	rows, err := m.DB.Query(query, siteID, start, end, interval.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataList []SolarInterval
+	for rows.Next() {
+		var data SolarInterval
+		err := rows.Scan(&data.Time, &data.ACTotalPower, &data.SolarRadiation, &data.ModuleTemperature, &data.Samples)
+		if err != nil {
+			return nil, err
+		}
+		dataList = append(dataList, data)
+	}
+
+	return dataList, rows.Err()
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SolarForecastRun 一次太陽能預測的訓練結果
+type SolarForecastRun struct {
+	ID            int64     `json:"id"`
+	SiteID        string    `json:"site_id"`
+	IssuedAt      time.Time `json:"issued_at"`
+	TrainingStart time.Time `json:"training_start"`
+	TrainingEnd   time.Time `json:"training_end"`
+	Samples       int       `json:"samples"`
+	Clearness     float64   `json:"clearness"` // 發布前 24 小時的晴空指數
+	Coefficients  []float64 `json:"coefficients"`
+	FitRMSE       float64   `json:"fit_rmse"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SolarForecast 單一 15 分鐘區間的太陽能預測
+type SolarForecast struct {
+	TargetTime time.Time `json:"target_time"`
+	PowerKW    float64   `json:"power_kw"`
+	ClearSky   float64   `json:"clear_sky"` // 晴空日照 W/m²
+}
+
+// ForecastActual 預測值與實際值
+type ForecastActual struct {
+	Time     time.Time `json:"time"`
+	Forecast float64   `json:"forecast"`
+	Actual   float64   `json:"actual"`
+}
+
+// SolarForecastModel 太陽能預測模型操作
+type SolarForecastModel struct {
+	DB *sql.DB
+}
+
+// NewSolarForecastModel 創建太陽能預測模型
+func NewSolarForecastModel(db *sql.DB) *SolarForecastModel {
+	return &SolarForecastModel{DB: db}
+}
+
+// Insert 在同一個事務中插入訓練結果及預測值，回填ID與建立時間
+func (m *SolarForecastModel) Insert(run *SolarForecastRun, forecasts []SolarForecast) error {
+	coefficients, err := json.Marshal(run.Coefficients)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("無法開始事務: %w", err)
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO solar_forecast_runs (
+			site_id, issued_at, training_start, training_end, samples, clearness, coefficients, fit_rmse
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, run.SiteID, run.IssuedAt, run.TrainingStart, run.TrainingEnd, run.Samples,
+		run.Clearness, coefficients, run.FitRMSE,
+	).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("插入預測記錄失敗: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO solar_forecasts (run_id, site_id, target_time, issued_at, power_kw, clear_sky)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, f := range forecasts {
+		if _, err := stmt.Exec(run.ID, run.SiteID, f.TargetTime, run.IssuedAt, f.PowerKW, f.ClearSky); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("插入預測值失敗: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetLatestRun 獲取場站最新的訓練結果，不存在時返回 nil
+func (m *SolarForecastModel) GetLatestRun(siteID string) (*SolarForecastRun, error) {
+	query := `
+		SELECT id, site_id, issued_at, training_start, training_end, samples,
+		       clearness, coefficients, fit_rmse, created_at
+		FROM solar_forecast_runs
+		WHERE site_id = $1
+		ORDER BY issued_at DESC
+		LIMIT 1
+	`
+
+	run := &SolarForecastRun{}
+	var coefficients []byte
+	err := m.DB.QueryRow(query, siteID).Scan(
+		&run.ID, &run.SiteID, &run.IssuedAt, &run.TrainingStart, &run.TrainingEnd,
+		&run.Samples, &run.Clearness, &coefficients, &run.FitRMSE, &run.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(coefficients, &run.Coefficients); err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// GetForecasts 獲取訓練結果中目標時間在 from 之後的預測值
+func (m *SolarForecastModel) GetForecasts(runID int64, from time.Time) ([]SolarForecast, error) {
+	query := `
+		SELECT target_time, power_kw, clear_sky
+		FROM solar_forecasts
+		WHERE run_id = $1 AND target_time >= $2
+		ORDER BY target_time
+	`
+
+	rows, err := m.DB.Query(query, runID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataList []SolarForecast
+	for rows.Next() {
+		var data SolarForecast
+		if err := rows.Scan(&data.TargetTime, &data.PowerKW, &data.ClearSky); err != nil {
+			return nil, err
+		}
+		dataList = append(dataList, data)
+	}
+
+	return dataList, rows.Err()
+}
+
+// GetForecastActuals 獲取時段內各區間的預測值與實際平均交流功率
+// 預測值取目標時間前至少 minLead 發布的最新一次預測，無實際數據的區間不返回
+func (m *SolarForecastModel) GetForecastActuals(siteID string, start, end time.Time, minLead time.Duration) ([]ForecastActual, error) {
+	query := `
+		WITH forecast AS (
+			SELECT DISTINCT ON (target_time) target_time, power_kw
+			FROM solar_forecasts
+			WHERE site_id = $1 AND target_time >= $2 AND target_time < $3
+			  AND issued_at <= target_time - make_interval(secs => $4)
+			ORDER BY target_time, issued_at DESC
+		),
+		actual AS (
+			SELECT to_timestamp(floor(extract(epoch FROM datetime) / 900) * 900) AS bucket,
+			       AVG(ac_total_power) AS power
+			FROM solar_data
+			WHERE site_id = $1 AND datetime >= $2 AND datetime < $3
+			GROUP BY bucket
+		)
+		SELECT f.target_time, f.power_kw, a.power
+		FROM forecast f
+		JOIN actual a ON a.bucket = f.target_time
+		ORDER BY f.target_time
+	`
+
+	rows, err := m.DB.Query(query, siteID, start, end, minLead.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dataList []ForecastActual
+	for rows.Next() {
+		var data ForecastActual
+		if err := rows.Scan(&data.Time, &data.Forecast, &data.Actual); err != nil {
+			return nil, err
+		}
+		dataList = append(dataList, data)
+	}
+
+	return dataList, rows.Err()
+}
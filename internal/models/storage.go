@@ -0,0 +1,220 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// 儲能運轉模式
+const (
+	StorageModeCharge    = "charge"
+	StorageModeDischarge = "discharge"
+	StorageModeIdle      = "idle"
+	StorageModeStandby   = "standby"
+	StorageModeFault     = "fault"
+)
+
+// StorageModes 可接受的儲能運轉模式
+var StorageModes = []string{
+	StorageModeCharge, StorageModeDischarge, StorageModeIdle, StorageModeStandby, StorageModeFault,
+}
+
+// StorageData 儲能數據模型
+type StorageData struct {
+	ID              int       `json:"id"`
+	SiteID          string    `json:"site_id"`
+	DateTime        time.Time `json:"datetime"`
+	SoC             float64   `json:"soc"`              // 電量狀態 %
+	SoH             float64   `json:"soh"`              // 健康狀態 %
+	ChargePower     float64   `json:"charge_power"`     // 充電功率 kW
+	DischargePower  float64   `json:"discharge_power"`  // 放電功率 kW
+	CellTemperature float64   `json:"cell_temperature"` // 電芯溫度 °C
+	AvailableEnergy float64   `json:"available_energy"` // 可用能量 kWh
+	Mode            string    `json:"mode"`
+}
+
+// StorageDataModel 儲能數據模型操作
+type StorageDataModel struct {
+	DB *sql.DB
+}
+
+// NewStorageDataModel 創建儲能數據模型
+func NewStorageDataModel(db *sql.DB) *StorageDataModel {
+	return &StorageDataModel{DB: db}
+}
+
+// GetLatest 獲取最新的儲能數據
+func (m *StorageDataModel) GetLatest(siteID string) (*StorageData, error) {
+	query := `
+		SELECT id, site_id, datetime, soc, soh, charge_power, discharge_power,
+		       cell_temperature, available_energy, mode
+		FROM storage_data
+		WHERE site_id = $1
+		ORDER BY datetime DESC
+		LIMIT 1
+	`
+
+	data := &StorageData{}
+	err := m.DB.QueryRow(query, siteID).Scan(
+		&data.ID, &data.SiteID, &data.DateTime, &data.SoC, &data.SoH,
+		&data.ChargePower, &data.DischargePower, &data.CellTemperature,
+		&data.AvailableEnergy, &data.Mode,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetAllLatest 獲取所有場站最新的儲能數據
+func (m *StorageDataModel) GetAllLatest() ([]StorageData, error) {
+	query := `
+		SELECT DISTINCT ON (site_id)
+		       id, site_id, datetime, soc, soh, charge_power, discharge_power,
+		       cell_temperature, available_energy, mode
+		FROM storage_data
+		ORDER BY site_id, datetime DESC
+	`
+
+	rows, err := m.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanStorageRows(rows)
+}
+
+// GetHistory 獲取歷史數據，依 (datetime, id) 遞減排序
+// after 為上一頁返回的游標，還有下一頁時返回下一頁的游標
+func (m *StorageDataModel) GetHistory(siteID string, startDate, endDate time.Time, limit int, after *Cursor) ([]StorageData, *Cursor, error) {
+	keyset, args := keysetClause(after, "datetime", "id", []interface{}{siteID, startDate, endDate})
+	query := `
+		SELECT id, site_id, datetime, soc, soh, charge_power, discharge_power,
+		       cell_temperature, available_energy, mode
+		FROM storage_data
+		WHERE site_id = $1 AND datetime BETWEEN $2 AND $3` + keyset + `
+		ORDER BY datetime DESC, id DESC
+		LIMIT ` + fmt.Sprint(limit+1)
+
+	rows, err := m.DB.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	dataList, err := scanStorageRows(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(dataList) <= limit {
+		return dataList, nil, nil
+	}
+	dataList = dataList[:limit]
+	last := dataList[limit-1]
+	return dataList, &Cursor{Time: last.DateTime, Key: int64(last.ID)}, nil
+}
+
+// Each 依時間遞增逐筆讀取時段內的數據，不將結果保留在記憶體，fn 返回錯誤時中止
+func (m *StorageDataModel) Each(siteID string, startDate, endDate time.Time, fn func(*StorageData) error) error {
+	query := `
+		SELECT id, site_id, datetime, soc, soh, charge_power, discharge_power,
+		       cell_temperature, available_energy, mode
+		FROM storage_data
+		WHERE site_id = $1 AND datetime BETWEEN $2 AND $3
+		ORDER BY datetime, id
+	`
+
+	rows, err := m.DB.Query(query, siteID, startDate, endDate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var data StorageData
+	for rows.Next() {
+		err := rows.Scan(
+			&data.ID, &data.SiteID, &data.DateTime, &data.SoC, &data.SoH,
+			&data.ChargePower, &data.DischargePower, &data.CellTemperature,
+			&data.AvailableEnergy, &data.Mode,
+		)
+		if err != nil {
+			return err
+		}
+		if err := fn(&data); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// scanStorageRows 掃描儲能數據列
+func scanStorageRows(rows *sql.Rows) ([]StorageData, error) {
+	var dataList []StorageData
+	for rows.Next() {
+		var data StorageData
+		err := rows.Scan(
+			&data.ID, &data.SiteID, &data.DateTime, &data.SoC, &data.SoH,
+			&data.ChargePower, &data.DischargePower, &data.CellTemperature,
+			&data.AvailableEnergy, &data.Mode,
+		)
+		if err != nil {
+			return nil, err
+		}
+		dataList = append(dataList, data)
+	}
+
+	return dataList, rows.Err()
+}
+
+// GetAveragePower 獲取時段內的平均淨功率（正值放電、負值充電）及樣本數
+func (m *StorageDataModel) GetAveragePower(siteID string, start, end time.Time) (float64, int, error) {
+	query := `
+		SELECT COALESCE(AVG(discharge_power - charge_power), 0), COUNT(*)
+		FROM storage_data
+		WHERE site_id = $1 AND datetime >= $2 AND datetime < $3
+	`
+
+	var avg float64
+	var samples int
+	err := m.DB.QueryRow(query, siteID, start, end).Scan(&avg, &samples)
+	return avg, samples, err
+}
+
+// StorageInsertQuery 儲能數據插入語句（同場站同時間則更新）
+const StorageInsertQuery = `
+	INSERT INTO storage_data (
+		site_id, datetime, soc, soh, charge_power, discharge_power,
+		cell_temperature, available_energy, mode
+	) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	ON CONFLICT (site_id, datetime) DO UPDATE SET
+		soc = EXCLUDED.soc,
+		soh = EXCLUDED.soh,
+		charge_power = EXCLUDED.charge_power,
+		discharge_power = EXCLUDED.discharge_power,
+		cell_temperature = EXCLUDED.cell_temperature,
+		available_energy = EXCLUDED.available_energy,
+		mode = EXCLUDED.mode
+`
+
+// InsertArgs 獲取 StorageInsertQuery 的參數
+func (data *StorageData) InsertArgs() []interface{} {
+	return []interface{}{
+		data.SiteID, data.DateTime, data.SoC, data.SoH, data.ChargePower,
+		data.DischargePower, data.CellTemperature, data.AvailableEnergy, data.Mode,
+	}
+}
+
+// Insert 插入儲能數據
+func (m *StorageDataModel) Insert(data *StorageData) error {
+	_, err := m.DB.Exec(StorageInsertQuery, data.InsertArgs()...)
+	return err
+}
@@ -2,25 +2,26 @@ package models
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 )
 
 // TaipowerReserveData 台電備轉資料模型
 type TaipowerReserveData struct {
-	ID              int       `json:"id"`
-	TranDate        time.Time `json:"tran_date"`
-	TranHour        int       `json:"tran_hour"`
-	SRBid           float64   `json:"sr_bid"`
-	SRBidQSE        float64   `json:"sr_bid_qse"`
-	SRBidNonTrade   float64   `json:"sr_bid_nontrade"`
-	SRPrice         float64   `json:"sr_price"`
-	SRPerfPrice1    float64   `json:"sr_perf_price_1"`
-	SRPerfPrice2    float64   `json:"sr_perf_price_2"`
-	SRPerfPrice3    float64   `json:"sr_perf_price_3"`
-	SUPBid          float64   `json:"sup_bid"`
-	SUPBidQSE       float64   `json:"sup_bid_qse"`
-	SUPBidNonTrade  float64   `json:"sup_bid_nontrade"`
-	SUPPrice        float64   `json:"sup_price"`
+	ID             int       `json:"id"`
+	TranDate       time.Time `json:"tran_date"`
+	TranHour       int       `json:"tran_hour"`
+	SRBid          float64   `json:"sr_bid"`
+	SRBidQSE       float64   `json:"sr_bid_qse"`
+	SRBidNonTrade  float64   `json:"sr_bid_nontrade"`
+	SRPrice        float64   `json:"sr_price"`
+	SRPerfPrice1   float64   `json:"sr_perf_price_1"`
+	SRPerfPrice2   float64   `json:"sr_perf_price_2"`
+	SRPerfPrice3   float64   `json:"sr_perf_price_3"`
+	SUPBid         float64   `json:"sup_bid"`
+	SUPBidQSE      float64   `json:"sup_bid_qse"`
+	SUPBidNonTrade float64   `json:"sup_bid_nontrade"`
+	SUPPrice       float64   `json:"sup_price"`
 }
 
 // TaipowerReserveModel 台電備轉資料模型操作
@@ -103,19 +104,99 @@ func (m *TaipowerReserveModel) GetByDate(date time.Time) ([]TaipowerReserveData,
 	return dataList, nil
 }
 
-// GetHistory 獲取歷史備轉資料
-func (m *TaipowerReserveModel) GetHistory(startDate, endDate time.Time, limit int) ([]TaipowerReserveData, error) {
+// GetHistory 獲取歷史備轉資料，依 (tran_date, tran_hour) 遞減排序
+// after 為上一頁返回的游標，還有下一頁時返回下一頁的游標
+func (m *TaipowerReserveModel) GetHistory(startDate, endDate time.Time, limit int, after *Cursor) ([]TaipowerReserveData, *Cursor, error) {
+	keyset, args := keysetClause(after, "tran_date", "tran_hour", []interface{}{startDate, endDate})
 	query := `
 		SELECT id, tran_date, tran_hour, sr_bid, sr_bid_qse, sr_bid_nontrade,
 		       sr_price, sr_perf_price_1, sr_perf_price_2, sr_perf_price_3,
 		       sup_bid, sup_bid_qse, sup_bid_nontrade, sup_price
 		FROM taipower_reserve_data
-		WHERE tran_date BETWEEN $1 AND $2
+		WHERE tran_date BETWEEN $1 AND $2` + keyset + `
 		ORDER BY tran_date DESC, tran_hour DESC
-		LIMIT $3
+		LIMIT ` + fmt.Sprint(limit+1)
+
+	rows, err := m.DB.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var dataList []TaipowerReserveData
+	for rows.Next() {
+		var data TaipowerReserveData
+		err := rows.Scan(
+			&data.ID, &data.TranDate, &data.TranHour,
+			&data.SRBid, &data.SRBidQSE, &data.SRBidNonTrade,
+			&data.SRPrice, &data.SRPerfPrice1, &data.SRPerfPrice2, &data.SRPerfPrice3,
+			&data.SUPBid, &data.SUPBidQSE, &data.SUPBidNonTrade, &data.SUPPrice,
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		dataList = append(dataList, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(dataList) <= limit {
+		return dataList, nil, nil
+	}
+	dataList = dataList[:limit]
+	last := dataList[limit-1]
+	return dataList, &Cursor{Time: last.TranDate, Key: int64(last.TranHour)}, nil
+}
+
+// Each 依日期與小時遞增逐筆讀取區間內的備轉資料，不將結果保留在記憶體，fn 返回錯誤時中止
+func (m *TaipowerReserveModel) Each(startDate, endDate time.Time, fn func(*TaipowerReserveData) error) error {
+	query := `
+		SELECT id, tran_date, tran_hour, sr_bid, sr_bid_qse, sr_bid_nontrade,
+		       sr_price, sr_perf_price_1, sr_perf_price_2, sr_perf_price_3,
+		       sup_bid, sup_bid_qse, sup_bid_nontrade, sup_price
+		FROM taipower_reserve_data
+		WHERE tran_date BETWEEN $1 AND $2
+		ORDER BY tran_date, tran_hour
 	`
 
-	rows, err := m.DB.Query(query, startDate, endDate, limit)
+	rows, err := m.DB.Query(query, startDate, endDate)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var data TaipowerReserveData
+	for rows.Next() {
+		err := rows.Scan(
+			&data.ID, &data.TranDate, &data.TranHour,
+			&data.SRBid, &data.SRBidQSE, &data.SRBidNonTrade,
+			&data.SRPrice, &data.SRPerfPrice1, &data.SRPerfPrice2, &data.SRPerfPrice3,
+			&data.SUPBid, &data.SUPBidQSE, &data.SUPBidNonTrade, &data.SUPPrice,
+		)
+		if err != nil {
+			return err
+		}
+		if err := fn(&data); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// GetRange 獲取日期區間內的備轉資料，依日期與小時排序
+func (m *TaipowerReserveModel) GetRange(startDate, endDate time.Time) ([]TaipowerReserveData, error) {
+	query := `
+		SELECT id, tran_date, tran_hour, sr_bid, sr_bid_qse, sr_bid_nontrade,
+		       sr_price, sr_perf_price_1, sr_perf_price_2, sr_perf_price_3,
+		       sup_bid, sup_bid_qse, sup_bid_nontrade, sup_price
+		FROM taipower_reserve_data
+		WHERE tran_date BETWEEN $1 AND $2
+		ORDER BY tran_date, tran_hour
+	`
+
+	rows, err := m.DB.Query(query, startDate, endDate)
 	if err != nil {
 		return nil, err
 	}
@@ -136,7 +217,7 @@ func (m *TaipowerReserveModel) GetHistory(startDate, endDate time.Time, limit in
 		dataList = append(dataList, data)
 	}
 
-	return dataList, nil
+	return dataList, rows.Err()
 }
 
 // GetByHour 獲取特定時段的備轉資料
@@ -220,6 +301,12 @@ func (m *TaipowerReserveModel) GetStatistics(date time.Time) (map[string]interfa
 
 // Insert 插入備轉資料
 func (m *TaipowerReserveModel) Insert(data *TaipowerReserveData) error {
+	_, err := m.Upsert(data)
+	return err
+}
+
+// Upsert 插入或更新備轉資料，返回是否為新插入的資料
+func (m *TaipowerReserveModel) Upsert(data *TaipowerReserveData) (bool, error) {
 	query := `
 		INSERT INTO taipower_reserve_data (
 			tran_date, tran_hour, sr_bid, sr_bid_qse, sr_bid_nontrade,
@@ -238,16 +325,49 @@ func (m *TaipowerReserveModel) Insert(data *TaipowerReserveData) error {
 			sup_bid_qse = EXCLUDED.sup_bid_qse,
 			sup_bid_nontrade = EXCLUDED.sup_bid_nontrade,
 			sup_price = EXCLUDED.sup_price
+		RETURNING (xmax = 0) AS inserted
 	`
 
-	_, err := m.DB.Exec(query,
+	var inserted bool
+	err := m.DB.QueryRow(query,
 		data.TranDate, data.TranHour,
 		data.SRBid, data.SRBidQSE, data.SRBidNonTrade,
 		data.SRPrice, data.SRPerfPrice1, data.SRPerfPrice2, data.SRPerfPrice3,
 		data.SUPBid, data.SUPBidQSE, data.SUPBidNonTrade, data.SUPPrice,
-	)
+	).Scan(&inserted)
 
-	return err
+	return inserted, err
+}
+
+// GetMissingHours 獲取日期區間內缺少的時段，以日期（YYYY-MM-DD）分組
+func (m *TaipowerReserveModel) GetMissingHours(startDate, endDate time.Time) (map[string][]int, error) {
+	query := `
+		SELECT to_char(d, 'YYYY-MM-DD'), h
+		FROM generate_series($1::date, $2::date, interval '1 day') AS d
+		CROSS JOIN generate_series(0, 23) AS h
+		LEFT JOIN taipower_reserve_data t
+			ON t.tran_date = d::date AND t.tran_hour = h
+		WHERE t.id IS NULL
+		ORDER BY d, h
+	`
+
+	rows, err := m.DB.Query(query, startDate.Format("2006-01-02"), endDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	missing := make(map[string][]int)
+	for rows.Next() {
+		var date string
+		var hour int
+		if err := rows.Scan(&date, &hour); err != nil {
+			return nil, err
+		}
+		missing[date] = append(missing[date], hour)
+	}
+
+	return missing, rows.Err()
 }
 
 // getFloatValue 輔助函數：從 sql.NullFloat64 獲取值
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// 原始遙測記錄狀態
+const (
+	TelemetryAccepted = "accepted"
+	TelemetryRejected = "rejected"
+)
+
+// TelemetryRaw 原始遙測記錄（上傳內容存檔）
+type TelemetryRaw struct {
+	ID         int64           `json:"id"`
+	SiteID     string          `json:"site_id"`
+	DeviceID   string          `json:"device_id"`
+	RecordType string          `json:"record_type"`
+	Timestamp  *time.Time      `json:"timestamp"`
+	Payload    json.RawMessage `json:"payload"`
+	Status     string          `json:"status"`
+	Error      string          `json:"error"`
+	ReceivedAt time.Time       `json:"received_at"`
+}
+
+// TelemetryRawModel 原始遙測記錄模型操作
+type TelemetryRawModel struct {
+	DB *sql.DB
+}
+
+// NewTelemetryRawModel 創建原始遙測記錄模型
+func NewTelemetryRawModel(db *sql.DB) *TelemetryRawModel {
+	return &TelemetryRawModel{DB: db}
+}
+
+// TelemetryRawInsertQuery 原始遙測記錄插入語句
+const TelemetryRawInsertQuery = `
+	INSERT INTO telemetry_raw (
+		site_id, device_id, record_type, timestamp, payload, status, error
+	) VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+// InsertArgs 獲取 TelemetryRawInsertQuery 的參數
+func (data *TelemetryRaw) InsertArgs() []interface{} {
+	return []interface{}{
+		data.SiteID, data.DeviceID, data.RecordType, data.Timestamp,
+		[]byte(data.Payload), data.Status, data.Error,
+	}
+}
+
+// Insert 插入原始遙測記錄
+func (m *TelemetryRawModel) Insert(data *TelemetryRaw) error {
+	query := TelemetryRawInsertQuery + " RETURNING id, received_at"
+	return m.DB.QueryRow(query, data.InsertArgs()...).Scan(&data.ID, &data.ReceivedAt)
+}
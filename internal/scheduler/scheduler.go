@@ -0,0 +1,375 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+	"vpp-go/internal/events"
+
+	"github.com/robfig/cron/v3"
+)
+
+// staleGrace 判定任務過期前額外容許的執行時間
+const staleGrace = 10 * time.Minute
+
+// onceSpec 一次性任務在狀態中顯示的排程
+const onceSpec = "@once"
+
+// maxOnceJobs 保留的一次性任務數，超過時移除最早執行的已結束任務
+const maxOnceJobs = 50
+
+// Job 可排程的任務介面
+type Job interface {
+	// Name 任務名稱（需唯一）
+	Name() string
+	// Run 執行任務，ctx 於排程器停止時取消
+	Run(ctx context.Context) error
+}
+
+// Reporter 可回報執行結果的任務，結果列於任務狀態
+type Reporter interface {
+	// Result 最近一次執行的結果，須可與 Run 並行呼叫
+	Result() interface{}
+}
+
+// Recorder 記錄每次任務的執行結果，於任務結束後同步呼叫，不經事件匯流排因此不會遺漏
+type Recorder interface {
+	RecordJob(job events.JobFinished)
+}
+
+// JobStatus 任務執行狀態
+type JobStatus struct {
+	Name         string      `json:"name"`
+	Spec         string      `json:"spec"`
+	Running      bool        `json:"running"`
+	LastRun      *time.Time  `json:"last_run"`
+	LastSuccess  *time.Time  `json:"last_success"`
+	NextRun      *time.Time  `json:"next_run"`
+	LastError    string      `json:"last_error"`
+	LastDuration string      `json:"last_duration"`
+	RunCount     int         `json:"run_count"`
+	ErrorCount   int         `json:"error_count"`
+	Stale        bool        `json:"stale"` // 連續錯過兩次排程仍未成功
+	Result       interface{} `json:"result,omitempty"`
+}
+
+// entry 已註冊的任務
+type entry struct {
+	id           cron.EntryID
+	job          Job
+	spec         string
+	running      bool
+	lastRun      time.Time
+	lastSuccess  time.Time
+	lastError    error
+	lastDuration time.Duration
+	runCount     int
+	errorCount   int
+	once         bool
+}
+
+// Scheduler 定時任務排程器
+type Scheduler struct {
+	cron   *cron.Cron
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu        sync.RWMutex
+	entries   map[string]*entry
+	startedAt time.Time
+
+	// Events 不為 nil 時於每次任務執行結束後發佈 JobFinished
+	Events *events.Bus
+	// Recorder 不為 nil 時於每次任務執行結束後同步記錄結果（如稽核記錄）
+	Recorder Recorder
+}
+
+// New 創建排程器，cron 表達式依照指定時區解析
+func New(loc *time.Location) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		cron:    cron.New(cron.WithLocation(loc)),
+		ctx:     ctx,
+		cancel:  cancel,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Register 以 cron 表達式註冊任務
+func (s *Scheduler) Register(spec string, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	name := job.Name()
+	if _, exists := s.entries[name]; exists {
+		return fmt.Errorf("任務已存在: %s", name)
+	}
+
+	e := &entry{job: job, spec: spec}
+	id, err := s.cron.AddFunc(spec, func() { s.execute(e) })
+	if err != nil {
+		return fmt.Errorf("無效的排程表達式 %q: %w", spec, err)
+	}
+	e.id = id
+	s.entries[name] = e
+
+	log.Printf("已註冊排程任務: %s (%s)\n", name, spec)
+	return nil
+}
+
+// Start 啟動排程器
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	s.startedAt = time.Now()
+	s.mu.Unlock()
+
+	s.cron.Start()
+	log.Println("排程器已啟動")
+}
+
+// Stop 停止排程器，取消執行中的任務並等待其結束或 ctx 逾時
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cron.Stop()
+	// 持有鎖取消，確保 execute 不會在 Wait 開始後才 Add
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("排程器已停止")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待任務結束逾時: %w", ctx.Err())
+	}
+}
+
+// RunNow 立即在背景執行指定任務
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.RLock()
+	e, ok := s.entries[name]
+	s.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("找不到任務: %s", name)
+	}
+
+	go s.execute(e)
+	return nil
+}
+
+// Submit 在背景執行一次性任務（如回補），任務名稱即任務ID，可由 Status、Job 查詢進度與結果
+// 排程器停止時取消
+func (s *Scheduler) Submit(job Job) error {
+	s.mu.Lock()
+	name := job.Name()
+	if _, exists := s.entries[name]; exists {
+		s.mu.Unlock()
+		return fmt.Errorf("任務已存在: %s", name)
+	}
+	if s.ctx.Err() != nil {
+		s.mu.Unlock()
+		return errors.New("排程器已停止")
+	}
+	e := &entry{job: job, spec: onceSpec, once: true}
+	s.entries[name] = e
+	s.pruneOnce()
+	s.mu.Unlock()
+
+	go s.execute(e)
+	return nil
+}
+
+// pruneOnce 一次性任務超過上限時移除最早執行的已結束任務，呼叫者需持有寫鎖
+func (s *Scheduler) pruneOnce() {
+	var finished []*entry
+	count := 0
+	for _, e := range s.entries {
+		if !e.once {
+			continue
+		}
+		count++
+		if !e.running && e.runCount > 0 {
+			finished = append(finished, e)
+		}
+	}
+
+	sort.Slice(finished, func(i, j int) bool {
+		return finished[i].lastRun.Before(finished[j].lastRun)
+	})
+	for _, e := range finished {
+		if count <= maxOnceJobs {
+			break
+		}
+		delete(s.entries, e.job.Name())
+		count--
+	}
+}
+
+// Status 獲取所有任務的執行狀態
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	statuses := make([]JobStatus, 0, len(s.entries))
+	for name, e := range s.entries {
+		statuses = append(statuses, s.status(name, e, now))
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// Job 獲取單一任務的執行狀態
+func (s *Scheduler) Job(name string) (JobStatus, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return JobStatus{}, false
+	}
+	return s.status(name, e, time.Now()), true
+}
+
+// status 組成任務狀態，呼叫者需持有讀鎖
+func (s *Scheduler) status(name string, e *entry, now time.Time) JobStatus {
+	status := JobStatus{
+		Name:       name,
+		Spec:       e.spec,
+		Running:    e.running,
+		RunCount:   e.runCount,
+		ErrorCount: e.errorCount,
+	}
+	if !e.lastRun.IsZero() {
+		lastRun := e.lastRun
+		status.LastRun = &lastRun
+		status.LastDuration = e.lastDuration.String()
+	}
+	if !e.lastSuccess.IsZero() {
+		lastSuccess := e.lastSuccess
+		status.LastSuccess = &lastSuccess
+	}
+	if e.lastError != nil {
+		status.LastError = e.lastError.Error()
+	}
+	if r, ok := e.job.(Reporter); ok {
+		status.Result = r.Result()
+	}
+	if e.once {
+		return status
+	}
+
+	cronEntry := s.cron.Entry(e.id)
+	if !cronEntry.Next.IsZero() {
+		next := cronEntry.Next
+		status.NextRun = &next
+	}
+	if cronEntry.Schedule != nil {
+		status.Stale = s.isStale(e, cronEntry.Schedule, now)
+	}
+	return status
+}
+
+// execute 執行任務並記錄結果，同一任務不會重疊執行
+func (s *Scheduler) execute(e *entry) {
+	s.mu.Lock()
+	if e.running {
+		s.mu.Unlock()
+		log.Printf("任務仍在執行中，略過本次排程: %s\n", e.job.Name())
+		return
+	}
+	if s.ctx.Err() != nil {
+		s.mu.Unlock()
+		return
+	}
+	e.running = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+	defer s.wg.Done()
+
+	start := time.Now()
+	err := s.runSafely(e.job)
+	duration := time.Since(start)
+
+	s.mu.Lock()
+	e.running = false
+	e.lastRun = start
+	e.lastDuration = duration
+	e.lastError = err
+	e.runCount++
+	if err != nil {
+		e.errorCount++
+	} else {
+		e.lastSuccess = time.Now()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Printf("排程任務執行失敗: %s, 錯誤: %v\n", e.job.Name(), err)
+	}
+	finished := events.JobFinished{Job: e.job.Name(), Start: start, Duration: duration, Err: err}
+	if s.Recorder != nil {
+		s.Recorder.RecordJob(finished)
+	}
+	s.Events.Publish(finished)
+}
+
+// isStale 自上次成功（或排程器啟動）後的第二次排程時間已過仍未成功，視為過期
+// 呼叫者需持有讀鎖
+func (s *Scheduler) isStale(e *entry, schedule cron.Schedule, now time.Time) bool {
+	since := e.lastSuccess
+	if since.IsZero() {
+		since = s.startedAt
+	}
+	if since.IsZero() {
+		return false
+	}
+
+	deadline := schedule.Next(schedule.Next(since))
+	return !deadline.IsZero() && now.After(deadline.Add(staleGrace))
+}
+
+// runSafely 執行任務並將 panic 轉為錯誤
+func (s *Scheduler) runSafely(job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("任務發生 panic: %v", r)
+		}
+	}()
+	return job.Run(s.ctx)
+}
+
+// funcJob 以函數實作的任務
+type funcJob struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewFuncJob 以函數創建任務
+func NewFuncJob(name string, fn func(ctx context.Context) error) Job {
+	return &funcJob{name: name, fn: fn}
+}
+
+// Name 任務名稱
+func (j *funcJob) Name() string {
+	return j.name
+}
+
+// Run 執行任務
+func (j *funcJob) Run(ctx context.Context) error {
+	return j.fn(ctx)
+}
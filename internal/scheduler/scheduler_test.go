@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+	"vpp-go/internal/events"
+)
+
+// testJob 返回固定錯誤的任務
+type testJob struct {
+	name string
+	err  error
+}
+
+func (j testJob) Name() string                  { return j.name }
+func (j testJob) Run(ctx context.Context) error { return j.err }
+
+// testRecorder 記錄收到的任務結果
+type testRecorder struct {
+	mu   sync.Mutex
+	jobs []events.JobFinished
+}
+
+func (r *testRecorder) RecordJob(job events.JobFinished) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs = append(r.jobs, job)
+}
+
+func TestRecorder(t *testing.T) {
+	recorder := &testRecorder{}
+	s := New(time.UTC)
+	s.Recorder = recorder
+
+	// 大量任務同時結束時每次執行都須記錄，不受事件緩衝影響
+	failure := errors.New("連線失敗")
+	const count = 200
+	for i := 0; i < count; i++ {
+		job := testJob{name: "backfill:" + string(rune('a'+i%26)) + string(rune('0'+i/26))}
+		if i%2 == 1 {
+			job.err = failure
+		}
+		if err := s.Submit(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer s.Stop(context.Background())
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		recorder.mu.Lock()
+		n := len(recorder.jobs)
+		recorder.mu.Unlock()
+		if n == count {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("記錄 %d 次, 預期 %d", n, count)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	failed := 0
+	for _, job := range recorder.jobs {
+		if errors.Is(job.Err, failure) {
+			failed++
+		}
+	}
+	if failed != count/2 {
+		t.Errorf("失敗 %d 次, 預期 %d", failed, count/2)
+	}
+}
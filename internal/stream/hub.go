@@ -0,0 +1,207 @@
+package stream
+
+import (
+	"sync"
+	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/events"
+)
+
+// 推送的數據種類
+const (
+	MetricSolar   = events.MetricSolar
+	MetricLoad    = events.MetricLoad
+	MetricStorage = events.MetricStorage
+)
+
+// IsValidMetric 檢查數據種類
+func IsValidMetric(metric string) bool {
+	switch metric {
+	case MetricSolar, MetricLoad, MetricStorage:
+		return true
+	}
+	return false
+}
+
+// Message 推送給客戶端的單筆數據
+type Message struct {
+	ID     uint64      `json:"id"` // 本實例內遞增，用於斷線重連後補送
+	Metric string      `json:"metric"`
+	SiteID string      `json:"site_id"`
+	Time   time.Time   `json:"time"`
+	Data   interface{} `json:"data"`
+}
+
+// Filter 訂閱條件，空集合表示不限
+type Filter struct {
+	Sites   map[string]bool
+	Metrics map[string]bool
+}
+
+// Match 檢查訊息是否符合訂閱條件
+func (f Filter) Match(m *Message) bool {
+	if len(f.Sites) > 0 && !f.Sites[m.SiteID] {
+		return false
+	}
+	if len(f.Metrics) > 0 && !f.Metrics[m.Metric] {
+		return false
+	}
+	return true
+}
+
+// Subscription 單一連線的訂閱
+type Subscription struct {
+	hub    *Hub
+	filter Filter
+	ch     chan Message
+	// overflow 待送訊息超過上限時設為 true 並關閉通道
+	overflow bool
+	closed   bool
+}
+
+// C 返回訊息通道，通道關閉表示訂閱已結束（逾量或 Hub 關閉）
+func (s *Subscription) C() <-chan Message {
+	return s.ch
+}
+
+// Overflowed 返回訂閱是否因客戶端跟不上而被中斷
+func (s *Subscription) Overflowed() bool {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	return s.overflow
+}
+
+// Close 取消訂閱
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// Hub 將新寫入的數據分送給訂閱的連線，並保留最近的訊息供重連補送
+// 只涵蓋本實例寫入的數據，多實例部署時各實例的連線只會收到該實例的數據
+type Hub struct {
+	mu           sync.Mutex
+	seq          uint64
+	replay       []Message // 環狀緩衝
+	next         int
+	size         int
+	clientBuffer int
+	subs         map[*Subscription]struct{}
+}
+
+// NewHub 創建推送中心
+func NewHub(cfg config.StreamConfig) *Hub {
+	replaySize := cfg.ReplaySize
+	if replaySize <= 0 {
+		replaySize = 1
+	}
+	clientBuffer := cfg.ClientBuffer
+	if clientBuffer <= 0 {
+		clientBuffer = 1
+	}
+	return &Hub{
+		replay:       make([]Message, replaySize),
+		clientBuffer: clientBuffer,
+		subs:         make(map[*Subscription]struct{}),
+	}
+}
+
+// Attach 訂閱事件匯流排上新寫入的數據
+// 緩衝已滿時發佈端等待至 BlockTimeout，逾時丟棄的數據不會推送也無法補送
+func (h *Hub) Attach(bus *events.Bus, cfg config.EventsConfig) error {
+	return bus.Subscribe("stream", events.SubscribeOptions{
+		Kinds:        []events.Kind{events.KindSampleStored},
+		Buffer:       cfg.Buffer,
+		Policy:       events.Block,
+		BlockTimeout: cfg.BlockTimeout,
+	}, h.handleEvent)
+}
+
+// handleEvent 將數據寫入事件轉為推送訊息
+func (h *Hub) handleEvent(e events.Event) {
+	if sample, ok := e.(events.SampleStored); ok {
+		h.Publish(sample.Metric, sample.SiteID, sample.Time, sample.Data)
+	}
+}
+
+// Publish 發佈一筆數據，不會因客戶端緩慢而阻塞
+func (h *Hub) Publish(metric, siteID string, t time.Time, data interface{}) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.seq++
+	msg := Message{ID: h.seq, Metric: metric, SiteID: siteID, Time: t, Data: data}
+	h.replay[h.next] = msg
+	h.next = (h.next + 1) % len(h.replay)
+	if h.size < len(h.replay) {
+		h.size++
+	}
+
+	for sub := range h.subs {
+		if !sub.filter.Match(&msg) {
+			continue
+		}
+		select {
+		case sub.ch <- msg:
+		default:
+			// 客戶端跟不上時中斷連線，由客戶端以最後收到的ID重連補送
+			sub.overflow = true
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe 建立訂閱；lastID 大於 0 時先補送其後符合條件的訊息
+// 返回的 bool 為 false 表示 lastID 已不在保留範圍內（或服務已重啟），客戶端須重新載入完整數據
+func (h *Hub) Subscribe(filter Filter, lastID uint64) (*Subscription, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub := &Subscription{hub: h, filter: filter}
+	complete := true
+
+	var missed []Message
+	if lastID > 0 {
+		oldest := h.seq - uint64(h.size) + 1
+		if lastID > h.seq || lastID+1 < oldest {
+			complete = false
+		} else {
+			for i := 0; i < h.size; i++ {
+				msg := h.replay[(h.next-h.size+i+len(h.replay))%len(h.replay)]
+				if msg.ID > lastID && filter.Match(&msg) {
+					missed = append(missed, msg)
+				}
+			}
+		}
+	}
+
+	bufferSize := h.clientBuffer
+	if len(missed) > bufferSize {
+		bufferSize = len(missed)
+	}
+	sub.ch = make(chan Message, bufferSize)
+	for _, msg := range missed {
+		sub.ch <- msg
+	}
+
+	h.subs[sub] = struct{}{}
+	return sub, complete
+}
+
+// remove 移除訂閱並關閉通道，呼叫端須持有鎖
+func (h *Hub) remove(sub *Subscription) {
+	if sub.closed {
+		return
+	}
+	sub.closed = true
+	delete(h.subs, sub)
+	close(sub.ch)
+}
+
+// Subscribers 目前的訂閱數
+func (h *Hub) Subscribers() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
@@ -0,0 +1,90 @@
+package telemetry
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"vpp-go/internal/database"
+	"vpp-go/internal/models"
+)
+
+// maxLineSize JSON lines 單行長度上限
+const maxLineSize = 1 << 20
+
+// ParseNDJSON 解析 JSON lines 格式的記錄，每行一筆 Record
+// 無法解析的行仍保留位置，於驗證時以錯誤回報，不影響其他行
+func ParseNDJSON(r io.Reader, siteID, deviceID string) (*Envelope, error) {
+	env := &Envelope{
+		SchemaVersion: SchemaVersion,
+		SiteID:        siteID,
+		DeviceID:      deviceID,
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var rec Record
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			rec = Record{parseErr: fmt.Errorf("第 %d 行不是有效的JSON", line)}
+		}
+		env.Records = append(env.Records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("讀取第 %d 行失敗: %w", line+1, err)
+	}
+
+	return env, nil
+}
+
+// BuildBatch 驗證所有記錄並產生批次寫入語句，並返回通過驗證的記錄
+// 通過驗證的記錄寫入對應數據表，所有可解析的記錄都會存檔原始內容
+func BuildBatch(env *Envelope, now time.Time) ([]RecordResult, []database.BatchStatement, []*Prepared) {
+	results := make([]RecordResult, len(env.Records))
+	var solarRows, loadRows, storageRows, rawRows [][]interface{}
+	var accepted []*Prepared
+
+	for idx := range env.Records {
+		rec := &env.Records[idx]
+		result := RecordResult{Index: idx, Type: rec.Type, Status: models.TelemetryAccepted}
+
+		prepared, errs := Prepare(env, rec, now)
+		if len(errs) > 0 {
+			result.Status = models.TelemetryRejected
+			result.Errors = errs
+		} else {
+			accepted = append(accepted, prepared)
+			switch prepared.Type {
+			case RecordSolar:
+				solarRows = append(solarRows, prepared.Solar().InsertArgs())
+			case RecordLoad:
+				loadRows = append(loadRows, prepared.Load().InsertArgs())
+			case RecordBattery:
+				storageRows = append(storageRows, prepared.Storage().InsertArgs())
+			}
+		}
+
+		if rec.parseErr == nil {
+			rawRows = append(rawRows, rawRecord(env, rec, prepared, result).InsertArgs())
+		}
+		results[idx] = result
+	}
+
+	statements := []database.BatchStatement{
+		{Query: models.SolarInsertQuery, DataList: solarRows},
+		{Query: models.LoadInsertQuery, DataList: loadRows},
+		{Query: models.StorageInsertQuery, DataList: storageRows},
+		{Query: models.TelemetryRawInsertQuery, DataList: rawRows},
+	}
+	return results, statements, accepted
+}
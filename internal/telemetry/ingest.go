@@ -0,0 +1,134 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+	"vpp-go/internal/events"
+	"vpp-go/internal/models"
+)
+
+// RecordResult 單筆記錄的處理結果
+type RecordResult struct {
+	Index  int          `json:"index"`
+	Type   string       `json:"type"`
+	Status string       `json:"status"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// Ingester 將上傳記錄寫入對應的數據表並存檔原始內容
+type Ingester struct {
+	Solar   *models.SolarDataModel
+	Load    *models.LoadDataModel
+	Storage *models.StorageDataModel
+	Raw     *models.TelemetryRawModel
+	// Events 不為 nil 時為寫入成功的記錄發佈數據寫入事件
+	Events *events.Bus
+}
+
+// Ingest 逐筆驗證並寫入記錄，單筆失敗不影響其他記錄
+func (i *Ingester) Ingest(env *Envelope) []RecordResult {
+	now := time.Now()
+	results := make([]RecordResult, len(env.Records))
+
+	for idx := range env.Records {
+		rec := &env.Records[idx]
+		result := RecordResult{Index: idx, Type: rec.Type, Status: models.TelemetryAccepted}
+
+		prepared, errs := Prepare(env, rec, now)
+		if len(errs) == 0 {
+			if err := i.store(prepared); err != nil {
+				log.Printf("遙測數據保存失敗 - 場站: %s, 類型: %s, 錯誤: %v\n", prepared.SiteID, prepared.Type, err)
+				errs = []FieldError{{Field: "record", Message: "數據保存失敗"}}
+			} else {
+				i.Publish(prepared)
+			}
+		}
+		if len(errs) > 0 {
+			result.Status = models.TelemetryRejected
+			result.Errors = errs
+		}
+
+		i.archive(env, rec, prepared, result)
+		results[idx] = result
+	}
+
+	return results
+}
+
+// store 依記錄類型寫入數據表
+func (i *Ingester) store(p *Prepared) error {
+	switch p.Type {
+	case RecordSolar:
+		return i.Solar.Insert(p.Solar())
+	case RecordLoad:
+		return i.Load.Insert(p.Load())
+	case RecordBattery:
+		return i.Storage.Insert(p.Storage())
+	default:
+		// 尚無對應數據表的類型僅存檔原始內容
+		return nil
+	}
+}
+
+// Publish 為已寫入的記錄發佈數據寫入事件
+func (i *Ingester) Publish(prepared ...*Prepared) {
+	if i.Events == nil {
+		return
+	}
+	for _, p := range prepared {
+		event := events.SampleStored{SiteID: p.SiteID, Time: p.Timestamp, Source: events.SourceUpload}
+		switch p.Type {
+		case RecordSolar:
+			event.Metric, event.Data = events.MetricSolar, p.Solar()
+		case RecordLoad:
+			event.Metric, event.Data = events.MetricLoad, p.Load()
+		case RecordBattery:
+			event.Metric, event.Data = events.MetricStorage, p.Storage()
+		default:
+			continue
+		}
+		i.Events.Publish(event)
+	}
+}
+
+// archive 存檔原始記錄，存檔失敗只記錄日誌
+func (i *Ingester) archive(env *Envelope, rec *Record, p *Prepared, result RecordResult) {
+	if rec.parseErr != nil {
+		return
+	}
+	if err := i.Raw.Insert(rawRecord(env, rec, p, result)); err != nil {
+		log.Printf("原始遙測記錄存檔失敗: %v\n", err)
+	}
+}
+
+// rawRecord 建立原始記錄存檔
+func rawRecord(env *Envelope, rec *Record, p *Prepared, result RecordResult) *models.TelemetryRaw {
+	raw := &models.TelemetryRaw{
+		SiteID:     rec.SiteID,
+		DeviceID:   env.DeviceID,
+		RecordType: rec.Type,
+		Status:     result.Status,
+	}
+	if raw.SiteID == "" {
+		raw.SiteID = env.SiteID
+	}
+
+	if p != nil {
+		raw.Payload = p.Raw
+		raw.Timestamp = &p.Timestamp
+	} else {
+		raw.Payload, _ = json.Marshal(rec)
+	}
+
+	if len(result.Errors) > 0 {
+		messages := make([]string, len(result.Errors))
+		for j, e := range result.Errors {
+			messages[j] = e.Error()
+		}
+		raw.Error = strings.Join(messages, "; ")
+	}
+
+	return raw
+}
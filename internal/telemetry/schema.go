@@ -0,0 +1,255 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+	"vpp-go/internal/config"
+	"vpp-go/internal/models"
+)
+
+// SchemaVersion 目前的上傳格式版本
+const SchemaVersion = 2
+
+// maxClockSkew 允許的設備時鐘超前量
+const maxClockSkew = 5 * time.Minute
+
+// 記錄類型
+const (
+	RecordSolar   = "solar"
+	RecordLoad    = "load"
+	RecordBattery = "battery"
+)
+
+// Envelope 版本化上傳格式
+type Envelope struct {
+	SchemaVersion int      `json:"schema_version"`
+	SiteID        string   `json:"site_id"`
+	DeviceID      string   `json:"device_id"`
+	Records       []Record `json:"records"`
+}
+
+// Record 單筆量測記錄
+type Record struct {
+	Type         string                 `json:"type"`
+	SiteID       string                 `json:"site_id,omitempty"` // 未填時沿用 Envelope.SiteID
+	Timestamp    string                 `json:"timestamp"`
+	Measurements map[string]Measurement `json:"measurements"`
+	Mode         string                 `json:"mode,omitempty"` // 儲能運轉模式
+
+	parseErr error // 批次上傳時該行無法解析的原因
+}
+
+// Measurement 帶單位的量測值
+type Measurement struct {
+	Value *float64 `json:"value"`
+	Unit  string   `json:"unit"`
+}
+
+// FieldError 欄位驗證錯誤
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error 實作 error 介面
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// fieldSpec 量測欄位規格
+type fieldSpec struct {
+	dim      dimension
+	min, max float64
+	required bool
+}
+
+// recordSpecs 各記錄類型可接受的量測欄位
+var recordSpecs = map[string]map[string]fieldSpec{
+	RecordSolar: {
+		"daily_generation":             {dim: dimEnergy, min: 0, max: 1e6},
+		"solar_radiation":              {dim: dimIrradiance, min: 0, max: 2000},
+		"ac_avg_voltage":               {dim: dimVoltage, min: 0, max: 1e5},
+		"ac_total_power":               {dim: dimPower, min: 0, max: 1e5, required: true},
+		"ac_total_current":             {dim: dimCurrent, min: 0, max: 1e5},
+		"dc_avg_voltage":               {dim: dimVoltage, min: 0, max: 1e5},
+		"dc_total_power":               {dim: dimPower, min: 0, max: 1e5},
+		"dc_total_current":             {dim: dimCurrent, min: 0, max: 1e5},
+		"module_temperature":           {dim: dimTemperature, min: -40, max: 120},
+		"total_accumulated_generation": {dim: dimEnergy, min: 0, max: 1e10},
+		"co2_reduction":                {dim: dimMass, min: 0, max: 1e10},
+	},
+	RecordLoad: {
+		"load_value": {dim: dimPower, min: -1e5, max: 1e5, required: true},
+	},
+	RecordBattery: {
+		"soc":              {dim: dimPercent, min: 0, max: 100, required: true},
+		"soh":              {dim: dimPercent, min: 0, max: 100},
+		"power":            {dim: dimPower, min: -1e5, max: 1e5}, // 正值放電，負值充電
+		"cell_temperature": {dim: dimTemperature, min: -40, max: 120},
+		"available_energy": {dim: dimEnergy, min: 0, max: 1e6},
+	},
+}
+
+// Prepared 驗證並換算後的記錄
+type Prepared struct {
+	Type      string
+	SiteID    string
+	Timestamp time.Time
+	Values    map[string]float64 // 標準單位的量測值
+	Mode      string
+	Raw       json.RawMessage
+}
+
+// Solar 轉為太陽能數據
+func (p *Prepared) Solar() *models.SolarData {
+	return &models.SolarData{
+		SiteID:                     p.SiteID,
+		DateTime:                   p.Timestamp,
+		DailyGeneration:            p.Values["daily_generation"],
+		SolarRadiation:             p.Values["solar_radiation"],
+		ACAverageVoltage:           p.Values["ac_avg_voltage"],
+		ACTotalPower:               p.Values["ac_total_power"],
+		ACTotalCurrent:             p.Values["ac_total_current"],
+		DCAverageVoltage:           p.Values["dc_avg_voltage"],
+		DCTotalPower:               p.Values["dc_total_power"],
+		DCTotalCurrent:             p.Values["dc_total_current"],
+		ModuleTemperature:          p.Values["module_temperature"],
+		TotalAccumulatedGeneration: p.Values["total_accumulated_generation"],
+		CO2Reduction:               p.Values["co2_reduction"],
+	}
+}
+
+// Load 轉為負載數據
+func (p *Prepared) Load() *models.LoadData {
+	return &models.LoadData{
+		SiteID:    p.SiteID,
+		DateTime:  p.Timestamp,
+		LoadValue: p.Values["load_value"],
+	}
+}
+
+// Storage 轉為儲能數據，未提供運轉模式時依功率正負判斷
+func (p *Prepared) Storage() *models.StorageData {
+	power := p.Values["power"]
+	data := &models.StorageData{
+		SiteID:          p.SiteID,
+		DateTime:        p.Timestamp,
+		SoC:             p.Values["soc"],
+		SoH:             p.Values["soh"],
+		ChargePower:     math.Max(-power, 0),
+		DischargePower:  math.Max(power, 0),
+		CellTemperature: p.Values["cell_temperature"],
+		AvailableEnergy: p.Values["available_energy"],
+		Mode:            p.Mode,
+	}
+
+	if data.Mode == "" {
+		switch {
+		case power > 0:
+			data.Mode = models.StorageModeDischarge
+		case power < 0:
+			data.Mode = models.StorageModeCharge
+		default:
+			data.Mode = models.StorageModeIdle
+		}
+	}
+	return data
+}
+
+// Prepare 驗證記錄並將量測值換算為標準單位
+func Prepare(env *Envelope, rec *Record, now time.Time) (*Prepared, []FieldError) {
+	if rec.parseErr != nil {
+		return nil, []FieldError{{"record", rec.parseErr.Error()}}
+	}
+
+	var errs []FieldError
+
+	siteID := rec.SiteID
+	if siteID == "" {
+		siteID = env.SiteID
+	}
+	if !config.IsValidSite(siteID) {
+		errs = append(errs, FieldError{"site_id", fmt.Sprintf("無效的場站ID %q", siteID)})
+	}
+
+	timestamp, err := time.Parse(time.RFC3339, rec.Timestamp)
+	switch {
+	case rec.Timestamp == "":
+		errs = append(errs, FieldError{"timestamp", "缺少時間戳"})
+	case err != nil:
+		errs = append(errs, FieldError{"timestamp", "時間戳須為 RFC3339 格式"})
+	case timestamp.After(now.Add(maxClockSkew)):
+		errs = append(errs, FieldError{"timestamp", "時間戳晚於伺服器時間"})
+	}
+
+	specs, ok := recordSpecs[rec.Type]
+	if !ok {
+		errs = append(errs, FieldError{"type", fmt.Sprintf("不支援的記錄類型 %q", rec.Type)})
+		return nil, errs
+	}
+
+	values := make(map[string]float64, len(rec.Measurements))
+	for name, m := range rec.Measurements {
+		field := "measurements." + name
+		spec, ok := specs[name]
+		if !ok {
+			errs = append(errs, FieldError{field, "未知的量測欄位"})
+			continue
+		}
+		if m.Value == nil || math.IsNaN(*m.Value) || math.IsInf(*m.Value, 0) {
+			errs = append(errs, FieldError{field, "缺少有效數值"})
+			continue
+		}
+		if m.Unit == "" {
+			errs = append(errs, FieldError{field, "缺少單位"})
+			continue
+		}
+
+		value, err := toCanonical(*m.Value, m.Unit, spec.dim)
+		if err != nil {
+			errs = append(errs, FieldError{field, err.Error()})
+			continue
+		}
+		if value < spec.min || value > spec.max {
+			errs = append(errs, FieldError{field, fmt.Sprintf("數值 %g 超出範圍 [%g, %g]", value, spec.min, spec.max)})
+			continue
+		}
+		values[name] = value
+	}
+
+	if rec.Type == RecordBattery && rec.Mode != "" && !isStorageMode(rec.Mode) {
+		errs = append(errs, FieldError{"mode", fmt.Sprintf("不支援的運轉模式 %q", rec.Mode)})
+	}
+
+	for name, spec := range specs {
+		if _, ok := rec.Measurements[name]; spec.required && !ok {
+			errs = append(errs, FieldError{"measurements." + name, "缺少必填欄位"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	raw, _ := json.Marshal(rec)
+	return &Prepared{
+		Type:      rec.Type,
+		SiteID:    siteID,
+		Timestamp: timestamp,
+		Values:    values,
+		Mode:      rec.Mode,
+		Raw:       raw,
+	}, nil
+}
+
+// isStorageMode 是否為可接受的儲能運轉模式
+func isStorageMode(mode string) bool {
+	for _, m := range models.StorageModes {
+		if mode == m {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package telemetry
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dimension 物理量類型
+type dimension string
+
+const (
+	dimPower       dimension = "功率"   // kW
+	dimEnergy      dimension = "能量"   // kWh
+	dimVoltage     dimension = "電壓"   // V
+	dimCurrent     dimension = "電流"   // A
+	dimIrradiance  dimension = "日照強度" // W/m²
+	dimTemperature dimension = "溫度"   // °C
+	dimPercent     dimension = "百分比"  // %
+	dimMass        dimension = "質量"   // kg
+)
+
+// unitConversion 單位換算（轉為標準單位：value*factor + offset）
+type unitConversion struct {
+	dim    dimension
+	factor float64
+	offset float64
+}
+
+// units 可接受的單位，區分大小寫（mW 與 MW 相差十億倍，不可混用）
+// 不在表中的寫法一律拒絕
+var units = map[string]unitConversion{
+	"W":     {dimPower, 0.001, 0},
+	"kW":    {dimPower, 1, 0},
+	"MW":    {dimPower, 1000, 0},
+	"Wh":    {dimEnergy, 0.001, 0},
+	"kWh":   {dimEnergy, 1, 0},
+	"MWh":   {dimEnergy, 1000, 0},
+	"mV":    {dimVoltage, 0.001, 0},
+	"V":     {dimVoltage, 1, 0},
+	"kV":    {dimVoltage, 1000, 0},
+	"mA":    {dimCurrent, 0.001, 0},
+	"A":     {dimCurrent, 1, 0},
+	"W/m2":  {dimIrradiance, 1, 0},
+	"W/m²":  {dimIrradiance, 1, 0},
+	"kW/m2": {dimIrradiance, 1000, 0},
+	"kW/m²": {dimIrradiance, 1000, 0},
+	"C":     {dimTemperature, 1, 0},
+	"°C":    {dimTemperature, 1, 0},
+	"K":     {dimTemperature, 1, -273.15},
+	"F":     {dimTemperature, 5.0 / 9.0, -32 * 5.0 / 9.0},
+	"°F":    {dimTemperature, 5.0 / 9.0, -32 * 5.0 / 9.0},
+	"%":     {dimPercent, 1, 0},
+	"g":     {dimMass, 0.001, 0},
+	"kg":    {dimMass, 1, 0},
+	"t":     {dimMass, 1000, 0},
+}
+
+// toCanonical 將數值換算為該物理量的標準單位
+func toCanonical(value float64, unit string, want dimension) (float64, error) {
+	conv, ok := units[strings.TrimSpace(unit)]
+	if !ok {
+		return 0, fmt.Errorf("不支援的單位 %q", unit)
+	}
+	if conv.dim != want {
+		return 0, fmt.Errorf("單位 %q 不是%s單位", unit, want)
+	}
+	return value*conv.factor + conv.offset, nil
+}
@@ -0,0 +1,61 @@
+package telemetry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestToCanonical(t *testing.T) {
+	tests := []struct {
+		value float64
+		unit  string
+		dim   dimension
+		want  float64
+	}{
+		{1500, "W", dimPower, 1.5},
+		{20, "kW", dimPower, 20},
+		{1.2, "MW", dimPower, 1200},
+		{500, "Wh", dimEnergy, 0.5},
+		{3, "MWh", dimEnergy, 3000},
+		{230000, "mV", dimVoltage, 230},
+		{11.4, "kV", dimVoltage, 11400},
+		{2500, "mA", dimCurrent, 2.5},
+		{0.8, "kW/m²", dimIrradiance, 800},
+		{298.15, "K", dimTemperature, 25},
+		{77, "°F", dimTemperature, 25},
+		{20, " kW ", dimPower, 20},
+	}
+
+	for _, tt := range tests {
+		got, err := toCanonical(tt.value, tt.unit, tt.dim)
+		if err != nil {
+			t.Errorf("toCanonical(%g, %q) 錯誤: %v", tt.value, tt.unit, err)
+			continue
+		}
+		if math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("toCanonical(%g, %q) = %g, 預期 %g", tt.value, tt.unit, got, tt.want)
+		}
+	}
+}
+
+func TestToCanonicalRejects(t *testing.T) {
+	tests := []struct {
+		unit string
+		dim  dimension
+	}{
+		{"mW", dimPower},   // 毫瓦不可當作百萬瓦
+		{"mw", dimPower},   // 大小寫不符
+		{"KW", dimPower},   // 大小寫不符
+		{"kwh", dimEnergy}, // 大小寫不符
+		{"MV", dimVoltage}, // 百萬伏特不在表中
+		{"kA", dimCurrent}, // 不在表中
+		{"kWh", dimPower},  // 物理量不符
+		{"", dimPower},
+	}
+
+	for _, tt := range tests {
+		if _, err := toCanonical(1, tt.unit, tt.dim); err == nil {
+			t.Errorf("單位 %q 應被拒絕", tt.unit)
+		}
+	}
+}